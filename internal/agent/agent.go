@@ -0,0 +1,175 @@
+// Package agent keeps the master password in memory so that non-interactive
+// callers (git, docker, kubectl, ...) can use the vault without prompting.
+//
+// The agent listens on a Unix socket that only the current user can open and
+// answers one request per connection:
+//
+//	GET   -> "OK <base64 master password>" or "ERR <message>"
+//	LOCK  -> "OK", after which the agent forgets the password and exits
+//
+// The agent also exits on its own once its timeout expires.
+package agent
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const socketFile = ".remembrall-agent.sock"
+
+// ErrLocked is returned when no agent is running
+var ErrLocked = errors.New("the vault is locked, run 'remembrall unlock' first")
+
+// SocketPath returns the path of the agent socket. REMEMBRALL_AGENT_SOCK
+// overrides the default location in the home directory.
+func SocketPath() (string, error) {
+	if path := os.Getenv("REMEMBRALL_AGENT_SOCK"); path != "" {
+		return path, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, socketFile), nil
+}
+
+// Listen creates the agent socket with 0600 permissions, replacing a stale
+// socket left behind by an agent that did not shut down cleanly
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("an agent is already listening on '%s'", path)
+	}
+	os.Remove(path)
+
+	// Create the socket without group or world access from the start
+	oldMask := syscall.Umask(0077)
+	listener, err := net.Listen("unix", path)
+	syscall.Umask(oldMask)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on '%s': %w", path, err)
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict permissions of '%s': %w", path, err)
+	}
+
+	return listener, nil
+}
+
+// Serve answers requests until the agent is locked or the timeout expires.
+// A zero timeout keeps the agent running until it is locked.
+func Serve(listener net.Listener, masterPassword string, timeout time.Duration) error {
+	defer listener.Close()
+
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, func() { listener.Close() })
+		defer timer.Stop()
+	}
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(masterPassword))
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		if locked := handle(conn, encoded); locked {
+			return nil
+		}
+	}
+}
+
+// handle answers a single request and reports whether the agent was locked
+func handle(conn net.Conn, encoded string) bool {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	request, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	switch strings.TrimSpace(request) {
+	case "GET":
+		fmt.Fprintf(conn, "OK %s\n", encoded)
+	case "LOCK":
+		fmt.Fprintln(conn, "OK")
+		return true
+	default:
+		fmt.Fprintln(conn, "ERR unknown request")
+	}
+	return false
+}
+
+// Password asks the running agent for the master password. It returns
+// ErrLocked when no agent is running.
+func Password() (string, error) {
+	reply, err := request("GET")
+	if err != nil {
+		return "", err
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(reply)
+	if err != nil {
+		return "", fmt.Errorf("invalid reply from agent: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// Lock tells the running agent to forget the master password and exit.
+// It returns ErrLocked when no agent is running.
+func Lock() error {
+	_, err := request("LOCK")
+	return err
+}
+
+// request sends one request to the agent and returns the payload of its reply
+func request(command string) (string, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return "", ErrLocked
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return "", fmt.Errorf("failed to contact agent: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read reply from agent: %w", err)
+	}
+	reply = strings.TrimSpace(reply)
+
+	if message, ok := strings.CutPrefix(reply, "ERR "); ok {
+		return "", fmt.Errorf("agent: %s", message)
+	}
+	if reply == "OK" {
+		return "", nil
+	}
+	payload, ok := strings.CutPrefix(reply, "OK ")
+	if !ok {
+		return "", fmt.Errorf("invalid reply from agent")
+	}
+	return payload, nil
+}
@@ -0,0 +1,448 @@
+// Package apiserver serves the vault over a local JSON API (see pkg/api for the
+// wire types and openapi.yaml for the description).
+package apiserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"remembrall/internal/audit"
+	"remembrall/internal/crypto"
+	"remembrall/internal/hooks"
+	"remembrall/internal/search"
+	"remembrall/pkg/api"
+	"remembrall/pkg/models"
+)
+
+// OpenAPI is the OpenAPI 3 description of the API
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// tokenPrefix makes API tokens easy to recognise, e.g. in secret scanners
+const tokenPrefix = "rmb_"
+
+// maxBodySize limits request bodies
+const maxBodySize = 1 << 20
+
+// TokenStore looks up API tokens
+type TokenStore interface {
+	GetTokenByHash(hash string) (*models.APIToken, error)
+}
+
+// Server answers API requests
+type Server struct {
+	store     models.PasswordStore
+	tokens    TokenStore
+	encryptor *crypto.Encryptor
+	// requireToken rejects requests without a bearer token. Over a 0600 Unix
+	// socket it can be off, giving unauthenticated clients full access.
+	requireToken bool
+}
+
+// NewServer creates an API server for the vault
+func NewServer(store models.PasswordStore, tokens TokenStore, encryptor *crypto.Encryptor, requireToken bool) *Server {
+	return &Server{store: store, tokens: tokens, encryptor: encryptor, requireToken: requireToken}
+}
+
+// GenerateToken returns a new bearer token and the hash to store for it
+func GenerateToken() (token, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token = tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hash under which a token is stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Handler returns the HTTP handler of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+api.Version+"/openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(OpenAPI)
+	})
+
+	mux.Handle("GET "+api.Version+"/entries", s.authenticate(s.listEntries))
+	mux.Handle("POST "+api.Version+"/entries", s.authenticate(s.writable(s.createEntry)))
+	mux.Handle("GET "+api.Version+"/entries/{name...}", s.authenticate(s.getEntry))
+	mux.Handle("PUT "+api.Version+"/entries/{name...}", s.authenticate(s.writable(s.updateEntry)))
+	mux.Handle("DELETE "+api.Version+"/entries/{name...}", s.authenticate(s.writable(s.deleteEntry)))
+	mux.Handle("GET "+api.Version+"/search", s.authenticate(s.searchEntries))
+	mux.Handle("POST "+api.Version+"/generate", s.authenticate(s.generate))
+	return mux
+}
+
+type contextKey struct{}
+
+// fullAccess is the scope of unauthenticated clients on the Unix socket
+var fullAccess = &models.APIToken{Name: "socket"}
+
+// authenticate resolves the bearer token and stores its scope in the request context
+func (s *Server) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := fullAccess
+
+		header := r.Header.Get("Authorization")
+		if bearer, ok := strings.CutPrefix(header, "Bearer "); ok {
+			found, err := s.tokens.GetTokenByHash(HashToken(strings.TrimSpace(bearer)))
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, "invalid token")
+				return
+			}
+			token = found
+		} else if header != "" || s.requireToken {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "a bearer token is required")
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, token)))
+	}
+}
+
+// writable rejects read-only tokens
+func (s *Server) writable(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if scope(r).ReadOnly {
+			writeError(w, http.StatusForbidden, "token is read-only")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func scope(r *http.Request) *models.APIToken {
+	return r.Context().Value(contextKey{}).(*models.APIToken)
+}
+
+// record audits an access through the API, naming the token used
+func record(r *http.Request, action, appName string) error {
+	detail := fmt.Sprintf("api (%s)", scope(r).Name)
+	if err := audit.Record(action, appName, detail); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// postHook runs the post hook of an event. The change has been made, so a
+// failure is only reported on the server's stderr.
+func postHook(event, appName string) {
+	if err := hooks.Post(event, appName); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+func (s *Server) listEntries(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.visibleEntries(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	tag, folder := r.URL.Query().Get("tag"), r.URL.Query().Get("folder")
+	summaries := []api.EntrySummary{}
+	for _, entry := range entries {
+		if (tag != "" && !entry.HasTag(tag)) || (folder != "" && entry.Folder != folder) {
+			continue
+		}
+		summaries = append(summaries, summarize(entry))
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (s *Server) searchEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing query parameter 'q'")
+		return
+	}
+
+	entries, err := s.visibleEntries(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	summaries := []api.EntrySummary{}
+	for _, result := range search.FuzzySearch(entries, query) {
+		summaries = append(summaries, summarize(result.Entry))
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (s *Server) getEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	decrypted, err := s.decrypt(entry)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := record(r, audit.ActionGet, entry.AppName); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, decrypted)
+}
+
+func (s *Server) createEntry(w http.ResponseWriter, r *http.Request) {
+	var input api.EntryInput
+	if !readJSON(w, r, &input) {
+		return
+	}
+	if input.Name == "" || input.Password == "" {
+		writeError(w, http.StatusBadRequest, "name and password are required")
+		return
+	}
+	if _, err := s.store.Get(input.Name); err == nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("entry '%s' already exists", input.Name))
+		return
+	}
+
+	entry := &models.PasswordEntry{AppName: input.Name, Tags: input.Tags}
+	if input.Folder != nil {
+		entry.Folder = *input.Folder
+	}
+	if !scope(r).Allows(entry) {
+		writeError(w, http.StatusForbidden, "token may only create entries with its tags")
+		return
+	}
+
+	if err := s.encryptInto(entry, input.Password, input.Fields); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := hooks.Pre(hooks.EventSave, entry.AppName); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err := s.store.SaveEntry(entry); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := record(r, audit.ActionSave, entry.AppName); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	postHook(hooks.EventSave, entry.AppName)
+
+	s.respondWithEntry(w, http.StatusCreated, entry.AppName)
+}
+
+func (s *Server) updateEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	var input api.EntryInput
+	if !readJSON(w, r, &input) {
+		return
+	}
+
+	current, err := s.decrypt(entry)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	password, fields := current.Password, current.Fields
+	if input.Password != "" {
+		password = input.Password
+	}
+	if input.Fields != nil {
+		fields = input.Fields
+	}
+	if input.Folder != nil {
+		entry.Folder = *input.Folder
+	}
+	if input.Tags != nil {
+		entry.Tags = input.Tags
+	}
+	if !scope(r).Allows(entry) {
+		writeError(w, http.StatusForbidden, "token may only keep entries within its tags")
+		return
+	}
+
+	storedPassword := entry.Password
+	if err := s.encryptInto(entry, password, fields); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// Keep the old ciphertext of an unchanged password, so it is not
+	// recorded in the password history
+	if input.Password == "" {
+		entry.Password = storedPassword
+	}
+	if err := hooks.Pre(hooks.EventUpdate, entry.AppName); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err := s.store.UpdateEntry(entry); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := record(r, audit.ActionUpdate, entry.AppName); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	postHook(hooks.EventUpdate, entry.AppName)
+
+	s.respondWithEntry(w, http.StatusOK, entry.AppName)
+}
+
+func (s *Server) deleteEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	if err := hooks.Pre(hooks.EventDelete, entry.AppName); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err := s.store.Delete(entry.AppName); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := record(r, audit.ActionDelete, entry.AppName); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	postHook(hooks.EventDelete, entry.AppName)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) generate(w http.ResponseWriter, r *http.Request) {
+	var req api.GenerateRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	password, err := crypto.GeneratePassword(crypto.PasswordOptions{
+		Length:    req.Length,
+		NoUpper:   req.NoUpper,
+		NoDigits:  req.NoDigits,
+		NoSymbols: req.NoSymbols,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, api.GenerateResponse{Password: password})
+}
+
+// visibleEntries lists the entries the token may see
+func (s *Server) visibleEntries(r *http.Request) ([]*models.PasswordEntry, error) {
+	entries, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	token := scope(r)
+	var visible []*models.PasswordEntry
+	for _, entry := range entries {
+		if token.Allows(entry) {
+			visible = append(visible, entry)
+		}
+	}
+	return visible, nil
+}
+
+// lookup finds the entry named in the path. Entries outside the token's
+// scope are reported as missing so their names don't leak.
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) (*models.PasswordEntry, bool) {
+	name := r.PathValue("name")
+	entry, err := s.store.Get(name)
+	if err != nil || !scope(r).Allows(entry) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no entry named '%s'", name))
+		return nil, false
+	}
+	return entry, true
+}
+
+func (s *Server) respondWithEntry(w http.ResponseWriter, status int, name string) {
+	entry, err := s.store.Get(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, status, summarize(entry))
+}
+
+func (s *Server) decrypt(entry *models.PasswordEntry) (*api.Entry, error) {
+	password, err := s.encryptor.Decrypt(entry.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	fields, err := s.encryptor.DecryptFields(entry.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt fields: %w", err)
+	}
+
+	return &api.Entry{EntrySummary: summarize(entry), Password: password, Fields: fields}, nil
+}
+
+func (s *Server) encryptInto(entry *models.PasswordEntry, password string, fields map[string]string) error {
+	var err error
+	if entry.Password, err = s.encryptor.Encrypt(password); err != nil {
+		return fmt.Errorf("failed to encrypt password: %w", err)
+	}
+	if entry.Fields, err = s.encryptor.EncryptFields(fields); err != nil {
+		return fmt.Errorf("failed to encrypt fields: %w", err)
+	}
+	return nil
+}
+
+func summarize(entry *models.PasswordEntry) api.EntrySummary {
+	return api.EntrySummary{
+		Name:      entry.AppName,
+		Type:      entry.Type,
+		Folder:    entry.Folder,
+		Tags:      entry.Tags,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+	}
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, api.Error{Error: message})
+}
@@ -0,0 +1,223 @@
+// Package audit keeps an append-only log of vault access.
+//
+// Each line of the log file is a record holding an event encrypted to the
+// vault's audit key with age, so events can be written without the master
+// password (failed unlocks, for one) but only read with it. Records are
+// chained by SHA-256 hashes and the hash of the newest record is kept in the
+// vault, which makes edited, removed or truncated records detectable. The
+// chain is not secret: someone able to rewrite both the log and the vault can
+// forge it, it guards against quiet tampering with either one.
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+
+	"filippo.io/age"
+)
+
+// Actions recorded in the log
+const (
+	ActionGet          = "get"
+	ActionSave         = "save"
+	ActionUpdate       = "update"
+	ActionDelete       = "delete"
+	ActionExport       = "export"
+	ActionUnlockFailed = "unlock-failed"
+)
+
+// Actions lists every action, in the order shown in help texts
+var Actions = []string{ActionGet, ActionSave, ActionUpdate, ActionDelete, ActionExport, ActionUnlockFailed}
+
+const logFile = ".remembrall-audit.log"
+
+// Vault settings holding the audit key and the head of the chain. They are
+// managed here rather than through 'remembrall config'.
+const (
+	settingRecipient = "audit.recipient"
+	settingIdentity  = "audit.identity"
+	settingHead      = "audit.head"
+)
+
+// genesis is the previous hash of the first record
+var genesis = strings.Repeat("0", sha256.Size*2)
+
+// Event is one access to the vault
+type Event struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	App    string    `json:"app,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// record is one line of the log file; Data is the encrypted event
+type record struct {
+	Seq  uint64 `json:"seq"`
+	Prev string `json:"prev"`
+	Data string `json:"data"`
+	Hash string `json:"hash"`
+}
+
+// sum returns the hash chaining a record to the one before it
+func (r *record) sum() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%d\n%s", r.Prev, r.Seq, r.Data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Path returns the location of the audit log
+func Path() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, logFile), nil
+}
+
+// EnsureKey creates the audit key on first use. The public half is stored
+// as is so events can be encrypted at any time; the private half is stored
+// encrypted with the master password.
+func EnsureKey(masterPassword string) error {
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	if _, ok, err := store.GetSetting(settingRecipient); err != nil || ok {
+		return err
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return fmt.Errorf("failed to generate audit key: %w", err)
+	}
+	encryptedIdentity, err := crypto.NewEncryptor(masterPassword).Encrypt(identity.String())
+	if err != nil {
+		return fmt.Errorf("failed to encrypt audit key: %w", err)
+	}
+
+	// The private half goes first, so a half-written key is never used
+	if err := store.SetSetting(settingIdentity, encryptedIdentity); err != nil {
+		return err
+	}
+	return store.SetSetting(settingRecipient, identity.Recipient().String())
+}
+
+// Record appends an event to the log. Nothing is recorded before the audit
+// key exists, which is created on the first successful unlock.
+func Record(action, app, detail string) error {
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	value, ok, err := store.GetSetting(settingRecipient)
+	if err != nil || !ok {
+		return err
+	}
+	recipient, err := age.ParseX25519Recipient(value)
+	if err != nil {
+		return fmt.Errorf("invalid audit key: %w", err)
+	}
+
+	event, err := json.Marshal(Event{Time: time.Now().UTC(), Action: action, App: app, Detail: detail})
+	if err != nil {
+		return err
+	}
+	var ciphertext bytes.Buffer
+	w, err := age.Encrypt(&ciphertext, recipient)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt audit event: %w", err)
+	}
+	if _, err := w.Write(event); err != nil {
+		return fmt.Errorf("failed to encrypt audit event: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt audit event: %w", err)
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	// Hold the log while the head is read and moved, so concurrent
+	// commands don't fork the chain
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	// Chain from the head kept in the vault rather than the last line of
+	// the file, so records cut from the file stay missing
+	seq, prev, err := head(store)
+	if err != nil {
+		return err
+	}
+	r := &record{Seq: seq + 1, Prev: prev, Data: base64.StdEncoding.EncodeToString(ciphertext.Bytes())}
+	r.Hash = r.sum()
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	// The new head is only committed once the record is on disk, and the
+	// record is cut off again when the head can't be stored, so the log
+	// and the vault never disagree
+	err = store.SetSettingWith(settingHead, fmt.Sprintf("%d:%s", r.Seq, r.Hash), func() error {
+		if _, err := file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		file.Truncate(info.Size())
+		return err
+	}
+	return nil
+}
+
+// head returns the sequence number and hash of the newest record, or zero
+// and the genesis hash for an empty log
+func head(store *db.SQLiteStore) (uint64, string, error) {
+	value, ok, err := store.GetSetting(settingHead)
+	if err != nil || !ok {
+		return 0, genesis, err
+	}
+
+	seq, hash, found := strings.Cut(value, ":")
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if !found || err != nil || len(hash) != len(genesis) {
+		return 0, "", fmt.Errorf("invalid %s setting '%s'", settingHead, value)
+	}
+	return n, hash, nil
+}
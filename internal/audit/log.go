@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+
+	"filippo.io/age"
+)
+
+// Entry is a decrypted record of the log
+type Entry struct {
+	Seq uint64
+	Event
+}
+
+// readRecords parses the log file; a missing file is an empty log
+func readRecords() ([]*record, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var records []*record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("line %d of the audit log is malformed", line)
+		}
+		records = append(records, &r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return records, nil
+}
+
+// Read decrypts every event in the log, oldest first
+func Read(masterPassword string) ([]*Entry, error) {
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	value, ok, err := store.GetSetting(settingIdentity)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	key, err := crypto.NewEncryptor(masterPassword).Decrypt(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt audit key: %w", err)
+	}
+	identity, err := age.ParseX25519Identity(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit key: %w", err)
+	}
+
+	records, err := readRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(records))
+	for _, r := range records {
+		ciphertext, err := base64.StdEncoding.DecodeString(r.Data)
+		if err != nil {
+			return nil, fmt.Errorf("record %d is malformed", r.Seq)
+		}
+		plaintext, err := age.Decrypt(bytes.NewReader(ciphertext), identity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt record %d: %w", r.Seq, err)
+		}
+		data, err := io.ReadAll(plaintext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt record %d: %w", r.Seq, err)
+		}
+
+		entry := &Entry{Seq: r.Seq}
+		if err := json.Unmarshal(data, &entry.Event); err != nil {
+			return nil, fmt.Errorf("record %d is malformed", r.Seq)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Verify checks that the records form an unbroken chain from the first one
+// to the head kept in the vault. It returns the number of records checked,
+// or an error describing the first problem found.
+func Verify() (int, error) {
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	headSeq, headHash, err := head(store)
+	if err != nil {
+		return 0, err
+	}
+
+	records, err := readRecords()
+	if err != nil {
+		return 0, err
+	}
+
+	prev := genesis
+	for i, r := range records {
+		switch {
+		case r.Seq != uint64(i+1):
+			return i, fmt.Errorf("record %d is out of sequence, expected record %d", r.Seq, i+1)
+		case r.Prev != prev:
+			return i, fmt.Errorf("record %d does not follow record %d, records were removed or changed", r.Seq, i)
+		case r.Hash != r.sum():
+			return i, fmt.Errorf("record %d was modified", r.Seq)
+		}
+		prev = r.Hash
+	}
+
+	// The log must end exactly at the head kept in the vault
+	count := uint64(len(records))
+	switch {
+	case count < headSeq:
+		return len(records), fmt.Errorf("the log ends at record %d but the vault expects %d, records were truncated", count, headSeq)
+	case count > headSeq:
+		return len(records), fmt.Errorf("the log has %d records after the last one known to the vault", count-headSeq)
+	case prev != headHash:
+		return len(records), fmt.Errorf("record %d does not match the head kept in the vault", headSeq)
+	}
+
+	return len(records), nil
+}
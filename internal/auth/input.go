@@ -1,16 +1,19 @@
 package auth
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"strings"
 	"syscall"
 
 	"golang.org/x/term"
 )
 
-// ReadPassword reads a password from stdin without echoing it to the terminal
+// ReadPassword reads a password from stdin without echoing it to the terminal.
+// The prompt goes to stderr so stdout stays clean when it is piped.
 func ReadPassword(prompt string) (string, error) {
-	fmt.Print(prompt)
+	fmt.Fprint(os.Stderr, prompt)
 	
 	// Get the file descriptor for stdin
 	fd := int(syscall.Stdin)
@@ -27,7 +30,7 @@ func ReadPassword(prompt string) (string, error) {
 	}
 	
 	// Print newline since ReadPassword doesn't echo the Enter key
-	fmt.Println()
+	fmt.Fprintln(os.Stderr)
 	
 	password := string(bytePassword)
 	password = strings.TrimSpace(password)
@@ -58,6 +61,36 @@ func ReadPasswordWithConfirmation(prompt, confirmPrompt string) (string, error)
 	return password, nil
 }
 
+// ReadLine reads a visible line of input from the terminal
+func ReadLine(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		return "", fmt.Errorf("not running in a terminal")
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+// ConfirmPhrase asks the user to type a phrase exactly before a dangerous operation
+func ConfirmPhrase(phrase string) error {
+	answer, err := ReadLine(fmt.Sprintf("Type '%s' to continue: ", phrase))
+	if err != nil {
+		return err
+	}
+
+	if answer != phrase {
+		return fmt.Errorf("confirmation did not match, aborting")
+	}
+
+	return nil
+}
+
 // PromptMasterPassword prompts for the master password
 func PromptMasterPassword() (string, error) {
 	return ReadPassword("Enter your master password: ")
@@ -69,13 +102,33 @@ func PromptApplicationPassword(appName string) (string, error) {
 	return ReadPassword(prompt)
 }
 
-// PromptNewMasterPassword prompts for a new master password with confirmation
-func PromptNewMasterPassword() (string, error) {
+// maxNewMasterAttempts bounds how often a too weak master password is
+// prompted for again
+const maxNewMasterAttempts = 3
+
+// PromptNewMasterPassword prompts for a new master password with confirmation,
+// until one is rated at least minScore
+func PromptNewMasterPassword(minScore int) (string, error) {
 	fmt.Println("Setting up master password for Remembrall...")
-	return ReadPasswordWithConfirmation(
-		"Enter your new master password: ",
-		"Confirm your master password: ",
-	)
+
+	for attempt := 1; ; attempt++ {
+		password, err := ReadPasswordWithConfirmation(
+			"Enter your new master password: ",
+			"Confirm your master password: ",
+		)
+		if err != nil {
+			return "", err
+		}
+
+		err = CheckPasswordStrength(password, minScore, "remembrall")
+		if err == nil {
+			return password, nil
+		}
+		if attempt == maxNewMasterAttempts {
+			return "", err
+		}
+		fmt.Fprintf(os.Stderr, "%v, choose a stronger master password.\n", err)
+	}
 }
 
 // ClearScreen clears the terminal screen (for security after displaying passwords)
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"remembrall/internal/audit"
 	"remembrall/internal/crypto"
+	"remembrall/pkg/models"
 )
 
 const (
@@ -40,7 +42,9 @@ func (m *MasterPasswordManager) SetupMasterPassword() (string, error) {
 		return "", fmt.Errorf("master password already exists")
 	}
 
-	masterPassword, err := PromptNewMasterPassword()
+	// Vault settings can't be changed before the master password exists,
+	// so the default minimum strength applies
+	masterPassword, err := PromptNewMasterPassword(models.DefaultMinStrength)
 	if err != nil {
 		return "", fmt.Errorf("failed to get master password: %w", err)
 	}
@@ -95,21 +99,33 @@ func (m *MasterPasswordManager) VerifyMasterPassword(masterPassword string) erro
 
 // PromptAndVerifyMasterPassword prompts for master password and verifies it
 func (m *MasterPasswordManager) PromptAndVerifyMasterPassword() (string, error) {
+	var masterPassword string
+	var err error
+
 	// Check if this is first time setup
 	if m.IsFirstTime() {
-		return m.SetupMasterPassword()
-	}
-
-	// Prompt for existing master password
-	masterPassword, err := PromptMasterPassword()
-	if err != nil {
-		return "", fmt.Errorf("failed to get master password: %w", err)
+		masterPassword, err = m.SetupMasterPassword()
+		if err != nil {
+			return "", err
+		}
+	} else {
+		// Prompt for existing master password
+		masterPassword, err = PromptMasterPassword()
+		if err != nil {
+			return "", fmt.Errorf("failed to get master password: %w", err)
+		}
+
+		// Verify the password, auditing failed attempts
+		err = m.VerifyMasterPassword(masterPassword)
+		if err != nil {
+			audit.Record(audit.ActionUnlockFailed, "", "")
+			return "", err
+		}
 	}
 
-	// Verify the password
-	err = m.VerifyMasterPassword(masterPassword)
-	if err != nil {
-		return "", err
+	// Create the audit key once the master password is known
+	if err := audit.EnsureKey(masterPassword); err != nil {
+		return "", fmt.Errorf("failed to set up audit log: %w", err)
 	}
 
 	return masterPassword, nil
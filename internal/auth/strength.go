@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"remembrall/internal/strength"
+	"strings"
+	"unicode"
+)
+
+// ErrWeakPassword is returned for new passwords below the vault's minimum
+// strength
+var ErrWeakPassword = errors.New("password is too weak")
+
+// CheckPasswordStrength prints how strong a new password is and how long an
+// offline attack on it would take, and fails with ErrWeakPassword when it
+// scores below minScore. User inputs, such as the application and user
+// name, make poor passwords and lower the score.
+func CheckPasswordStrength(password string, minScore int, userInputs ...string) error {
+	result := strength.Estimate(password, StrengthInputs(userInputs...)...)
+
+	fmt.Fprintf(os.Stderr, "Strength: %s (%d/4), %s to crack offline\n",
+		result.Label(), result.Score, result.CrackTime(strength.OfflineSlowHash))
+	if result.Warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", result.Warning)
+	}
+
+	if result.Score < minScore {
+		return fmt.Errorf("%w: rated %s, the minimum is %s (%d/4)",
+			ErrWeakPassword, result.Label(), strength.Labels[minScore], minScore)
+	}
+	return nil
+}
+
+// StrengthInputs lists the given names and the words in them, which make
+// poor passwords for an entry
+func StrengthInputs(names ...string) []string {
+	var inputs []string
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		inputs = append(inputs, name)
+		inputs = append(inputs, strings.FieldsFunc(name, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+	return inputs
+}
@@ -0,0 +1,41 @@
+package crypto
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// EncryptFields encrypts a set of named fields as a single JSON blob.
+// An empty set encrypts to the empty string so entries without extra
+// fields don't pay for a key derivation on every read.
+func (e *Encryptor) EncryptFields(fields map[string]string) (string, error) {
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode fields: %w", err)
+	}
+
+	return e.Encrypt(string(data))
+}
+
+// DecryptFields decrypts a blob produced by EncryptFields
+func (e *Encryptor) DecryptFields(encodedFields string) (map[string]string, error) {
+	fields := make(map[string]string)
+	if encodedFields == "" {
+		return fields, nil
+	}
+
+	data, err := e.Decrypt(encodedFields)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode fields: %w", err)
+	}
+
+	return fields, nil
+}
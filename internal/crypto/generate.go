@@ -0,0 +1,95 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// Character classes used by GeneratePassword
+const (
+	lowercaseChars = "abcdefghijklmnopqrstuvwxyz"
+	uppercaseChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars     = "0123456789"
+	symbolChars    = "!@#$%^&*()-_=+[]{};:,.<>?/~"
+)
+
+// DefaultPasswordLength is the length used when none is given
+const DefaultPasswordLength = 20
+
+// PasswordOptions controls GeneratePassword
+type PasswordOptions struct {
+	Length    int
+	NoUpper   bool
+	NoDigits  bool
+	NoSymbols bool
+}
+
+// GeneratePassword creates a random password from a cryptographic source.
+// Lowercase letters are always used; every enabled class appears at least once.
+func GeneratePassword(opts PasswordOptions) (string, error) {
+	length := opts.Length
+	if length == 0 {
+		length = DefaultPasswordLength
+	}
+
+	classes := []string{lowercaseChars}
+	if !opts.NoUpper {
+		classes = append(classes, uppercaseChars)
+	}
+	if !opts.NoDigits {
+		classes = append(classes, digitChars)
+	}
+	if !opts.NoSymbols {
+		classes = append(classes, symbolChars)
+	}
+	if length < len(classes) || length > 1024 {
+		return "", fmt.Errorf("password length must be between %d and 1024", len(classes))
+	}
+
+	var all string
+	for _, class := range classes {
+		all += class
+	}
+
+	// One character from each class, the rest from all of them
+	password := make([]byte, length)
+	for i := range password {
+		set := all
+		if i < len(classes) {
+			set = classes[i]
+		}
+		c, err := randomChar(set)
+		if err != nil {
+			return "", err
+		}
+		password[i] = c
+	}
+
+	// Shuffle so the guaranteed characters aren't always first
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+
+	return string(password), nil
+}
+
+func randomChar(set string) (byte, error) {
+	i, err := randomInt(len(set))
+	if err != nil {
+		return 0, err
+	}
+	return set[i], nil
+}
+
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return int(v.Int64()), nil
+}
@@ -0,0 +1,78 @@
+package db
+
+import "fmt"
+
+// SetExpiry sets how many days an entry's password may go unchanged, or 0
+// to follow the policies of its tags
+func (s *SQLiteStore) SetExpiry(appName string, days int) error {
+	result, err := s.db.Exec("UPDATE passwords SET expiry_days = ? WHERE app_name = ?", days, appName)
+	if err != nil {
+		return fmt.Errorf("failed to set expiry policy: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no password found for '%s'", appName)
+	}
+
+	return nil
+}
+
+// TagExpiryPolicies returns the expiry policy of each tag that has one, in
+// days
+func (s *SQLiteStore) TagExpiryPolicies() (map[string]int, error) {
+	rows, err := s.db.Query("SELECT tag, days FROM expiry_policies")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expiry policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := make(map[string]int)
+	for rows.Next() {
+		var tag string
+		var days int
+		if err := rows.Scan(&tag, &days); err != nil {
+			return nil, fmt.Errorf("failed to read expiry policies: %w", err)
+		}
+		policies[tag] = days
+	}
+
+	return policies, rows.Err()
+}
+
+// SetTagExpiryPolicy makes passwords of entries with a tag expire after a
+// number of days, replacing any previous policy of the tag
+func (s *SQLiteStore) SetTagExpiryPolicy(tag string, days int) error {
+	query := `
+	INSERT INTO expiry_policies (tag, days) VALUES (?, ?)
+	ON CONFLICT(tag) DO UPDATE SET days = excluded.days
+	`
+
+	if _, err := s.db.Exec(query, tag, days); err != nil {
+		return fmt.Errorf("failed to save expiry policy for tag '%s': %w", tag, err)
+	}
+	return nil
+}
+
+// UnsetTagExpiryPolicy removes the expiry policy of a tag
+func (s *SQLiteStore) UnsetTagExpiryPolicy(tag string) error {
+	result, err := s.db.Exec("DELETE FROM expiry_policies WHERE tag = ?", tag)
+	if err != nil {
+		return fmt.Errorf("failed to remove expiry policy for tag '%s': %w", tag, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check delete result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no expiry policy for tag '%s'", tag)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"remembrall/pkg/models"
+)
+
+// historyColumns lists the columns read by scanHistory, in order
+const historyColumns = "id, app_name, password, set_at, replaced_at, note"
+
+// scanHistory reads a history entry selected with historyColumns
+func scanHistory(row rowScanner) (*models.HistoryEntry, error) {
+	var entry models.HistoryEntry
+	if err := row.Scan(&entry.ID, &entry.AppName, &entry.Password, &entry.SetAt, &entry.ReplacedAt, &entry.Note); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// archivePassword copies the current password of an entry into its history,
+// unless it equals unchanged, and trims the history to the vault's limit.
+// The note says how the password was replaced, if worth telling.
+func archivePassword(tx *sql.Tx, appName, unchanged, note string, replacedAt time.Time) error {
+	query := `
+	INSERT INTO password_history (app_name, password, set_at, replaced_at, note)
+	SELECT app_name, password, updated_at, ?, ?
+	FROM passwords
+	WHERE app_name = ? AND password != ?
+	`
+
+	if _, err := tx.Exec(query, replacedAt, note, appName, unchanged); err != nil {
+		return fmt.Errorf("failed to keep password history: %w", err)
+	}
+
+	return pruneHistory(tx, appName)
+}
+
+// pruneHistory trims the history of an entry to the vault's limit
+func pruneHistory(tx *sql.Tx, appName string) error {
+	limit, err := historyLimit(tx)
+	if err != nil {
+		return err
+	}
+
+	prune := `
+	DELETE FROM password_history
+	WHERE app_name = ? AND id NOT IN (
+		SELECT id FROM password_history WHERE app_name = ? ORDER BY id DESC LIMIT ?
+	)
+	`
+
+	if _, err := tx.Exec(prune, appName, appName, limit); err != nil {
+		return fmt.Errorf("failed to trim password history: %w", err)
+	}
+
+	return nil
+}
+
+// History returns the previous passwords of an entry, newest first
+func (s *SQLiteStore) History(appName string) ([]*models.HistoryEntry, error) {
+	query := `
+	SELECT ` + historyColumns + `
+	FROM password_history
+	WHERE app_name = ?
+	ORDER BY id DESC
+	`
+
+	rows, err := s.db.Query(query, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve password history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*models.HistoryEntry
+	for rows.Next() {
+		entry, err := scanHistory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan password history: %w", err)
+		}
+		history = append(history, entry)
+	}
+
+	return history, rows.Err()
+}
+
+// Rollback restores a previous password of an entry. The password it
+// replaces goes into the history in its place, so a rollback can itself be
+// rolled back.
+func (s *SQLiteStore) Rollback(appName string, historyID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to roll back password: %w", err)
+	}
+	defer tx.Rollback()
+
+	var password string
+	err = tx.QueryRow("SELECT password FROM password_history WHERE id = ? AND app_name = ?", historyID, appName).Scan(&password)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("no such previous password for '%s'", appName)
+		}
+		return fmt.Errorf("failed to retrieve password history: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM password_history WHERE id = ?", historyID); err != nil {
+		return fmt.Errorf("failed to roll back password: %w", err)
+	}
+
+	now := time.Now()
+	if err := archivePassword(tx, appName, "", "", now); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("UPDATE passwords SET password = ?, updated_at = ? WHERE app_name = ?", password, now, appName)
+	if err != nil {
+		return fmt.Errorf("failed to roll back password: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no password found for '%s'", appName)
+	}
+
+	return tx.Commit()
+}
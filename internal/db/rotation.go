@@ -0,0 +1,186 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"remembrall/pkg/models"
+)
+
+// SavePendingRotation keeps a new password for an entry until the rotation
+// is committed or discarded. The password must already be encrypted.
+func (s *SQLiteStore) SavePendingRotation(appName, password string) error {
+	query := `
+	INSERT INTO pending_rotations (app_name, password, created_at)
+	SELECT app_name, ?, ? FROM passwords WHERE app_name = ?
+	`
+
+	result, err := s.db.Exec(query, password, time.Now(), appName)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return fmt.Errorf("a rotation of '%s' is already pending", appName)
+		}
+		return fmt.Errorf("failed to save pending rotation: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check insert result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no password found for '%s'", appName)
+	}
+
+	return nil
+}
+
+// PendingRotation returns the pending rotation of an entry, or nil when
+// there is none
+func (s *SQLiteStore) PendingRotation(appName string) (*models.PendingRotation, error) {
+	query := `
+	SELECT app_name, password, created_at
+	FROM pending_rotations
+	WHERE app_name = ?
+	`
+
+	var pending models.PendingRotation
+	err := s.db.QueryRow(query, appName).Scan(&pending.AppName, &pending.Password, &pending.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending rotation: %w", err)
+	}
+
+	return &pending, nil
+}
+
+// CommitPendingRotation replaces the password of an entry with its pending
+// one. The previous password is kept in the entry's history with the note.
+func (s *SQLiteStore) CommitPendingRotation(appName, note string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to commit rotation: %w", err)
+	}
+	defer tx.Rollback()
+
+	var password string
+	err = tx.QueryRow("SELECT password FROM pending_rotations WHERE app_name = ?", appName).Scan(&password)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("no rotation of '%s' is pending", appName)
+		}
+		return fmt.Errorf("failed to read pending rotation: %w", err)
+	}
+
+	now := time.Now()
+	if err := archivePassword(tx, appName, "", note, now); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("UPDATE passwords SET password = ?, updated_at = ? WHERE app_name = ?", password, now, appName)
+	if err != nil {
+		return fmt.Errorf("failed to commit rotation: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no password found for '%s'", appName)
+	}
+
+	if _, err := tx.Exec("DELETE FROM pending_rotations WHERE app_name = ?", appName); err != nil {
+		return fmt.Errorf("failed to commit rotation: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DiscardPendingRotation drops the pending rotation of an entry, keeping
+// its current password
+func (s *SQLiteStore) DiscardPendingRotation(appName string) error {
+	result, err := s.db.Exec("DELETE FROM pending_rotations WHERE app_name = ?", appName)
+	if err != nil {
+		return fmt.Errorf("failed to discard pending rotation: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check delete result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no rotation of '%s' is pending", appName)
+	}
+
+	return nil
+}
+
+// FailPendingRotation drops the pending rotation of an entry that could not
+// be applied, keeping its current password. The pending password goes into
+// the history with the note, in case it was applied after all.
+func (s *SQLiteStore) FailPendingRotation(appName, note string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to record failed rotation: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO password_history (app_name, password, set_at, replaced_at, note)
+	SELECT app_name, password, created_at, ?, ?
+	FROM pending_rotations
+	WHERE app_name = ?
+	`
+
+	result, err := tx.Exec(query, time.Now(), note, appName)
+	if err != nil {
+		return fmt.Errorf("failed to record failed rotation: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check insert result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no rotation of '%s' is pending", appName)
+	}
+
+	if _, err := tx.Exec("DELETE FROM pending_rotations WHERE app_name = ?", appName); err != nil {
+		return fmt.Errorf("failed to record failed rotation: %w", err)
+	}
+
+	if err := pruneHistory(tx, appName); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetHook sets the rotation hook of an entry, or removes it when empty. The
+// path must already be encrypted, so that whoever can write the database
+// cannot point the hook, and the secrets it receives, elsewhere.
+func (s *SQLiteStore) SetHook(appName, path string) error {
+	result, err := s.db.Exec("UPDATE passwords SET hook = ? WHERE app_name = ?", path, appName)
+	if err != nil {
+		return fmt.Errorf("failed to set rotation hook: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no password found for '%s'", appName)
+	}
+
+	return nil
+}
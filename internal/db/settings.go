@@ -0,0 +1,128 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"remembrall/pkg/models"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// getSetting reads a vault setting; ok is false when it is not set
+func getSetting(q querier, key string) (string, bool, error) {
+	var value string
+	err := q.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read setting '%s': %w", key, err)
+	}
+	return value, true, nil
+}
+
+// GetSetting returns a vault setting; ok is false when it is not set
+func (s *SQLiteStore) GetSetting(key string) (string, bool, error) {
+	return getSetting(s.db, key)
+}
+
+// Settings returns all vault settings that are set
+func (s *SQLiteStore) Settings() (map[string]string, error) {
+	rows, err := s.db.Query("SELECT key, value FROM settings")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to read settings: %w", err)
+		}
+		settings[key] = value
+	}
+
+	return settings, rows.Err()
+}
+
+// SetSetting stores a vault setting, replacing any previous value
+func (s *SQLiteStore) SetSetting(key, value string) error {
+	query := `
+	INSERT INTO settings (key, value) VALUES (?, ?)
+	ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`
+
+	if _, err := s.db.Exec(query, key, value); err != nil {
+		return fmt.Errorf("failed to save setting '%s': %w", key, err)
+	}
+	return nil
+}
+
+// SetSettingWith stores a vault setting together with a change made outside
+// the vault. The setting is written in a transaction that is only committed
+// once write succeeds, so a failed write leaves the old value in place.
+func (s *SQLiteStore) SetSettingWith(key, value string, write func() error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save setting '%s': %w", key, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO settings (key, value) VALUES (?, ?)
+	ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`
+
+	if _, err := tx.Exec(query, key, value); err != nil {
+		return fmt.Errorf("failed to save setting '%s': %w", key, err)
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save setting '%s': %w", key, err)
+	}
+	return nil
+}
+
+// UnsetSetting removes a vault setting, restoring its default
+func (s *SQLiteStore) UnsetSetting(key string) error {
+	if _, err := s.db.Exec("DELETE FROM settings WHERE key = ?", key); err != nil {
+		return fmt.Errorf("failed to remove setting '%s': %w", key, err)
+	}
+	return nil
+}
+
+// historyLimit returns how many previous passwords are kept per entry
+func historyLimit(q querier) (int, error) {
+	value, ok, err := getSetting(q, models.SettingHistoryLimit)
+	if err != nil || !ok {
+		return models.DefaultHistoryLimit, err
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid %s setting '%s'", models.SettingHistoryLimit, value)
+	}
+	return limit, nil
+}
+
+// MinStrength returns the lowest strength score new passwords may have
+func (s *SQLiteStore) MinStrength() (int, error) {
+	value, ok, err := getSetting(s.db, models.SettingMinStrength)
+	if err != nil || !ok {
+		return models.DefaultMinStrength, err
+	}
+
+	score, err := strconv.Atoi(value)
+	if err != nil || score < 0 || score > 4 {
+		return 0, fmt.Errorf("invalid %s setting '%s'", models.SettingMinStrength, value)
+	}
+	return score, nil
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -15,16 +16,26 @@ import (
 
 type SQLiteStore struct {
 	db *sql.DB
+	// versionConn is the connection DataVersion reads from
+	versionConn *sql.Conn
 }
 
-// NewSQLiteStore creates a new SQLite store
-func NewSQLiteStore() (*SQLiteStore, error) {
+// Path returns the location of the vault database
+func Path() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	dbPath := filepath.Join(homeDir, ".remembrall.db")
+	return filepath.Join(homeDir, ".remembrall.db"), nil
+}
+
+// NewSQLiteStore creates a new SQLite store
+func NewSQLiteStore() (*SQLiteStore, error) {
+	dbPath, err := Path()
+	if err != nil {
+		return nil, err
+	}
 	
 	db, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
@@ -36,6 +47,10 @@ func NewSQLiteStore() (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	if err := store.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
 	return store, nil
 }
 
@@ -51,12 +66,155 @@ func (s *SQLiteStore) createTables() error {
 	);
 	
 	CREATE INDEX IF NOT EXISTS idx_app_name ON passwords(app_name);
+
+	CREATE TABLE IF NOT EXISTS api_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		read_only INTEGER NOT NULL DEFAULT 0,
+		tags TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS password_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		app_name TEXT NOT NULL,
+		password TEXT NOT NULL,
+		set_at DATETIME NOT NULL,
+		replaced_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_history_app_name ON password_history(app_name);
+
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS expiry_policies (
+		tag TEXT PRIMARY KEY,
+		days INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS pending_rotations (
+		app_name TEXT PRIMARY KEY,
+		password TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
 	`
 
 	_, err := s.db.Exec(query)
 	return err
 }
 
+// migrate adds columns introduced after the initial schema to existing databases
+func (s *SQLiteStore) migrate() error {
+	columns := []struct {
+		table      string
+		name       string
+		definition string
+	}{
+		{"passwords", "fields", "TEXT NOT NULL DEFAULT ''"},
+		{"passwords", "folder", "TEXT NOT NULL DEFAULT ''"},
+		{"passwords", "tags", "TEXT NOT NULL DEFAULT ''"},
+		{"passwords", "type", "TEXT NOT NULL DEFAULT '" + models.EntryTypePassword + "'"},
+		{"passwords", "otp_counter", "INTEGER NOT NULL DEFAULT 0"},
+		{"passwords", "expiry_days", "INTEGER NOT NULL DEFAULT 0"},
+		{"passwords", "hook", "TEXT NOT NULL DEFAULT ''"},
+		{"password_history", "note", "TEXT NOT NULL DEFAULT ''"},
+	}
+
+	existing := make(map[string]map[string]bool)
+	for _, column := range columns {
+		if existing[column.table] == nil {
+			names, err := s.columnNames(column.table)
+			if err != nil {
+				return err
+			}
+			existing[column.table] = names
+		}
+		if existing[column.table][column.name] {
+			continue
+		}
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", column.table, column.name, column.definition)
+		if _, err := s.db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add column '%s' to '%s': %w", column.name, column.table, err)
+		}
+	}
+
+	return nil
+}
+
+// columnNames returns the set of column names of a table
+func (s *SQLiteStore) columnNames(table string) (map[string]bool, error) {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect table '%s': %w", table, err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, fmt.Errorf("failed to inspect table '%s': %w", table, err)
+		}
+		names[name] = true
+	}
+
+	return names, rows.Err()
+}
+
+// entryColumns lists the columns read by scanEntry, in order
+const entryColumns = "id, app_name, type, password, fields, folder, tags, otp_counter, expiry_days, hook, created_at, updated_at"
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEntry reads a password entry selected with entryColumns
+func scanEntry(row rowScanner) (*models.PasswordEntry, error) {
+	var entry models.PasswordEntry
+	var tags string
+	err := row.Scan(&entry.ID, &entry.AppName, &entry.Type, &entry.Password, &entry.Fields, &entry.Folder, &tags, &entry.OTPCounter, &entry.ExpiryDays, &entry.Hook, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	entry.Tags = splitTags(tags)
+	return &entry, nil
+}
+
+// joinTags encodes tags for the tags column
+func joinTags(tags []string) string {
+	var cleaned []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.ReplaceAll(tag, ",", " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		cleaned = append(cleaned, tag)
+	}
+	return strings.Join(cleaned, ",")
+}
+
+// splitTags decodes the tags column
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, ",")
+}
+
 // Save stores a new password entry
 func (s *SQLiteStore) Save(appName, password string) error {
 	query := `
@@ -76,18 +234,49 @@ func (s *SQLiteStore) Save(appName, password string) error {
 	return nil
 }
 
+// SaveEntry stores a new entry together with its type, fields, folder and tags.
+// The password and fields must already be encrypted.
+func (s *SQLiteStore) SaveEntry(entry *models.PasswordEntry) error {
+	query := `
+	INSERT INTO passwords (app_name, type, password, fields, folder, tags, otp_counter, expiry_days, hook, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	entryType := entry.Type
+	if entryType == "" {
+		entryType = models.EntryTypePassword
+	}
+
+	createdAt, updatedAt := entry.CreatedAt, entry.UpdatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	if updatedAt.IsZero() {
+		updatedAt = createdAt
+	}
+
+	_, err := s.db.Exec(query, entry.AppName, entryType, entry.Password, entry.Fields, entry.Folder, joinTags(entry.Tags), entry.OTPCounter, entry.ExpiryDays, entry.Hook, createdAt, updatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return fmt.Errorf("password for '%s' already exists, use 'update' command to modify it", entry.AppName)
+		}
+		return fmt.Errorf("failed to save password: %w", err)
+	}
+
+	return nil
+}
+
 // Get retrieves a password entry by app name
 func (s *SQLiteStore) Get(appName string) (*models.PasswordEntry, error) {
 	query := `
-	SELECT id, app_name, password, created_at, updated_at
+	SELECT ` + entryColumns + `
 	FROM passwords
 	WHERE app_name = ?
 	`
 	
 	row := s.db.QueryRow(query, appName)
 	
-	var entry models.PasswordEntry
-	err := row.Scan(&entry.ID, &entry.AppName, &entry.Password, &entry.CreatedAt, &entry.UpdatedAt)
+	entry, err := scanEntry(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no password found for '%s'", appName)
@@ -95,18 +284,30 @@ func (s *SQLiteStore) Get(appName string) (*models.PasswordEntry, error) {
 		return nil, fmt.Errorf("failed to retrieve password: %w", err)
 	}
 	
-	return &entry, nil
+	return entry, nil
 }
 
-// Update modifies an existing password entry
+// Update modifies an existing password entry. The previous password is
+// kept in the entry's history.
 func (s *SQLiteStore) Update(appName, newPassword string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := archivePassword(tx, appName, "", "", now); err != nil {
+		return err
+	}
+
 	query := `
 	UPDATE passwords
 	SET password = ?, updated_at = ?
 	WHERE app_name = ?
 	`
 	
-	result, err := s.db.Exec(query, newPassword, time.Now(), appName)
+	result, err := tx.Exec(query, newPassword, now, appName)
 	if err != nil {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
@@ -120,13 +321,129 @@ func (s *SQLiteStore) Update(appName, newPassword string) error {
 		return fmt.Errorf("no password found for '%s'", appName)
 	}
 	
+	return tx.Commit()
+}
+
+// UpdateEntry replaces the password, fields, folder and tags of an existing
+// entry. The password and fields must already be encrypted. A changed
+// password is kept in the entry's history; updated_at records when the
+// password last changed, so it is left alone when the ciphertext is the same.
+func (s *SQLiteStore) UpdateEntry(entry *models.PasswordEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := archivePassword(tx, entry.AppName, entry.Password, "", now); err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE passwords
+	SET updated_at = CASE WHEN password != ? THEN ? ELSE updated_at END,
+		password = ?, fields = ?, folder = ?, tags = ?
+	WHERE app_name = ?
+	`
+
+	result, err := tx.Exec(query, entry.Password, now, entry.Password, entry.Fields, entry.Folder, joinTags(entry.Tags), entry.AppName)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no password found for '%s'", entry.AppName)
+	}
+
+	return tx.Commit()
+}
+
+// NextOTPCounter returns the HOTP counter of an entry and increments it in
+// the same statement, so concurrent callers never get the same value
+func (s *SQLiteStore) NextOTPCounter(appName string) (uint64, error) {
+	query := `
+	UPDATE passwords
+	SET otp_counter = otp_counter + 1
+	WHERE app_name = ?
+	RETURNING otp_counter - 1
+	`
+
+	var counter uint64
+	err := s.db.QueryRow(query, appName).Scan(&counter)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("no password found for '%s'", appName)
+		}
+		return 0, fmt.Errorf("failed to update OTP counter: %w", err)
+	}
+
+	return counter, nil
+}
+
+// SetOTPCounter sets the HOTP counter of an entry, for a secret that
+// replaces the previous one
+func (s *SQLiteStore) SetOTPCounter(appName string, counter uint64) error {
+	result, err := s.db.Exec("UPDATE passwords SET otp_counter = ? WHERE app_name = ?", counter, appName)
+	if err != nil {
+		return fmt.Errorf("failed to update OTP counter: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no password found for '%s'", appName)
+	}
+
 	return nil
 }
 
+// Delete removes a password entry together with its history and any
+// pending rotation
+func (s *SQLiteStore) Delete(appName string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete password: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM password_history WHERE app_name = ?", appName); err != nil {
+		return fmt.Errorf("failed to delete password history: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM pending_rotations WHERE app_name = ?", appName); err != nil {
+		return fmt.Errorf("failed to delete pending rotation: %w", err)
+	}
+
+	result, err := tx.Exec("DELETE FROM passwords WHERE app_name = ?", appName)
+	if err != nil {
+		return fmt.Errorf("failed to delete password: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check delete result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no password found for '%s'", appName)
+	}
+
+	return tx.Commit()
+}
+
 // List returns all password entries (without decrypted passwords)
 func (s *SQLiteStore) List() ([]*models.PasswordEntry, error) {
 	query := `
-	SELECT id, app_name, password, created_at, updated_at
+	SELECT ` + entryColumns + `
 	FROM passwords
 	ORDER BY app_name
 	`
@@ -139,12 +456,11 @@ func (s *SQLiteStore) List() ([]*models.PasswordEntry, error) {
 	
 	var entries []*models.PasswordEntry
 	for rows.Next() {
-		var entry models.PasswordEntry
-		err := rows.Scan(&entry.ID, &entry.AppName, &entry.Password, &entry.CreatedAt, &entry.UpdatedAt)
+		entry, err := scanEntry(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan password entry: %w", err)
 		}
-		entries = append(entries, &entry)
+		entries = append(entries, entry)
 	}
 	
 	return entries, nil
@@ -153,7 +469,7 @@ func (s *SQLiteStore) List() ([]*models.PasswordEntry, error) {
 // Search finds password entries that match the query (fuzzy search)
 func (s *SQLiteStore) Search(query string) ([]*models.PasswordEntry, error) {
 	sqlQuery := `
-	SELECT id, app_name, password, created_at, updated_at
+	SELECT ` + entryColumns + `
 	FROM passwords
 	WHERE app_name LIKE ?
 	ORDER BY app_name
@@ -168,18 +484,39 @@ func (s *SQLiteStore) Search(query string) ([]*models.PasswordEntry, error) {
 	
 	var entries []*models.PasswordEntry
 	for rows.Next() {
-		var entry models.PasswordEntry
-		err := rows.Scan(&entry.ID, &entry.AppName, &entry.Password, &entry.CreatedAt, &entry.UpdatedAt)
+		entry, err := scanEntry(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan password entry: %w", err)
 		}
-		entries = append(entries, &entry)
+		entries = append(entries, entry)
 	}
 	
 	return entries, nil
 }
 
+// DataVersion returns a number that changes whenever another connection
+// commits to the vault. It is read from one connection kept for the life
+// of the store, as values from different connections can't be compared.
+func (s *SQLiteStore) DataVersion() (int64, error) {
+	if s.versionConn == nil {
+		conn, err := s.db.Conn(context.Background())
+		if err != nil {
+			return 0, fmt.Errorf("failed to check the vault for changes: %w", err)
+		}
+		s.versionConn = conn
+	}
+
+	var version int64
+	if err := s.versionConn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to check the vault for changes: %w", err)
+	}
+	return version, nil
+}
+
 // Close closes the database connection
 func (s *SQLiteStore) Close() error {
+	if s.versionConn != nil {
+		s.versionConn.Close()
+	}
 	return s.db.Close()
 }
\ No newline at end of file
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"remembrall/pkg/models"
+)
+
+// tokenColumns lists the columns read by scanToken, in order
+const tokenColumns = "id, name, token_hash, read_only, tags, created_at"
+
+// scanToken reads an API token selected with tokenColumns
+func scanToken(row rowScanner) (*models.APIToken, error) {
+	var token models.APIToken
+	var tags string
+	if err := row.Scan(&token.ID, &token.Name, &token.TokenHash, &token.ReadOnly, &tags, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+	token.Tags = splitTags(tags)
+	return &token, nil
+}
+
+// SaveToken stores a new API token
+func (s *SQLiteStore) SaveToken(token *models.APIToken) error {
+	query := `
+	INSERT INTO api_tokens (name, token_hash, read_only, tags)
+	VALUES (?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(query, token.Name, token.TokenHash, token.ReadOnly, joinTags(token.Tags))
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return fmt.Errorf("a token named '%s' already exists", token.Name)
+		}
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+
+	return nil
+}
+
+// GetTokenByHash returns the API token with the given hash
+func (s *SQLiteStore) GetTokenByHash(hash string) (*models.APIToken, error) {
+	row := s.db.QueryRow("SELECT "+tokenColumns+" FROM api_tokens WHERE token_hash = ?", hash)
+
+	token, err := scanToken(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("unknown token")
+		}
+		return nil, fmt.Errorf("failed to retrieve token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListTokens returns all API tokens
+func (s *SQLiteStore) ListTokens() ([]*models.APIToken, error) {
+	rows, err := s.db.Query("SELECT " + tokenColumns + " FROM api_tokens ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.APIToken
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// DeleteToken revokes an API token
+func (s *SQLiteStore) DeleteToken(name string) error {
+	result, err := s.db.Exec("DELETE FROM api_tokens WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check delete result: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no token named '%s'", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+// Package dockercredential implements the docker-credential-helpers protocol:
+// docker runs "docker-credential-<name> <action>" and exchanges JSON (or a
+// bare server URL) over stdin and stdout.
+package dockercredential
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is reported to docker when no credentials exist for a server.
+// Docker recognises the exact message.
+var ErrNotFound = errors.New("credentials not found in native keychain")
+
+// Credentials are the credentials of one registry
+type Credentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// Helper stores registry credentials
+type Helper interface {
+	Add(creds *Credentials) error
+	Delete(serverURL string) error
+	Get(serverURL string) (username, secret string, err error)
+	List() (map[string]string, error)
+}
+
+// Serve runs one action. On failure the error message is also written to
+// out, which is where docker reads it from.
+func Serve(helper Helper, action string, in io.Reader, out io.Writer) error {
+	err := serve(helper, action, in, out)
+	if err != nil {
+		fmt.Fprintln(out, err)
+	}
+	return err
+}
+
+func serve(helper Helper, action string, in io.Reader, out io.Writer) error {
+	switch action {
+	case "store":
+		var creds Credentials
+		if err := json.NewDecoder(in).Decode(&creds); err != nil {
+			return fmt.Errorf("invalid credentials: %w", err)
+		}
+		if creds.ServerURL == "" {
+			return fmt.Errorf("no credentials server URL")
+		}
+		return helper.Add(&creds)
+
+	case "get":
+		serverURL, err := readServerURL(in)
+		if err != nil {
+			return err
+		}
+		username, secret, err := helper.Get(serverURL)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(out).Encode(Credentials{ServerURL: serverURL, Username: username, Secret: secret})
+
+	case "erase":
+		serverURL, err := readServerURL(in)
+		if err != nil {
+			return err
+		}
+		return helper.Delete(serverURL)
+
+	case "list":
+		accounts, err := helper.List()
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(out).Encode(accounts)
+
+	default:
+		return fmt.Errorf("unknown credential action '%s'", action)
+	}
+}
+
+// readServerURL reads the bare server URL sent for get and erase
+func readServerURL(in io.Reader) (string, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return "", fmt.Errorf("failed to read server URL: %w", err)
+	}
+
+	serverURL := string(bytes.TrimSpace(data))
+	if serverURL == "" {
+		return "", fmt.Errorf("no credentials server URL")
+	}
+	return serverURL, nil
+}
+
+// Normalize reduces a server URL to its host and path, so that
+// "https://registry.example.com/" and "registry.example.com" match
+func Normalize(serverURL string) string {
+	if _, rest, ok := strings.Cut(serverURL, "://"); ok {
+		serverURL = rest
+	}
+	serverURL = strings.TrimRight(serverURL, "/")
+
+	host, path, _ := strings.Cut(serverURL, "/")
+	if path == "" {
+		return strings.ToLower(host)
+	}
+	return strings.ToLower(host) + "/" + path
+}
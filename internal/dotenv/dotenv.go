@@ -0,0 +1,128 @@
+// Package dotenv parses and writes .env files.
+//
+// The supported syntax is the common subset understood by docker compose,
+// direnv and the various dotenv libraries: KEY=VALUE lines, an optional
+// leading "export", # comments, and single- or double-quoted values.
+package dotenv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Var is a single variable assignment
+type Var struct {
+	Key   string
+	Value string
+}
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Parse reads variable assignments in file order
+func Parse(r io.Reader) ([]Var, error) {
+	var vars []Var
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, rest, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !keyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNo)
+		}
+
+		value, err := parseValue(strings.TrimSpace(rest), scanner, &lineNo)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		vars = append(vars, Var{Key: key, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+// parseValue decodes a quoted or bare value. Double-quoted values may span
+// several lines and support \n, \t, \" and \\ escapes.
+func parseValue(raw string, scanner *bufio.Scanner, lineNo *int) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, "'"):
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return raw[1 : end+1], nil
+
+	case strings.HasPrefix(raw, `"`):
+		var value strings.Builder
+		text := raw[1:]
+		for {
+			for i := 0; i < len(text); i++ {
+				c := text[i]
+				if c == '"' {
+					return value.String(), nil
+				}
+				if c == '\\' && i+1 < len(text) {
+					i++
+					switch text[i] {
+					case 'n':
+						value.WriteByte('\n')
+					case 't':
+						value.WriteByte('\t')
+					case 'r':
+						value.WriteByte('\r')
+					default:
+						value.WriteByte(text[i])
+					}
+					continue
+				}
+				value.WriteByte(c)
+			}
+			if !scanner.Scan() {
+				return "", fmt.Errorf("unterminated double-quoted value")
+			}
+			*lineNo++
+			value.WriteByte('\n')
+			text = scanner.Text()
+		}
+
+	default:
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = raw[:i]
+		}
+		return strings.TrimSpace(raw), nil
+	}
+}
+
+// Format renders variables as a .env file, quoting values as needed
+func Format(vars []Var) []byte {
+	var buf bytes.Buffer
+	for _, v := range vars {
+		fmt.Fprintf(&buf, "%s=%s\n", v.Key, quote(v.Value))
+	}
+	return buf.Bytes()
+}
+
+func quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r'\"\\#$`") {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,156 @@
+// Package gitcredential implements the key=value protocol git uses to talk
+// to credential helpers (see gitcredentials(7) and git-credential(1)).
+package gitcredential
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// Credential is a credential description exchanged with git
+type Credential struct {
+	Protocol string
+	Host     string
+	Path     string
+	Username string
+	Password string
+}
+
+// Parse reads a credential description, stopping at a blank line or EOF.
+// Attributes the helper doesn't use are ignored.
+func Parse(r io.Reader) (*Credential, error) {
+	c := &Credential{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid credential line '%s'", line)
+		}
+
+		switch key {
+		case "protocol":
+			c.Protocol = value
+		case "host":
+			c.Host = value
+		case "path":
+			c.Path = value
+		case "username":
+			c.Username = value
+		case "password":
+			c.Password = value
+		case "url":
+			if err := c.setURL(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read credential: %w", err)
+	}
+	return c, nil
+}
+
+// setURL fills the attributes from a url=... line, as git itself does
+func (c *Credential) setURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid credential url '%s': %w", raw, err)
+	}
+
+	c.Protocol = u.Scheme
+	c.Host = u.Host
+	c.Path = strings.TrimPrefix(u.Path, "/")
+	if u.User != nil {
+		c.Username = u.User.Username()
+		if password, ok := u.User.Password(); ok {
+			c.Password = password
+		}
+	}
+	return nil
+}
+
+// Write sends the non-empty attributes back to git
+func (c *Credential) Write(w io.Writer) error {
+	attributes := []struct{ key, value string }{
+		{"protocol", c.Protocol},
+		{"host", c.Host},
+		{"path", c.Path},
+		{"username", c.Username},
+		{"password", c.Password},
+	}
+
+	for _, a := range attributes {
+		if a.value == "" {
+			continue
+		}
+		if strings.ContainsAny(a.value, "\n\x00") {
+			return fmt.Errorf("credential %s contains a newline or NUL byte", a.key)
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", a.key, a.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// URL returns the credential as a URL without user information
+func (c *Credential) URL() string {
+	u := url.URL{Scheme: c.Protocol, Host: c.Host}
+	if c.Path != "" {
+		u.Path = "/" + c.Path
+	}
+	return u.String()
+}
+
+// Match scores how well a stored URL and username fit the credential.
+// A stored URL without a scheme matches any protocol, one without a path
+// matches every path on the host, and an empty username matches any user.
+// More specific matches score higher; ok is false when they don't fit.
+func (c *Credential) Match(storedURL, username string) (score int, ok bool) {
+	if storedURL == "" {
+		return 0, false
+	}
+	if !strings.Contains(storedURL, "://") {
+		storedURL = "//" + storedURL
+	}
+	u, err := url.Parse(storedURL)
+	if err != nil || u.Host == "" {
+		return 0, false
+	}
+
+	if u.Scheme != "" {
+		if !strings.EqualFold(u.Scheme, c.Protocol) {
+			return 0, false
+		}
+		score++
+	}
+	if !strings.EqualFold(u.Host, c.Host) {
+		return 0, false
+	}
+
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		requested := strings.Trim(c.Path, "/")
+		if requested != path && !strings.HasPrefix(requested, path+"/") {
+			return 0, false
+		}
+		score += 1 + len(path)
+	}
+
+	if username != "" && c.Username != "" {
+		if username != c.Username {
+			return 0, false
+		}
+		score += 1000
+	}
+
+	return score, true
+}
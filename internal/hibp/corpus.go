@@ -0,0 +1,180 @@
+// Package hibp checks passwords against a local copy of the Have I Been
+// Pwned "Pwned Passwords" corpus, so no hash ever leaves the machine.
+//
+// The corpus is the SHA-1 edition ordered by hash, one "HASH:COUNT" line
+// per password. It is memory-mapped and binary-searched in place. For
+// repeated checks a compact Bloom filter can be built from it (see
+// BuildFilter), which answers "not breached" without the corpus.
+package hibp
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// hashLength is the length of a hex SHA-1 hash in the corpus
+const hashLength = sha1.Size * 2
+
+// Sum returns the SHA-1 hash of a password, as used by the corpus
+func Sum(password string) [sha1.Size]byte {
+	return sha1.Sum([]byte(password))
+}
+
+// Corpus is a memory-mapped Pwned Passwords file
+type Corpus struct {
+	data []byte
+}
+
+// OpenCorpus maps a Pwned Passwords file ordered by hash
+func OpenCorpus(path string) (*Corpus, error) {
+	data, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Catch the NTLM or unsorted editions early
+	first, _ := line(data, 0)
+	if _, _, err := parseLine(first); err != nil {
+		syscall.Munmap(data)
+		return nil, fmt.Errorf("'%s' is not a SHA-1 Pwned Passwords file: %w", path, err)
+	}
+	if !bytes.Equal(first[:hashLength], bytes.ToUpper(first[:hashLength])) {
+		syscall.Munmap(data)
+		return nil, fmt.Errorf("'%s' has lowercase hashes, expected the file ordered by hash as downloaded", path)
+	}
+
+	return &Corpus{data: data}, nil
+}
+
+// Close unmaps the corpus
+func (c *Corpus) Close() error {
+	return syscall.Munmap(c.data)
+}
+
+// Count returns how often a password hash was seen in breaches, or zero
+// when it is not in the corpus
+func (c *Corpus) Count(sum [sha1.Size]byte) (int, error) {
+	target := []byte(fmt.Sprintf("%X", sum))
+
+	// Find the first line whose hash is not below the target. lo is
+	// always the start of a line, hi the start of a line or the end.
+	lo, hi := 0, len(c.data)
+	for lo < hi {
+		start := lineStart(c.data, lo+(hi-lo)/2)
+		text, next := line(c.data, start)
+		if len(text) < hashLength {
+			return 0, fmt.Errorf("malformed corpus line at offset %d", start)
+		}
+		if bytes.Compare(text[:hashLength], target) < 0 {
+			lo = next
+		} else {
+			hi = start
+		}
+	}
+
+	if lo >= len(c.data) {
+		return 0, nil
+	}
+	text, _ := line(c.data, lo)
+	hash, count, err := parseLine(text)
+	if err != nil {
+		return 0, fmt.Errorf("malformed corpus line at offset %d: %w", lo, err)
+	}
+	if hash != sum {
+		return 0, nil
+	}
+	return count, nil
+}
+
+// each calls visit with the hash of every line in the corpus
+func (c *Corpus) each(visit func(sum [sha1.Size]byte)) error {
+	for offset := 0; offset < len(c.data); {
+		text, next := line(c.data, offset)
+		if len(bytes.TrimSpace(text)) > 0 {
+			hash, _, err := parseLine(text)
+			if err != nil {
+				return fmt.Errorf("malformed corpus line at offset %d: %w", offset, err)
+			}
+			visit(hash)
+		}
+		offset = next
+	}
+	return nil
+}
+
+// lines counts the lines of the corpus
+func (c *Corpus) lines() int {
+	n := bytes.Count(c.data, []byte{'\n'})
+	if len(c.data) > 0 && c.data[len(c.data)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// parseLine reads a "HASH:COUNT" line; the count is optional
+func parseLine(text []byte) ([sha1.Size]byte, int, error) {
+	var sum [sha1.Size]byte
+	text = bytes.TrimRight(text, "\r")
+	if len(text) < hashLength {
+		return sum, 0, fmt.Errorf("expected a 40 character SHA-1 hash")
+	}
+	if _, err := hex.Decode(sum[:], text[:hashLength]); err != nil {
+		return sum, 0, fmt.Errorf("expected a 40 character SHA-1 hash")
+	}
+
+	count := 1
+	if rest := text[hashLength:]; len(rest) > 0 {
+		if rest[0] != ':' {
+			return sum, 0, fmt.Errorf("expected HASH:COUNT")
+		}
+		n, err := strconv.Atoi(string(rest[1:]))
+		if err != nil {
+			return sum, 0, fmt.Errorf("invalid count '%s'", rest[1:])
+		}
+		count = n
+	}
+	return sum, count, nil
+}
+
+// lineStart returns the start of the line holding offset
+func lineStart(data []byte, offset int) int {
+	return bytes.LastIndexByte(data[:offset], '\n') + 1
+}
+
+// line returns the line starting at offset, without its newline, and the
+// offset of the next line
+func line(data []byte, offset int) ([]byte, int) {
+	end := bytes.IndexByte(data[offset:], '\n')
+	if end < 0 {
+		return data[offset:], len(data)
+	}
+	return data[offset : offset+end], offset + end + 1
+}
+
+// mapFile maps a whole file read-only
+func mapFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	if info.Size() == 0 {
+		return nil, fmt.Errorf("'%s' is empty", path)
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map '%s': %w", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,166 @@
+package hibp
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"syscall"
+)
+
+// filterMagic starts every filter file
+const filterMagic = "RMBLOOM1"
+
+// filterHeaderSize is the magic, the hash count, the size in bits and the
+// number of hashes added
+const filterHeaderSize = len(filterMagic) + 4 + 8 + 8
+
+// Filter is a memory-mapped Bloom filter of breached password hashes. It
+// never misses a breached password; a small share of other passwords is
+// reported as breached too, at the rate chosen when it was built.
+type Filter struct {
+	data   []byte
+	bits   []byte
+	hashes uint32
+	size   uint64
+	count  uint64
+}
+
+// FilterStats describes a built filter
+type FilterStats struct {
+	Hashes            uint64
+	Bytes             int64
+	FalsePositiveRate float64
+}
+
+// BuildFilter writes a Bloom filter holding every hash of the corpus to
+// path. The false positive rate sets the size: about 1.2 bytes per hash
+// at 1%, 1.8 at 0.1%.
+func BuildFilter(corpus *Corpus, path string, falsePositiveRate float64) (*FilterStats, error) {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, fmt.Errorf("false positive rate must be between 0 and 1")
+	}
+
+	n := corpus.lines()
+	if n == 0 {
+		return nil, fmt.Errorf("the corpus is empty")
+	}
+	size := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	size = (size + 7) / 8 * 8
+	hashes := uint32(math.Max(1, math.Round(float64(size)/float64(n)*math.Ln2)))
+
+	// Build in a temporary file mapped into memory, so filters larger than
+	// the free memory work and a failed build leaves nothing behind
+	temp := path + ".tmp"
+	file, err := os.OpenFile(temp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create '%s': %w", temp, err)
+	}
+	defer os.Remove(temp)
+	defer file.Close()
+
+	total := int64(filterHeaderSize) + int64(size/8)
+	if err := file.Truncate(total); err != nil {
+		return nil, fmt.Errorf("failed to size '%s': %w", temp, err)
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(total), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map '%s': %w", temp, err)
+	}
+
+	filter := &Filter{data: data, bits: data[filterHeaderSize:], hashes: hashes, size: size}
+	err = corpus.each(func(sum [sha1.Size]byte) {
+		filter.add(sum)
+		filter.count++
+	})
+	if err == nil {
+		filter.writeHeader()
+	}
+	if unmapErr := syscall.Munmap(data); err == nil {
+		err = unmapErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to write '%s': %w", temp, err)
+	}
+	if err := os.Rename(temp, path); err != nil {
+		return nil, fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+
+	rate := math.Pow(1-math.Exp(-float64(hashes)*float64(filter.count)/float64(size)), float64(hashes))
+	return &FilterStats{Hashes: filter.count, Bytes: total, FalsePositiveRate: rate}, nil
+}
+
+// OpenFilter maps a filter written by BuildFilter
+func OpenFilter(path string) (*Filter, error) {
+	data, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < filterHeaderSize || string(data[:len(filterMagic)]) != filterMagic {
+		syscall.Munmap(data)
+		return nil, fmt.Errorf("'%s' is not a breached password filter", path)
+	}
+
+	header := data[len(filterMagic):]
+	filter := &Filter{
+		data:   data,
+		bits:   data[filterHeaderSize:],
+		hashes: binary.LittleEndian.Uint32(header),
+		size:   binary.LittleEndian.Uint64(header[4:]),
+		count:  binary.LittleEndian.Uint64(header[12:]),
+	}
+	if filter.hashes == 0 || filter.size == 0 || uint64(len(filter.bits))*8 != filter.size {
+		syscall.Munmap(data)
+		return nil, fmt.Errorf("'%s' is truncated or corrupt", path)
+	}
+	return filter, nil
+}
+
+// Close unmaps the filter
+func (f *Filter) Close() error {
+	return syscall.Munmap(f.data)
+}
+
+// Count returns the number of hashes in the filter
+func (f *Filter) Count() uint64 {
+	return f.count
+}
+
+// Contains reports whether a hash may be in the filter; false is certain
+func (f *Filter) Contains(sum [sha1.Size]byte) bool {
+	h1, h2 := f.positions(sum)
+	for i := uint64(0); i < uint64(f.hashes); i++ {
+		bit := (h1 + i*h2) % f.size
+		if f.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Filter) add(sum [sha1.Size]byte) {
+	h1, h2 := f.positions(sum)
+	for i := uint64(0); i < uint64(f.hashes); i++ {
+		bit := (h1 + i*h2) % f.size
+		f.bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// positions derives the two hashes for double hashing. SHA-1 output is
+// already uniform, so its bytes are used directly.
+func (f *Filter) positions(sum [sha1.Size]byte) (uint64, uint64) {
+	return binary.LittleEndian.Uint64(sum[0:8]), binary.LittleEndian.Uint64(sum[8:16]) | 1
+}
+
+func (f *Filter) writeHeader() {
+	copy(f.data, filterMagic)
+	header := f.data[len(filterMagic):]
+	binary.LittleEndian.PutUint32(header, f.hashes)
+	binary.LittleEndian.PutUint64(header[4:], f.size)
+	binary.LittleEndian.PutUint64(header[12:], f.count)
+}
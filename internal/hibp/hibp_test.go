@@ -0,0 +1,166 @@
+package hibp
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testdata/corpus.txt holds the hashes of these passwords, ordered by hash
+// as in the real corpus, with made-up counts. "dragon" has no count.
+var breached = map[string]int{
+	"password": 9545824, // first line
+	"123456":   37359195,
+	"dragon":   1,
+	"qwerty":   10556095,
+	"letmein":  455271,
+	"trustno1": 154046,
+	"iloveyou": 1645488,
+	"hunter2":  24230, // last line
+}
+
+func parseSum(t *testing.T, s string) [sha1.Size]byte {
+	t.Helper()
+	var sum [sha1.Size]byte
+	if _, err := hex.Decode(sum[:], []byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	return sum
+}
+
+// corpusVariants returns the fixture as is, with CRLF line endings and
+// without its final newline
+func corpusVariants(t *testing.T) map[string]string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "corpus.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	variants := map[string]string{"lf": filepath.Join("testdata", "corpus.txt")}
+	for name, content := range map[string][]byte{
+		"crlf":          bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n")),
+		"no final line": bytes.TrimSuffix(data, []byte("\n")),
+	} {
+		path := filepath.Join(dir, name+".txt")
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+		variants[name] = path
+	}
+	return variants
+}
+
+func TestCorpusCount(t *testing.T) {
+	missing := map[string][sha1.Size]byte{
+		"before the first line": parseSum(t, "0000000000000000000000000000000000000000"),
+		"between lines":         parseSum(t, "A000000000000000000000000000000000000000"),
+		"after the last line":   parseSum(t, "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"),
+		"not breached":          Sum("correct horse battery staple"),
+	}
+
+	for variant, path := range corpusVariants(t) {
+		t.Run(variant, func(t *testing.T) {
+			corpus, err := OpenCorpus(path)
+			if err != nil {
+				t.Fatalf("OpenCorpus: %v", err)
+			}
+			defer corpus.Close()
+
+			for password, want := range breached {
+				got, err := corpus.Count(Sum(password))
+				if err != nil {
+					t.Errorf("Count(%q): %v", password, err)
+				} else if got != want {
+					t.Errorf("Count(%q) = %d, want %d", password, got, want)
+				}
+			}
+			for name, sum := range missing {
+				got, err := corpus.Count(sum)
+				if err != nil {
+					t.Errorf("Count(%s): %v", name, err)
+				} else if got != 0 {
+					t.Errorf("Count(%s) = %d, want 0", name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenCorpusRejectsOtherEditions(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"ntlm":      "8846F7EAEE8FB117AD06BDD830B7586C:9545824\n",
+		"lowercase": "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8:9545824\n",
+		"empty":     "",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if corpus, err := OpenCorpus(path); err == nil {
+			corpus.Close()
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFilterRoundTrip(t *testing.T) {
+	corpus, err := OpenCorpus(filepath.Join("testdata", "corpus.txt"))
+	if err != nil {
+		t.Fatalf("OpenCorpus: %v", err)
+	}
+	defer corpus.Close()
+
+	path := filepath.Join(t.TempDir(), "filter.bin")
+	stats, err := BuildFilter(corpus, path, 0.001)
+	if err != nil {
+		t.Fatalf("BuildFilter: %v", err)
+	}
+	if stats.Hashes != uint64(len(breached)) {
+		t.Errorf("built with %d hashes, want %d", stats.Hashes, len(breached))
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind")
+	}
+
+	filter, err := OpenFilter(path)
+	if err != nil {
+		t.Fatalf("OpenFilter: %v", err)
+	}
+	defer filter.Close()
+
+	if filter.Count() != uint64(len(breached)) {
+		t.Errorf("Count() = %d, want %d", filter.Count(), len(breached))
+	}
+	for password := range breached {
+		if !filter.Contains(Sum(password)) {
+			t.Errorf("Contains(%q) = false, a Bloom filter never misses", password)
+		}
+	}
+
+	// At 0.1% a handful of false positives among a thousand is already
+	// very unlikely; this catches a filter that answers yes to everything
+	positives := 0
+	for i := 0; i < 1000; i++ {
+		if filter.Contains(Sum(fmt.Sprintf("not breached %d", i))) {
+			positives++
+		}
+	}
+	if positives > 10 {
+		t.Errorf("%d of 1000 other passwords reported as breached", positives)
+	}
+}
+
+func TestOpenFilterRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join("testdata", "corpus.txt")
+	if filter, err := OpenFilter(path); err == nil {
+		filter.Close()
+		t.Errorf("OpenFilter(%s): expected an error", path)
+	}
+}
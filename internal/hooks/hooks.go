@@ -0,0 +1,182 @@
+// Package hooks runs user-defined executables when entries change.
+//
+// Event hooks live in ~/.remembrall-hooks, named after the event and when
+// they run: pre-save, post-save, pre-update, post-update, pre-delete and
+// post-delete. A pre hook exiting non-zero stops the change; a failing post
+// hook is only reported. Event hooks are told the event and entry name but
+// never a secret.
+//
+// A rotation hook is referenced by an entry and applies a new password to
+// the service it belongs to, such as a database. It receives the old and new
+// secret, and the new one is only committed when it exits zero.
+//
+// Every hook gets a JSON Payload on stdin, so secrets travel over a pipe and
+// never appear in argv or the environment, and runs with its stdout sent to
+// stderr, keeping the output of credential helpers clean. Hooks that another
+// user could replace, because they or their directory are writable by group
+// or others or they belong to someone else, are refused.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Events hooks run for
+const (
+	EventSave   = "save"
+	EventUpdate = "update"
+	EventDelete = "delete"
+	EventRotate = "rotate"
+)
+
+const hooksDir = ".remembrall-hooks"
+
+// Timeout bounds how long a hook may run
+const Timeout = 5 * time.Minute
+
+// Payload is written to a hook's stdin as JSON. Old and New are only set
+// for rotation hooks.
+type Payload struct {
+	Event string `json:"event"`
+	App   string `json:"app"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// Dir returns the directory holding the event hooks
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, hooksDir), nil
+}
+
+// Pre runs the pre hook of an event, if there is one. An error means the
+// change must not go ahead.
+func Pre(event, app string) error {
+	if err := runEventHook("pre-"+event, event, app); err != nil {
+		return fmt.Errorf("pre-%s hook rejected the change: %w", event, err)
+	}
+	return nil
+}
+
+// Post runs the post hook of an event, if there is one. The change has
+// already happened, so callers only report an error.
+func Post(event, app string) error {
+	if err := runEventHook("post-"+event, event, app); err != nil {
+		return fmt.Errorf("post-%s hook failed: %w", event, err)
+	}
+	return nil
+}
+
+// runEventHook runs the named event hook. Like git, hooks that are missing
+// or not executable are skipped.
+func runEventHook(name, event, app string) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return nil
+	}
+
+	return Run(path, &Payload{Event: event, App: app})
+}
+
+// Run executes a hook with the payload on stdin. REMEMBRALL_EVENT and
+// REMEMBRALL_APP are set for hooks that only need those.
+func Run(path string, payload *Payload) error {
+	if err := Check(path); err != nil {
+		return err
+	}
+
+	// Passwords are written as they are, not with HTML escapes
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(payload); err != nil {
+		return fmt.Errorf("failed to encode hook input: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = &input
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "REMEMBRALL_EVENT="+payload.Event, "REMEMBRALL_APP="+payload.App)
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s", Timeout)
+		}
+		return err
+	}
+	return nil
+}
+
+// Check reports whether path is an executable a hook can be run from
+func Check(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return fmt.Errorf("'%s' is not an executable file", path)
+	}
+
+	// Hooks receive secrets, so only the user (or root) may be able to
+	// change what runs
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	for _, p := range []string{resolved, filepath.Dir(resolved)} {
+		if err := checkOwner(p); err != nil {
+			return fmt.Errorf("refusing hook '%s': %w", path, err)
+		}
+	}
+	return nil
+}
+
+// checkOwner fails when a file or directory is writable by group or others,
+// or owned by a user other than the current one or root
+func checkOwner(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+
+	// Sticky directories such as /tmp only let owners replace files
+	sticky := info.IsDir() && info.Mode()&os.ModeSticky != 0
+	if info.Mode().Perm()&0022 != 0 && !sticky {
+		return fmt.Errorf("'%s' is writable by other users", path)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		if uid := int(stat.Uid); uid != os.Getuid() && uid != 0 {
+			return fmt.Errorf("'%s' belongs to another user", path)
+		}
+	}
+	return nil
+}
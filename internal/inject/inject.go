@@ -0,0 +1,102 @@
+// Package inject replaces secret references in text templates.
+//
+// Two reference forms are recognised:
+//
+//	{{ remembrall "app-name" "field" }}   (the field is optional)
+//	remembrall://app-name/field
+//
+// Everything else in the template is copied through unchanged.
+package inject
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Reference is a secret reference found in a template
+type Reference struct {
+	// Raw is the reference exactly as written in the template
+	Raw string
+	// Path is the entry name, or for URIs everything after the scheme
+	Path string
+	// Field is the field requested by a template action, empty for the password
+	Field string
+	// URI reports whether the reference used the remembrall:// form, in which
+	// case the last path segment may name a field
+	URI bool
+}
+
+// LookupFunc returns the secret for a reference
+type LookupFunc func(ref Reference) (string, error)
+
+// UnresolvedError lists every reference that could not be resolved
+type UnresolvedError struct {
+	Errors []error
+}
+
+func (e *UnresolvedError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		parts[i] = err.Error()
+	}
+	return fmt.Sprintf("%d unresolved reference(s):\n  %s", len(e.Errors), strings.Join(parts, "\n  "))
+}
+
+const quoted = `"(?:[^"\\]|\\.)*"`
+
+var (
+	actionPattern    = regexp.MustCompile(`\{\{-?\s*remembrall\s+(` + quoted + `)(?:\s+(` + quoted + `))?\s*-?\}\}`)
+	uriPattern       = regexp.MustCompile("remembrall://[^\\s\"'<>`,;)\\]}]+")
+	referencePattern = regexp.MustCompile(actionPattern.String() + "|" + uriPattern.String())
+)
+
+// Render replaces every reference in the template in a single pass, so
+// secrets that happen to look like references are never expanded. It fails
+// without returning output if any reference cannot be resolved, so a
+// template is never half-rendered.
+func Render(template []byte, lookup LookupFunc) ([]byte, error) {
+	var failures []error
+	output := referencePattern.ReplaceAllStringFunc(string(template), func(match string) string {
+		ref, err := parseReference(match)
+		if err == nil {
+			var value string
+			if value, err = lookup(ref); err == nil {
+				return value
+			}
+		}
+		failures = append(failures, fmt.Errorf("%s: %w", match, err))
+		return match
+	})
+
+	if len(failures) > 0 {
+		return nil, &UnresolvedError{Errors: failures}
+	}
+	return []byte(output), nil
+}
+
+// parseReference decodes a match of referencePattern
+func parseReference(match string) (Reference, error) {
+	if strings.HasPrefix(match, "remembrall://") {
+		path, err := url.PathUnescape(strings.TrimPrefix(match, "remembrall://"))
+		if err != nil || path == "" {
+			return Reference{}, fmt.Errorf("invalid reference")
+		}
+		return Reference{Raw: match, Path: path, URI: true}, nil
+	}
+
+	groups := actionPattern.FindStringSubmatch(match)
+	app, err := strconv.Unquote(groups[1])
+	if err != nil {
+		return Reference{}, fmt.Errorf("invalid entry name")
+	}
+	var field string
+	if groups[2] != "" {
+		if field, err = strconv.Unquote(groups[2]); err != nil {
+			return Reference{}, fmt.Errorf("invalid field name")
+		}
+	}
+	return Reference{Raw: match, Path: app, Field: field}, nil
+}
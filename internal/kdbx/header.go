@@ -0,0 +1,417 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Variant dictionary value types
+const (
+	variantEnd       = 0x00
+	variantUInt32    = 0x04
+	variantUInt64    = 0x05
+	variantBool      = 0x08
+	variantInt32     = 0x0C
+	variantInt64     = 0x0D
+	variantString    = 0x18
+	variantByteArray = 0x42
+
+	variantVersion = 0x0100
+)
+
+// variantDictionary is the typed key/value map used for KDF parameters
+type variantDictionary struct {
+	keys   []string
+	types  map[string]byte
+	values map[string][]byte
+}
+
+func newVariantDictionary() *variantDictionary {
+	return &variantDictionary{
+		types:  make(map[string]byte),
+		values: make(map[string][]byte),
+	}
+}
+
+func (d *variantDictionary) set(key string, kind byte, value []byte) {
+	if _, ok := d.types[key]; !ok {
+		d.keys = append(d.keys, key)
+	}
+	d.types[key] = kind
+	d.values[key] = value
+}
+
+func (d *variantDictionary) setUint32(key string, v uint32) {
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, v)
+	d.set(key, variantUInt32, buf)
+}
+
+func (d *variantDictionary) setUint64(key string, v uint64) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, v)
+	d.set(key, variantUInt64, buf)
+}
+
+func (d *variantDictionary) bytes(key string) ([]byte, error) {
+	value, ok := d.values[key]
+	if !ok {
+		return nil, fmt.Errorf("missing KDF parameter '%s'", key)
+	}
+	return value, nil
+}
+
+func (d *variantDictionary) uint32(key string) (uint32, error) {
+	value, err := d.bytes(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(value) != 4 {
+		return 0, fmt.Errorf("invalid KDF parameter '%s'", key)
+	}
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+func (d *variantDictionary) uint64(key string) (uint64, error) {
+	value, err := d.bytes(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(value) != 8 {
+		return 0, fmt.Errorf("invalid KDF parameter '%s'", key)
+	}
+	return binary.LittleEndian.Uint64(value), nil
+}
+
+func parseVariantDictionary(data []byte) (*variantDictionary, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("variant dictionary too short")
+	}
+	if binary.LittleEndian.Uint16(data)&0xFF00 != variantVersion&0xFF00 {
+		return nil, fmt.Errorf("unsupported variant dictionary version")
+	}
+
+	d := newVariantDictionary()
+	r := bytes.NewReader(data[2:])
+	for {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("truncated variant dictionary")
+		}
+		if kind == variantEnd {
+			return d, nil
+		}
+
+		key, err := readSized(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readSized(r)
+		if err != nil {
+			return nil, err
+		}
+		d.set(string(key), kind, value)
+	}
+}
+
+func readSized(r io.Reader) ([]byte, error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, fmt.Errorf("truncated variant dictionary")
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid variant dictionary item size")
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("truncated variant dictionary")
+	}
+	return buf, nil
+}
+
+func (d *variantDictionary) marshal() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint16(variantVersion))
+	for _, key := range d.keys {
+		value := d.values[key]
+		buf.WriteByte(d.types[key])
+		binary.Write(&buf, binary.LittleEndian, int32(len(key)))
+		buf.WriteString(key)
+		binary.Write(&buf, binary.LittleEndian, int32(len(value)))
+		buf.Write(value)
+	}
+	buf.WriteByte(variantEnd)
+	return buf.Bytes()
+}
+
+// header holds the outer header fields this package understands
+type header struct {
+	version      uint16
+	cipherID     []byte
+	compression  uint32
+	masterSeed   []byte
+	encryptionIV []byte
+	kdf          *variantDictionary
+
+	// KDBX 3.1 keeps the inner stream settings in the outer header
+	streamStartBytes []byte
+	streamID         uint32
+	streamKey        []byte
+}
+
+// readHeader parses the outer header and returns it along with its raw bytes
+func readHeader(r io.Reader) (*header, []byte, error) {
+	var raw bytes.Buffer
+	tee := io.TeeReader(r, &raw)
+
+	var preamble struct {
+		Sig1, Sig2, Version uint32
+	}
+	if err := binary.Read(tee, binary.LittleEndian, &preamble); err != nil {
+		return nil, nil, fmt.Errorf("not a KeePass database: %w", err)
+	}
+	if preamble.Sig1 != signature1 || preamble.Sig2 != signature2 {
+		return nil, nil, fmt.Errorf("not a KeePass database")
+	}
+	major, minor := preamble.Version>>16, preamble.Version&0xFFFF
+	if major != versionMajor4 && (major != versionMajor3 || minor < 1) {
+		return nil, nil, fmt.Errorf("unsupported KDBX version %d.%d, only KDBX 3.1 and 4 are supported", major, minor)
+	}
+
+	h := &header{version: uint16(major)}
+	transform := newVariantDictionary()
+	for {
+		var id uint8
+		if err := binary.Read(tee, binary.LittleEndian, &id); err != nil {
+			return nil, nil, fmt.Errorf("truncated header")
+		}
+		// Field sizes grew from 16 to 32 bits in KDBX 4
+		var size uint32
+		if h.version == versionMajor3 {
+			var size16 uint16
+			if err := binary.Read(tee, binary.LittleEndian, &size16); err != nil {
+				return nil, nil, fmt.Errorf("truncated header")
+			}
+			size = uint32(size16)
+		} else if err := binary.Read(tee, binary.LittleEndian, &size); err != nil {
+			return nil, nil, fmt.Errorf("truncated header")
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(tee, data); err != nil {
+			return nil, nil, fmt.Errorf("truncated header")
+		}
+
+		switch id {
+		case headerEndOfHeader:
+			if h.version == versionMajor3 {
+				// Describe the AES-KDF like KDBX 4 does
+				transform.set("$UUID", variantByteArray, kdfAES)
+				h.kdf = transform
+				if h.streamStartBytes == nil || h.streamKey == nil {
+					return nil, nil, fmt.Errorf("incomplete header")
+				}
+			}
+			if h.cipherID == nil || h.masterSeed == nil || h.encryptionIV == nil || h.kdf == nil {
+				return nil, nil, fmt.Errorf("incomplete header")
+			}
+			return h, raw.Bytes(), nil
+		case headerCipherID:
+			h.cipherID = data
+		case headerCompressionFlags:
+			if len(data) != 4 {
+				return nil, nil, fmt.Errorf("invalid compression flags")
+			}
+			h.compression = binary.LittleEndian.Uint32(data)
+		case headerMasterSeed:
+			if len(data) != 32 {
+				return nil, nil, fmt.Errorf("invalid master seed")
+			}
+			h.masterSeed = data
+		case headerEncryptionIV:
+			h.encryptionIV = data
+		case headerKdfParameters:
+			kdf, err := parseVariantDictionary(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			h.kdf = kdf
+		case headerPublicCustomData:
+			// Plugin data, not needed
+		case headerTransformSeed:
+			transform.set("S", variantByteArray, data)
+		case headerTransformRounds:
+			transform.set("R", variantUInt64, data)
+		case headerProtectedStreamKey:
+			h.streamKey = data
+		case headerStreamStartBytes:
+			h.streamStartBytes = data
+		case headerInnerRandomStream:
+			if len(data) != 4 {
+				return nil, nil, fmt.Errorf("invalid inner stream id")
+			}
+			h.streamID = binary.LittleEndian.Uint32(data)
+		default:
+			return nil, nil, fmt.Errorf("unknown header field %d", id)
+		}
+	}
+}
+
+// marshal encodes the outer header, including the end-of-header field
+func (h *header) marshal() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, signature1)
+	binary.Write(&buf, binary.LittleEndian, signature2)
+	binary.Write(&buf, binary.LittleEndian, uint32(fileVersion40))
+
+	writeField := func(id uint8, data []byte) {
+		buf.WriteByte(id)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+		buf.Write(data)
+	}
+
+	compression := make([]byte, 4)
+	binary.LittleEndian.PutUint32(compression, h.compression)
+
+	writeField(headerCipherID, h.cipherID)
+	writeField(headerCompressionFlags, compression)
+	writeField(headerMasterSeed, h.masterSeed)
+	writeField(headerEncryptionIV, h.encryptionIV)
+	writeField(headerKdfParameters, h.kdf.marshal())
+	writeField(headerEndOfHeader, []byte{'\r', '\n', '\r', '\n'})
+	return buf.Bytes()
+}
+
+// compositeKey builds the KeePass composite key from the password and, if
+// the database uses one, the key file
+func compositeKey(password string, keyFile []byte) ([]byte, error) {
+	passwordHash := sha256.Sum256([]byte(password))
+	composite := sha256.New()
+	composite.Write(passwordHash[:])
+	if keyFile != nil {
+		key, err := keyFileKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		composite.Write(key)
+	}
+	return composite.Sum(nil), nil
+}
+
+// transformKey runs the KDF described by the header over the composite key
+func (h *header) transformKey(composite []byte) ([]byte, error) {
+	kdfID, err := h.kdf.bytes("$UUID")
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.Equal(kdfID, kdfArgon2d), bytes.Equal(kdfID, kdfArgon2id):
+		salt, err := h.kdf.bytes("S")
+		if err != nil {
+			return nil, err
+		}
+		parallelism, err := h.kdf.uint32("P")
+		if err != nil {
+			return nil, err
+		}
+		memory, err := h.kdf.uint64("M")
+		if err != nil {
+			return nil, err
+		}
+		iterations, err := h.kdf.uint64("I")
+		if err != nil {
+			return nil, err
+		}
+		version, err := h.kdf.uint32("V")
+		if err != nil {
+			return nil, err
+		}
+		if version != argon2Version {
+			return nil, fmt.Errorf("unsupported Argon2 version 0x%x", version)
+		}
+		if parallelism == 0 || iterations == 0 || memory/1024 > 0xFFFFFFFF || iterations > 0xFFFFFFFF {
+			return nil, fmt.Errorf("invalid Argon2 parameters")
+		}
+
+		mode := argon2d
+		if bytes.Equal(kdfID, kdfArgon2id) {
+			mode = argon2id
+		}
+		return argon2Key(mode, composite, salt, uint32(iterations), uint32(memory/1024), parallelism, 32), nil
+
+	case bytes.Equal(kdfID, kdfAES):
+		seed, err := h.kdf.bytes("S")
+		if err != nil {
+			return nil, err
+		}
+		rounds, err := h.kdf.uint64("R")
+		if err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(seed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AES-KDF seed: %w", err)
+		}
+
+		key := make([]byte, len(composite))
+		copy(key, composite)
+		for i := uint64(0); i < rounds; i++ {
+			block.Encrypt(key[:16], key[:16])
+			block.Encrypt(key[16:], key[16:])
+		}
+		transformed := sha256.Sum256(key)
+		return transformed[:], nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key derivation function")
+	}
+}
+
+// deriveKeys returns the payload encryption key and the HMAC base key
+func (h *header) deriveKeys(password string, keyFile []byte) (encryptionKey, hmacKey []byte, err error) {
+	composite, err := compositeKey(password, keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	transformed, err := h.transformKey(composite)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryption := sha256.New()
+	encryption.Write(h.masterSeed)
+	encryption.Write(transformed)
+
+	mac := sha512.New()
+	mac.Write(h.masterSeed)
+	mac.Write(transformed)
+	mac.Write([]byte{0x01})
+
+	return encryption.Sum(nil), mac.Sum(nil), nil
+}
+
+// blockHMAC computes the HMAC of a payload block (or of the header for headerHMACBlockIndex)
+func blockHMAC(hmacKey []byte, index uint64, data []byte) []byte {
+	var indexBytes [8]byte
+	binary.LittleEndian.PutUint64(indexBytes[:], index)
+
+	keyHash := sha512.New()
+	keyHash.Write(indexBytes[:])
+	keyHash.Write(hmacKey)
+
+	mac := hmac.New(sha256.New, keyHash.Sum(nil))
+	if index != headerHMACBlockIndex {
+		var size [4]byte
+		binary.LittleEndian.PutUint32(size[:], uint32(len(data)))
+		mac.Write(indexBytes[:])
+		mac.Write(size[:])
+	}
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,110 @@
+// Package kdbx reads KeePass KDBX 3.1 and 4 database files and writes KDBX 4.
+//
+// Only the parts of the format needed to exchange entries with KeePass and
+// KeePassXC are implemented: password credentials, optionally with a key
+// file on read, the Argon2d, Argon2id and AES key derivation functions,
+// AES-256 and ChaCha20 payload encryption and the Salsa20 and ChaCha20 inner
+// protected streams. Attachments, entry history and custom icons are ignored
+// on read.
+package kdbx
+
+import (
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCredentials is returned when the password does not open the database
+var ErrInvalidCredentials = errors.New("invalid password or corrupted database")
+
+// Database is the flattened content of a KDBX file
+type Database struct {
+	Name    string
+	Entries []*Entry
+}
+
+// Entry is a single KeePass entry
+type Entry struct {
+	Title    string
+	Username string
+	Password string
+	URL      string
+	Notes    string
+	// Fields holds the custom strings of the entry, including "otp"
+	Fields map[string]string
+	// Group is the slash-separated path of the entry's group below the root group
+	Group    string
+	Tags     []string
+	Created  time.Time
+	Modified time.Time
+}
+
+const (
+	signature1 uint32 = 0x9AA2D903
+	signature2 uint32 = 0xB54BFB67
+
+	versionMajor3 = 3
+	versionMajor4 = 4
+	fileVersion40 = 0x00040000
+)
+
+// Outer header field identifiers
+const (
+	headerEndOfHeader      = 0
+	headerCipherID         = 2
+	headerCompressionFlags = 3
+	headerMasterSeed       = 4
+	headerEncryptionIV     = 7
+	headerKdfParameters    = 11
+	headerPublicCustomData = 12
+
+	// KDBX 3.1 only; KDBX 4 moved these into the KDF parameters and the
+	// inner header
+	headerTransformSeed      = 5
+	headerTransformRounds    = 6
+	headerProtectedStreamKey = 8
+	headerStreamStartBytes   = 9
+	headerInnerRandomStream  = 10
+)
+
+// Inner header field identifiers
+const (
+	innerHeaderEnd          = 0
+	innerHeaderStreamID     = 1
+	innerHeaderStreamKey    = 2
+	innerHeaderBinary       = 3
+	innerStreamSalsa20      = 2
+	innerStreamChaCha20     = 3
+	compressionNone         = 0
+	compressionGzip         = 1
+	hmacBlockSize           = 1024 * 1024
+	headerHMACBlockIndex    = ^uint64(0)
+	defaultArgon2Memory     = 64 * 1024 * 1024
+	defaultArgon2Iterations = 4
+	defaultArgon2Threads    = 2
+)
+
+var (
+	cipherAES256   = mustUUID("31c1f2e6bf714350be5805216afc5aff")
+	cipherChaCha20 = mustUUID("d6038a2b8b6f4cb5a524339a31dbb59a")
+	kdfAES         = mustUUID("c9d9f39a628a4460bf740d08c18a4fea")
+	kdfArgon2d     = mustUUID("ef636ddf8c29444b91f7a9a403e30a0c")
+	kdfArgon2id    = mustUUID("9e298b1956db4773b23dfc3ec6f0a1e6")
+)
+
+// knownStringKeys are the standard entry strings that map to Entry fields
+var knownStringKeys = map[string]bool{
+	"Title":    true,
+	"UserName": true,
+	"Password": true,
+	"URL":      true,
+	"Notes":    true,
+}
+
+func mustUUID(hexString string) []byte {
+	id, err := hex.DecodeString(hexString)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
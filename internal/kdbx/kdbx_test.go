@@ -0,0 +1,233 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// The fixtures are generated by testdata/generate.py
+const fixturePassword = "correct horse battery staple"
+
+var fixtures = []struct {
+	file    string
+	keyFile string
+}{
+	{file: "kdbx4-argon2d-aes.kdbx"},
+	{file: "kdbx4-argon2id-chacha20.kdbx"},
+	{file: "kdbx31-aeskdf-keyfile.kdbx", keyFile: "kdbx31-aeskdf-keyfile.keyx"},
+}
+
+func readFixture(t *testing.T, file, password string, keyFile []byte) (*Database, error) {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return Read(f, password, keyFile)
+}
+
+func readKeyFile(t *testing.T, name string) []byte {
+	t.Helper()
+	if name == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestReadFixtures(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	modified := time.Date(2024, 6, 15, 18, 45, 10, 0, time.UTC)
+
+	want := []*Entry{
+		{
+			Title:    "GitHub",
+			Username: "alice",
+			Password: "gh-S3cret!<&>",
+			URL:      "https://github.com",
+			Notes:    "Personal account",
+			Fields: map[string]string{
+				"otp":            "otpauth://totp/GitHub:alice?secret=JBSWY3DPEHPK3PXP&issuer=GitHub",
+				"Recovery codes": "1111-2222 3333-4444",
+			},
+			Tags: []string{"dev", "personal"},
+		},
+		{
+			Title:    "GitHub",
+			Username: "alice-corp",
+			Password: "w0rk-gh-pässwörd",
+			URL:      "https://github.com/corp",
+			Fields:   map[string]string{},
+			Group:    "Work",
+			Tags:     []string{"dev", "work"},
+		},
+		{
+			Title:    "VPN",
+			Username: "alice",
+			Password: "vpn pass with spaces",
+			Notes:    "Line one\nLine two",
+			Fields:   map[string]string{"Server": "vpn.corp.example"},
+			Group:    "Work",
+		},
+		{
+			Title:    "db01",
+			Username: "postgres",
+			Password: "db01-Pw",
+			URL:      "postgres://db01:5432",
+			Fields:   map[string]string{},
+			Group:    "Work/Servers",
+		},
+	}
+	for _, entry := range want {
+		entry.Created, entry.Modified = created, modified
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(fixture.file, func(t *testing.T) {
+			db, err := readFixture(t, fixture.file, fixturePassword, readKeyFile(t, fixture.keyFile))
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if db.Name != "Fixture Vault" {
+				t.Errorf("Name = %q, want %q", db.Name, "Fixture Vault")
+			}
+			// The entry in the recycle bin is left out
+			if len(db.Entries) != len(want) {
+				t.Fatalf("got %d entries, want %d", len(db.Entries), len(want))
+			}
+			for i, entry := range db.Entries {
+				if !reflect.DeepEqual(entry, want[i]) {
+					t.Errorf("entry %d:\n got %+v\nwant %+v", i, entry, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadWrongCredentials(t *testing.T) {
+	for _, fixture := range fixtures {
+		t.Run(fixture.file, func(t *testing.T) {
+			_, err := readFixture(t, fixture.file, "wrong password", readKeyFile(t, fixture.keyFile))
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("wrong password: got %v, want ErrInvalidCredentials", err)
+			}
+		})
+	}
+
+	_, err := readFixture(t, "kdbx31-aeskdf-keyfile.kdbx", fixturePassword, nil)
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("missing key file: got %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestReadCorrupted(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "kdbx4-argon2d-aes.kdbx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-50] ^= 0xFF
+
+	if _, err := Read(bytes.NewReader(data), fixturePassword, nil); err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("got %v, want a corruption error", err)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	db := &Database{
+		Name: "Round Trip",
+		Entries: []*Entry{
+			{
+				Title:    "mail",
+				Username: "bob",
+				Password: "p@ss <word> & more",
+				URL:      "https://mail.example",
+				Notes:    "notes",
+				Fields:   map[string]string{"otp": "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP", "PIN": "1234"},
+				Group:    "Personal/Mail",
+				Tags:     []string{"mail", "personal"},
+				Created:  time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+				Modified: time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC),
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, db, "round trip"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err := Read(&buf, "round trip", nil)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !reflect.DeepEqual(got, db) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", got.Entries[0], db.Entries[0])
+	}
+}
+
+// Argon2d has no implementation to compare with in the standard library,
+// but Argon2id shares all of its code apart from the block indexing, and
+// the Argon2d fixture checks the rest.
+func TestArgon2idMatchesXCrypto(t *testing.T) {
+	password, salt := []byte("password"), []byte("somesalt12345678")
+	for _, p := range []struct{ time, memory, threads uint32 }{{1, 64, 1}, {2, 1024, 2}, {3, 256, 4}} {
+		want := argon2.IDKey(password, salt, p.time, p.memory, uint8(p.threads), 32)
+		got := argon2Key(argon2id, password, salt, p.time, p.memory, p.threads, 32)
+		if !bytes.Equal(got, want) {
+			t.Errorf("argon2id(t=%d, m=%d, p=%d) = %x, want %x", p.time, p.memory, p.threads, got, want)
+		}
+	}
+}
+
+func TestKeyFileKey(t *testing.T) {
+	raw := bytes.Repeat([]byte{0xAB}, 32)
+	other := []byte("any other file is hashed")
+	otherHash := sha256.Sum256(other)
+
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"raw 32 bytes", raw, raw},
+		{"64 hex digits", []byte(hex.EncodeToString(raw)), raw},
+		{"other", other, otherHash[:]},
+		{
+			"xml 1.0",
+			[]byte(`<?xml version="1.0" encoding="utf-8"?><KeyFile><Meta><Version>1.00</Version></Meta><Key><Data>q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s=</Data></Key></KeyFile>`),
+			raw,
+		},
+		{
+			"xml 2.0",
+			[]byte(`<KeyFile><Meta><Version>2.0</Version></Meta><Key><Data Hash="9A2DB2E2">ABABABAB ABABABAB ABABABAB ABABABAB ABABABAB ABABABAB ABABABAB ABABABAB</Data></Key></KeyFile>`),
+			raw,
+		},
+	}
+	for _, tt := range tests {
+		got, err := keyFileKey(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: got %x, want %x", tt.name, got, tt.want)
+		}
+	}
+
+	badChecksum := []byte(`<KeyFile><Meta><Version>2.0</Version></Meta><Key><Data Hash="00000000">ABABABAB ABABABAB ABABABAB ABABABAB ABABABAB ABABABAB ABABABAB ABABABAB</Data></Key></KeyFile>`)
+	if _, err := keyFileKey(badChecksum); err == nil {
+		t.Error("xml 2.0 with a wrong checksum: expected an error")
+	}
+}
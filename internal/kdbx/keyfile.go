@@ -0,0 +1,77 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// xmlKeyFile is the XML key file format of KeePass 2.x (version 1.0) and
+// KeePass 2.47+ / KeePassXC (version 2.0)
+type xmlKeyFile struct {
+	XMLName xml.Name `xml:"KeyFile"`
+	Version string   `xml:"Meta>Version"`
+	Data    struct {
+		Hash string `xml:"Hash,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"Key>Data"`
+}
+
+// keyFileKey returns the 32-byte key a key file contributes to the
+// composite key. Like KeePass, it accepts XML key files, 32 raw bytes and
+// 64 hex digits, and hashes any other file.
+func keyFileKey(data []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<KeyFile")) {
+		return xmlKeyFileKey(trimmed)
+	}
+
+	if len(data) == 32 {
+		return data, nil
+	}
+	if len(data) == 64 {
+		if key, err := hex.DecodeString(string(data)); err == nil {
+			return key, nil
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+func xmlKeyFileKey(data []byte) ([]byte, error) {
+	var file xmlKeyFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid key file: %w", err)
+	}
+
+	switch {
+	case strings.HasPrefix(file.Version, "1."):
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(file.Data.Text))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("invalid key file: malformed key data")
+		}
+		return key, nil
+
+	case strings.HasPrefix(file.Version, "2."):
+		key, err := hex.DecodeString(strings.Join(strings.Fields(file.Data.Text), ""))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("invalid key file: malformed key data")
+		}
+		// The hash attribute guards against typos in printed key files
+		if file.Data.Hash != "" {
+			sum := sha256.Sum256(key)
+			if !strings.EqualFold(file.Data.Hash, hex.EncodeToString(sum[:4])) {
+				return nil, fmt.Errorf("invalid key file: checksum mismatch")
+			}
+		}
+		return key, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key file version '%s'", file.Version)
+	}
+}
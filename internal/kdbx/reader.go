@@ -0,0 +1,353 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// Read decrypts a KDBX 3.1 or 4 database with the given password and, if
+// the database needs one, the content of its key file (nil otherwise)
+func Read(r io.Reader, password string, keyFile []byte) (*Database, error) {
+	h, rawHeader, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if h.version == versionMajor3 {
+		return readKDBX3(r, h, rawHeader, password, keyFile)
+	}
+
+	// Verify the header checksum before spending time on the KDF
+	var headerHash, headerMAC [32]byte
+	if _, err := io.ReadFull(r, headerHash[:]); err != nil {
+		return nil, fmt.Errorf("truncated header")
+	}
+	if sum := sha256.Sum256(rawHeader); !hmac.Equal(sum[:], headerHash[:]) {
+		return nil, fmt.Errorf("header checksum mismatch, file is corrupted")
+	}
+	if _, err := io.ReadFull(r, headerMAC[:]); err != nil {
+		return nil, fmt.Errorf("truncated header")
+	}
+
+	encryptionKey, hmacKey, err := h.deriveKeys(password, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(blockHMAC(hmacKey, headerHMACBlockIndex, rawHeader), headerMAC[:]) {
+		return nil, ErrInvalidCredentials
+	}
+
+	ciphertext, err := readBlocks(r, hmacKey)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := decryptPayload(h, encryptionKey, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	payload, err = decompress(h, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, document, err := readInnerHeader(payload)
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(document, stream, nil)
+}
+
+// readKDBX3 decrypts the rest of a KDBX 3.1 database, which has no header
+// HMAC: the key is checked against the stream start bytes instead, and the
+// header against the hash stored in the XML.
+func readKDBX3(r io.Reader, h *header, rawHeader []byte, password string, keyFile []byte) (*Database, error) {
+	encryptionKey, _, err := h.deriveKeys(password, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+	payload, err := decryptPayload(h, encryptionKey, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < len(h.streamStartBytes) || !hmac.Equal(payload[:len(h.streamStartBytes)], h.streamStartBytes) {
+		return nil, ErrInvalidCredentials
+	}
+
+	payload, err = readHashedBlocks(payload[len(h.streamStartBytes):])
+	if err != nil {
+		return nil, err
+	}
+	payload, err = decompress(h, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := newProtectedStream(h.streamID, h.streamKey)
+	if err != nil {
+		return nil, err
+	}
+	headerHash := sha256.Sum256(rawHeader)
+	return parseDocument(payload, stream, headerHash[:])
+}
+
+// decompress undoes the compression given in the header
+func decompress(h *header, payload []byte) ([]byte, error) {
+	switch h.compression {
+	case compressionNone:
+		return payload, nil
+	case compressionGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress database: %w", err)
+		}
+		payload, err = io.ReadAll(gz)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress database: %w", err)
+		}
+		return payload, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %d", h.compression)
+	}
+}
+
+// parseDocument decrypts the protected values of the XML document and
+// collects its entries. headerHash, if set, must match the one in the XML.
+func parseDocument(document []byte, stream protectedStream, headerHash []byte) (*Database, error) {
+	plainXML, err := transformProtected(document, stream, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var file xmlFile
+	if err := xml.Unmarshal(plainXML, &file); err != nil {
+		return nil, fmt.Errorf("invalid database XML: %w", err)
+	}
+
+	if headerHash != nil && file.Meta.HeaderHash != "" {
+		stored, err := base64.StdEncoding.DecodeString(strings.TrimSpace(file.Meta.HeaderHash))
+		if err != nil || !hmac.Equal(stored, headerHash) {
+			return nil, fmt.Errorf("header checksum mismatch, file is corrupted")
+		}
+	}
+
+	db := &Database{Name: file.Meta.DatabaseName}
+	collectEntries(db, &file.Root.Group, "", recycleBin(&file.Meta))
+	return db, nil
+}
+
+// recycleBin returns the UUID of the group holding deleted entries, or ""
+// when the database has none
+func recycleBin(meta *xmlMeta) string {
+	id, err := base64.StdEncoding.DecodeString(strings.TrimSpace(meta.RecycleBinUUID))
+	if err != nil || len(id) == 0 || bytes.Equal(id, make([]byte, len(id))) {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(id)
+}
+
+// readBlocks reads and authenticates the HMAC block stream
+func readBlocks(r io.Reader, hmacKey []byte) ([]byte, error) {
+	var ciphertext bytes.Buffer
+	for index := uint64(0); ; index++ {
+		var mac [32]byte
+		var size uint32
+		if _, err := io.ReadFull(r, mac[:]); err != nil {
+			return nil, fmt.Errorf("truncated database")
+		}
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return nil, fmt.Errorf("truncated database")
+		}
+		if size > 64*1024*1024 {
+			return nil, fmt.Errorf("invalid block size")
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("truncated database")
+		}
+		if !hmac.Equal(blockHMAC(hmacKey, index, data), mac[:]) {
+			return nil, fmt.Errorf("block %d failed authentication, file is corrupted", index)
+		}
+		if size == 0 {
+			return ciphertext.Bytes(), nil
+		}
+		ciphertext.Write(data)
+	}
+}
+
+// readHashedBlocks reads the SHA-256 hashed block stream of KDBX 3.1
+func readHashedBlocks(data []byte) ([]byte, error) {
+	r := bytes.NewReader(data)
+	var plaintext bytes.Buffer
+	for {
+		var block struct {
+			Index uint32
+			Hash  [32]byte
+			Size  int32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &block); err != nil {
+			return nil, fmt.Errorf("truncated database")
+		}
+		if block.Size < 0 || int64(block.Size) > int64(r.Len()) {
+			return nil, fmt.Errorf("invalid block size")
+		}
+		if block.Size == 0 {
+			if block.Hash != [32]byte{} {
+				return nil, fmt.Errorf("block %d failed verification, file is corrupted", block.Index)
+			}
+			return plaintext.Bytes(), nil
+		}
+
+		buf := make([]byte, block.Size)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("truncated database")
+		}
+		if sha256.Sum256(buf) != block.Hash {
+			return nil, fmt.Errorf("block %d failed verification, file is corrupted", block.Index)
+		}
+		plaintext.Write(buf)
+	}
+}
+
+func decryptPayload(h *header, key, ciphertext []byte) ([]byte, error) {
+	switch {
+	case bytes.Equal(h.cipherID, cipherAES256):
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(h.encryptionIV) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+			return nil, fmt.Errorf("invalid AES payload")
+		}
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, h.encryptionIV).CryptBlocks(plaintext, ciphertext)
+
+		padding := int(plaintext[len(plaintext)-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, ErrInvalidCredentials
+		}
+		return plaintext[:len(plaintext)-padding], nil
+
+	case bytes.Equal(h.cipherID, cipherChaCha20):
+		stream, err := chacha20.NewUnauthenticatedCipher(key, h.encryptionIV)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ChaCha20 payload: %w", err)
+		}
+		plaintext := make([]byte, len(ciphertext))
+		stream.XORKeyStream(plaintext, ciphertext)
+		return plaintext, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported payload cipher (only AES-256 and ChaCha20 are supported)")
+	}
+}
+
+// readInnerHeader parses the inner header and returns the protected stream and the XML document
+func readInnerHeader(payload []byte) (protectedStream, []byte, error) {
+	r := bytes.NewReader(payload)
+	var streamID uint32
+	var streamKey []byte
+	for {
+		var id uint8
+		var size int32
+		if err := binary.Read(r, binary.LittleEndian, &id); err != nil {
+			return nil, nil, fmt.Errorf("truncated inner header")
+		}
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil || size < 0 {
+			return nil, nil, fmt.Errorf("truncated inner header")
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, nil, fmt.Errorf("truncated inner header")
+		}
+
+		switch id {
+		case innerHeaderEnd:
+			stream, err := newProtectedStream(streamID, streamKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			return stream, payload[len(payload)-r.Len():], nil
+		case innerHeaderStreamID:
+			if len(data) != 4 {
+				return nil, nil, fmt.Errorf("invalid inner stream id")
+			}
+			streamID = binary.LittleEndian.Uint32(data)
+		case innerHeaderStreamKey:
+			streamKey = data
+		case innerHeaderBinary:
+			// Attachments are not imported
+		}
+	}
+}
+
+// collectEntries flattens a group tree into db.Entries. The recycle bin
+// and everything below it holds deleted entries and is left out.
+func collectEntries(db *Database, group *xmlGroup, path, recycleBin string) {
+	for i := range group.Entries {
+		db.Entries = append(db.Entries, convertEntry(&group.Entries[i], path))
+	}
+	for i := range group.Groups {
+		child := &group.Groups[i]
+		if recycleBin != "" && strings.TrimSpace(child.UUID) == recycleBin {
+			continue
+		}
+		childPath := child.Name
+		if path != "" {
+			childPath = path + "/" + child.Name
+		}
+		collectEntries(db, child, childPath, recycleBin)
+	}
+}
+
+func convertEntry(e *xmlEntry, group string) *Entry {
+	entry := &Entry{
+		Group:    group,
+		Fields:   make(map[string]string),
+		Created:  decodeTime(e.Times.CreationTime),
+		Modified: decodeTime(e.Times.LastModificationTime),
+	}
+
+	for _, tag := range strings.FieldsFunc(e.Tags, func(r rune) bool { return r == ';' || r == ',' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			entry.Tags = append(entry.Tags, tag)
+		}
+	}
+
+	for _, s := range e.Strings {
+		switch s.Key {
+		case "Title":
+			entry.Title = s.Value.Text
+		case "UserName":
+			entry.Username = s.Value.Text
+		case "Password":
+			entry.Password = s.Value.Text
+		case "URL":
+			entry.URL = s.Value.Text
+		case "Notes":
+			entry.Notes = s.Value.Text
+		default:
+			if s.Value.Text != "" {
+				entry.Fields[s.Key] = s.Value.Text
+			}
+		}
+	}
+
+	return entry
+}
@@ -0,0 +1,320 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Write encrypts the database as KDBX 4 using Argon2d, AES-256 and a
+// ChaCha20 inner stream, the defaults KeePass and KeePassXC expect
+func Write(w io.Writer, db *Database, password string) error {
+	salt, err := randomBytes(32)
+	if err != nil {
+		return err
+	}
+	kdf := newVariantDictionary()
+	kdf.set("$UUID", variantByteArray, kdfArgon2d)
+	kdf.setUint64("I", defaultArgon2Iterations)
+	kdf.setUint64("M", defaultArgon2Memory)
+	kdf.setUint32("P", defaultArgon2Threads)
+	kdf.set("S", variantByteArray, salt)
+	kdf.setUint32("V", argon2Version)
+
+	masterSeed, err := randomBytes(32)
+	if err != nil {
+		return err
+	}
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return err
+	}
+	h := &header{
+		cipherID:     cipherAES256,
+		compression:  compressionGzip,
+		masterSeed:   masterSeed,
+		encryptionIV: iv,
+		kdf:          kdf,
+	}
+
+	encryptionKey, hmacKey, err := h.deriveKeys(password, nil)
+	if err != nil {
+		return err
+	}
+
+	payload, err := buildPayload(db)
+	if err != nil {
+		return err
+	}
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(payload); err != nil {
+		return fmt.Errorf("failed to compress database: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress database: %w", err)
+	}
+
+	ciphertext, err := encryptPayload(encryptionKey, iv, compressed.Bytes())
+	if err != nil {
+		return err
+	}
+
+	rawHeader := h.marshal()
+	headerHash := sha256.Sum256(rawHeader)
+
+	var out bytes.Buffer
+	out.Write(rawHeader)
+	out.Write(headerHash[:])
+	out.Write(blockHMAC(hmacKey, headerHMACBlockIndex, rawHeader))
+	writeBlocks(&out, hmacKey, ciphertext)
+
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+func randomBytes(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return nil, fmt.Errorf("failed to generate random data: %w", err)
+	}
+	return buf, nil
+}
+
+func encryptPayload(key, iv, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := make([]byte, len(plaintext), len(plaintext)+padding)
+	copy(padded, plaintext)
+	padded = append(padded, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+	return ciphertext, nil
+}
+
+// writeBlocks splits the ciphertext into HMAC-authenticated blocks
+func writeBlocks(w *bytes.Buffer, hmacKey, ciphertext []byte) {
+	index := uint64(0)
+	for {
+		size := len(ciphertext)
+		if size > hmacBlockSize {
+			size = hmacBlockSize
+		}
+		data := ciphertext[:size]
+		ciphertext = ciphertext[size:]
+
+		w.Write(blockHMAC(hmacKey, index, data))
+		binary.Write(w, binary.LittleEndian, uint32(size))
+		w.Write(data)
+		index++
+
+		if size == 0 {
+			return
+		}
+	}
+}
+
+// buildPayload produces the inner header followed by the XML document
+func buildPayload(db *Database) ([]byte, error) {
+	streamKey, err := randomBytes(64)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := newProtectedStream(innerStreamChaCha20, streamKey)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := buildXML(db)
+	if err != nil {
+		return nil, err
+	}
+	protectedXML, err := transformProtected(document, stream, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeInner := func(id uint8, data []byte) {
+		buf.WriteByte(id)
+		binary.Write(&buf, binary.LittleEndian, int32(len(data)))
+		buf.Write(data)
+	}
+	streamID := make([]byte, 4)
+	binary.LittleEndian.PutUint32(streamID, innerStreamChaCha20)
+	writeInner(innerHeaderStreamID, streamID)
+	writeInner(innerHeaderStreamKey, streamKey)
+	writeInner(innerHeaderEnd, nil)
+
+	buf.WriteString(xml.Header)
+	buf.Write(protectedXML)
+	return buf.Bytes(), nil
+}
+
+// groupNode is a mutable group tree used while assembling the XML document
+type groupNode struct {
+	name     string
+	entries  []xmlEntry
+	children []*groupNode
+}
+
+func (n *groupNode) child(name string) *groupNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &groupNode{name: name}
+	n.children = append(n.children, c)
+	return c
+}
+
+func (n *groupNode) toXML() (xmlGroup, error) {
+	group, err := newXMLGroup(n.name)
+	if err != nil {
+		return xmlGroup{}, err
+	}
+	group.Entries = n.entries
+	for _, c := range n.children {
+		child, err := c.toXML()
+		if err != nil {
+			return xmlGroup{}, err
+		}
+		group.Groups = append(group.Groups, child)
+	}
+	return *group, nil
+}
+
+func buildXML(db *Database) ([]byte, error) {
+	name := db.Name
+	if name == "" {
+		name = "Remembrall"
+	}
+
+	root := &groupNode{name: name}
+	for _, entry := range db.Entries {
+		node := root
+		for _, part := range strings.Split(strings.Trim(entry.Group, "/"), "/") {
+			if part != "" {
+				node = node.child(part)
+			}
+		}
+		xe, err := newXMLEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		node.entries = append(node.entries, *xe)
+	}
+
+	rootGroup, err := root.toXML()
+	if err != nil {
+		return nil, err
+	}
+
+	file := xmlFile{
+		Meta: xmlMeta{
+			Generator:    "Remembrall",
+			DatabaseName: name,
+			MemoryProtection: xmlMemoryProtection{
+				ProtectTitle:    "False",
+				ProtectUserName: "False",
+				ProtectPassword: "True",
+				ProtectURL:      "False",
+				ProtectNotes:    "False",
+			},
+		},
+		Root: xmlRoot{Group: rootGroup},
+	}
+
+	return xml.Marshal(file)
+}
+
+func newUUID() (string, error) {
+	id, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(id), nil
+}
+
+func newXMLTimes(created, modified time.Time) xmlTimes {
+	if created.IsZero() {
+		created = time.Now()
+	}
+	if modified.IsZero() {
+		modified = created
+	}
+	return xmlTimes{
+		CreationTime:         encodeTime(created),
+		LastModificationTime: encodeTime(modified),
+		LastAccessTime:       encodeTime(modified),
+		ExpiryTime:           encodeTime(created),
+		Expires:              "False",
+		LocationChanged:      encodeTime(modified),
+	}
+}
+
+func newXMLGroup(name string) (*xmlGroup, error) {
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	return &xmlGroup{UUID: id, Name: name, Times: newXMLTimes(time.Time{}, time.Time{})}, nil
+}
+
+func newXMLEntry(entry *Entry) (*xmlEntry, error) {
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	xe := &xmlEntry{
+		UUID:  id,
+		Tags:  strings.Join(entry.Tags, ";"),
+		Times: newXMLTimes(entry.Created, entry.Modified),
+	}
+
+	add := func(key, value string, protected bool) {
+		s := xmlString{Key: key, Value: xmlValue{Text: value}}
+		if protected {
+			s.Value.Protected = "True"
+		}
+		xe.Strings = append(xe.Strings, s)
+	}
+
+	add("Title", entry.Title, false)
+	add("UserName", entry.Username, false)
+	add("Password", entry.Password, true)
+	add("URL", entry.URL, false)
+	add("Notes", entry.Notes, false)
+
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		if !knownStringKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add(key, entry.Fields[key], key == "otp")
+	}
+
+	return xe, nil
+}
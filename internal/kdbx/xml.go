@@ -0,0 +1,228 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20/salsa"
+)
+
+type xmlFile struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    xmlMeta  `xml:"Meta"`
+	Root    xmlRoot  `xml:"Root"`
+}
+
+type xmlMeta struct {
+	Generator        string              `xml:"Generator"`
+	HeaderHash       string              `xml:"HeaderHash,omitempty"`
+	DatabaseName     string              `xml:"DatabaseName"`
+	MemoryProtection xmlMemoryProtection `xml:"MemoryProtection"`
+	RecycleBinUUID   string              `xml:"RecycleBinUUID,omitempty"`
+}
+
+type xmlMemoryProtection struct {
+	ProtectTitle    string `xml:"ProtectTitle"`
+	ProtectUserName string `xml:"ProtectUserName"`
+	ProtectPassword string `xml:"ProtectPassword"`
+	ProtectURL      string `xml:"ProtectURL"`
+	ProtectNotes    string `xml:"ProtectNotes"`
+}
+
+type xmlRoot struct {
+	Group xmlGroup `xml:"Group"`
+}
+
+type xmlGroup struct {
+	UUID    string     `xml:"UUID"`
+	Name    string     `xml:"Name"`
+	Times   xmlTimes   `xml:"Times"`
+	Entries []xmlEntry `xml:"Entry"`
+	Groups  []xmlGroup `xml:"Group"`
+}
+
+type xmlEntry struct {
+	UUID    string      `xml:"UUID"`
+	Tags    string      `xml:"Tags"`
+	Times   xmlTimes    `xml:"Times"`
+	Strings []xmlString `xml:"String"`
+}
+
+type xmlString struct {
+	Key   string   `xml:"Key"`
+	Value xmlValue `xml:"Value"`
+}
+
+type xmlValue struct {
+	Protected string `xml:"Protected,attr,omitempty"`
+	Text      string `xml:",chardata"`
+}
+
+type xmlTimes struct {
+	CreationTime         string `xml:"CreationTime"`
+	LastModificationTime string `xml:"LastModificationTime"`
+	LastAccessTime       string `xml:"LastAccessTime"`
+	ExpiryTime           string `xml:"ExpiryTime"`
+	Expires              string `xml:"Expires"`
+	UsageCount           int    `xml:"UsageCount"`
+	LocationChanged      string `xml:"LocationChanged"`
+}
+
+// unixEpochOffset is the number of seconds between 0001-01-01, the zero
+// point of KDBX 4 timestamps, and the Unix epoch
+const unixEpochOffset = 62135596800
+
+// encodeTime encodes a timestamp as base64 seconds since year 1, as KDBX 4 does
+func encodeTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(t.Unix()+unixEpochOffset))
+	return base64.StdEncoding.EncodeToString(buf[:])
+}
+
+// decodeTime decodes a KDBX 4 timestamp, falling back to the KDBX 3 ISO format
+func decodeTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	raw, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(raw) != 8 {
+		return time.Time{}
+	}
+	seconds := int64(binary.LittleEndian.Uint64(raw))
+	return time.Unix(seconds-unixEpochOffset, 0).UTC()
+}
+
+// protectedStream is the keystream used for protected values in the XML
+type protectedStream interface {
+	XORKeyStream(dst, src []byte)
+}
+
+func newProtectedStream(id uint32, key []byte) (protectedStream, error) {
+	switch id {
+	case innerStreamChaCha20:
+		hash := sha512.Sum512(key)
+		return chacha20.NewUnauthenticatedCipher(hash[:32], hash[32:44])
+	case innerStreamSalsa20:
+		return newSalsa20Stream(key), nil
+	default:
+		return nil, fmt.Errorf("unsupported inner stream cipher %d", id)
+	}
+}
+
+// salsa20Stream is a seekless Salsa20 keystream with the fixed KeePass nonce
+type salsa20Stream struct {
+	key     [32]byte
+	counter [16]byte
+	block   [64]byte
+	used    int
+}
+
+func newSalsa20Stream(key []byte) *salsa20Stream {
+	s := &salsa20Stream{key: sha256.Sum256(key), used: 64}
+	copy(s.counter[:8], []byte{0xE8, 0x30, 0x09, 0x4B, 0x97, 0x20, 0x5D, 0x2A})
+	return s
+}
+
+func (s *salsa20Stream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.used == 64 {
+			var zero [64]byte
+			salsa.XORKeyStream(s.block[:], zero[:], &s.counter, &s.key)
+			binary.LittleEndian.PutUint64(s.counter[8:], binary.LittleEndian.Uint64(s.counter[8:])+1)
+			s.used = 0
+		}
+		dst[i] = src[i] ^ s.block[s.used]
+		s.used++
+	}
+}
+
+// transformProtected rewrites the XML document, decrypting (or encrypting)
+// every value marked Protected="True" with the inner stream. Protected values
+// share one keystream and must be processed in document order.
+func transformProtected(data []byte, stream protectedStream, protect bool) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var out bytes.Buffer
+	encoder := xml.NewEncoder(&out)
+
+	inProtected := false
+	var text []byte
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid database XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "Protected" && strings.EqualFold(attr.Value, "True") {
+					inProtected = true
+					text = text[:0]
+				}
+			}
+		case xml.CharData:
+			if inProtected {
+				text = append(text, t...)
+				continue
+			}
+		case xml.EndElement:
+			if inProtected {
+				value, err := applyStream(string(text), stream, protect)
+				if err != nil {
+					return nil, err
+				}
+				if err := encoder.EncodeToken(xml.CharData(value)); err != nil {
+					return nil, err
+				}
+				inProtected = false
+			}
+		case xml.ProcInst:
+			if t.Target == "xml" {
+				continue
+			}
+		}
+
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func applyStream(value string, stream protectedStream, protect bool) (string, error) {
+	if protect {
+		buf := []byte(value)
+		stream.XORKeyStream(buf, buf)
+		return base64.StdEncoding.EncodeToString(buf), nil
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf("invalid protected value: %w", err)
+	}
+	stream.XORKeyStream(buf, buf)
+	return string(buf), nil
+}
@@ -0,0 +1,92 @@
+package nativemsg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// HostName is the name extensions pass to connectNative
+const HostName = "remembrall"
+
+// chromeExtensionID matches the IDs of Chromium-based extensions
+var chromeExtensionID = regexp.MustCompile(`^[a-p]{32}$`)
+
+// Browser describes where a browser looks for native messaging manifests
+type Browser struct {
+	Name      string
+	ConfigDir string // relative to the home directory, used to detect the browser
+	HostsDir  string // relative to the home directory
+	Firefox   bool   // Firefox manifests list extension IDs rather than origins
+}
+
+// Browsers lists the supported browsers on Linux
+var Browsers = []Browser{
+	{Name: "chrome", ConfigDir: ".config/google-chrome", HostsDir: ".config/google-chrome/NativeMessagingHosts"},
+	{Name: "chromium", ConfigDir: ".config/chromium", HostsDir: ".config/chromium/NativeMessagingHosts"},
+	{Name: "brave", ConfigDir: ".config/BraveSoftware/Brave-Browser", HostsDir: ".config/BraveSoftware/Brave-Browser/NativeMessagingHosts"},
+	{Name: "firefox", ConfigDir: ".mozilla", HostsDir: ".mozilla/native-messaging-hosts", Firefox: true},
+}
+
+// Manifest is the JSON file that registers a native messaging host
+type Manifest struct {
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	Path              string   `json:"path"`
+	Type              string   `json:"type"`
+	AllowedOrigins    []string `json:"allowed_origins,omitempty"`
+	AllowedExtensions []string `json:"allowed_extensions,omitempty"`
+}
+
+// IsChromeExtensionID reports whether id looks like a Chromium extension ID
+// rather than a Firefox add-on ID
+func IsChromeExtensionID(id string) bool {
+	return chromeExtensionID.MatchString(id)
+}
+
+// ManifestFor builds the manifest for a browser, keeping only the extension
+// IDs that browser understands. It returns nil if none are left.
+func ManifestFor(browser Browser, hostPath string, extensionIDs []string) *Manifest {
+	manifest := &Manifest{
+		Name:        HostName,
+		Description: "Remembrall password manager",
+		Path:        hostPath,
+		Type:        "stdio",
+	}
+
+	for _, id := range extensionIDs {
+		switch {
+		case browser.Firefox && !IsChromeExtensionID(id):
+			manifest.AllowedExtensions = append(manifest.AllowedExtensions, id)
+		case !browser.Firefox && IsChromeExtensionID(id):
+			manifest.AllowedOrigins = append(manifest.AllowedOrigins, "chrome-extension://"+id+"/")
+		}
+	}
+
+	if len(manifest.AllowedExtensions) == 0 && len(manifest.AllowedOrigins) == 0 {
+		return nil
+	}
+	return manifest
+}
+
+// WriteManifest writes the manifest into the browser's hosts directory and
+// returns its path
+func WriteManifest(homeDir string, browser Browser, manifest *Manifest) (string, error) {
+	dir := filepath.Join(homeDir, browser.HostsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create '%s': %w", dir, err)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	path := filepath.Join(dir, HostName+".json")
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	return path, nil
+}
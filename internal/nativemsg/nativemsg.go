@@ -0,0 +1,52 @@
+// Package nativemsg implements the framing browsers use to talk to native
+// messaging hosts: each message is a 32-bit length in native byte order
+// followed by that many bytes of UTF-8 JSON.
+package nativemsg
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// MaxMessageSize is the largest message a host may send to the browser
+const MaxMessageSize = 1 << 20
+
+// Read reads one message into v. It returns io.EOF when the browser has
+// closed the connection.
+func Read(r io.Reader, v interface{}) error {
+	var length uint32
+	if err := binary.Read(r, binary.NativeEndian, &length); err != nil {
+		return err
+	}
+	if length > MaxMessageSize {
+		return fmt.Errorf("message of %d bytes is too large", length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return fmt.Errorf("failed to read message: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+	return nil
+}
+
+// Write sends v as one message
+func Write(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if len(data) > MaxMessageSize {
+		return fmt.Errorf("message of %d bytes is too large", len(data))
+	}
+
+	if err := binary.Write(w, binary.NativeEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,191 @@
+package otp
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MigrationScheme is the URI scheme of Google Authenticator exports
+const MigrationScheme = "otpauth-migration"
+
+// ParseMigration reads the keys of a Google Authenticator export, e.g.
+//
+//	otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU...
+//
+// The data parameter is a base64 protobuf MigrationPayload. Large exports
+// are split over several QR codes, each of which parses on its own.
+func ParseMigration(uri string) ([]*Key, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || u.Scheme != MigrationScheme {
+		return nil, fmt.Errorf("invalid %s URI", MigrationScheme)
+	}
+
+	data := u.Query().Get("data")
+	if data == "" {
+		return nil, fmt.Errorf("%s URI is missing the data parameter", MigrationScheme)
+	}
+	// Padding is sometimes dropped and '+' may arrive decoded as a space
+	data = strings.TrimRight(strings.ReplaceAll(data, " ", "+"), "=")
+	payload, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s data is not valid base64", MigrationScheme)
+	}
+
+	// MigrationPayload: repeated OtpParameters otp_parameters = 1
+	var keys []*Key
+	err = readMessage(payload, func(field int, value []byte, _ uint64) error {
+		if field != 1 || value == nil {
+			return nil
+		}
+		key, err := parseMigrationKey(value)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s data: %w", MigrationScheme, err)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s data has no keys", MigrationScheme)
+	}
+	return keys, nil
+}
+
+// parseMigrationKey reads an OtpParameters message
+func parseMigrationKey(message []byte) (*Key, error) {
+	key := &Key{Type: TypeTOTP, Algorithm: DefaultAlgorithm, Digits: DefaultDigits, Period: DefaultPeriod}
+	err := readMessage(message, func(field int, value []byte, number uint64) error {
+		switch field {
+		case 1:
+			key.Secret = value
+		case 2:
+			key.Account = string(value)
+		case 3:
+			key.Issuer = string(value)
+		case 4:
+			switch number {
+			case 0, 1:
+				key.Algorithm = "SHA1"
+			case 2:
+				key.Algorithm = "SHA256"
+			case 3:
+				key.Algorithm = "SHA512"
+			default:
+				return fmt.Errorf("unsupported algorithm %d", number)
+			}
+		case 5:
+			switch number {
+			case 0, 1:
+				key.Digits = 6
+			case 2:
+				key.Digits = 8
+			default:
+				return fmt.Errorf("unsupported digit count %d", number)
+			}
+		case 6:
+			switch number {
+			case 0, 2:
+				key.Type = TypeTOTP
+			case 1:
+				key.Type = TypeHOTP
+			default:
+				return fmt.Errorf("unsupported OTP type %d", number)
+			}
+		case 7:
+			key.Counter = number
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(key.Secret) == 0 {
+		return nil, fmt.Errorf("key '%s' has no secret", key.Account)
+	}
+
+	// Names are often "issuer:account", as in otpauth:// labels
+	if issuer, account, ok := strings.Cut(key.Account, ":"); ok {
+		if key.Issuer == "" {
+			key.Issuer = strings.TrimSpace(issuer)
+		}
+		key.Account = strings.TrimSpace(account)
+	}
+	return key, nil
+}
+
+// readMessage walks the fields of a protobuf message. Length-delimited
+// fields are passed as value, varint and fixed-width fields as number.
+func readMessage(message []byte, visit func(field int, value []byte, number uint64) error) error {
+	for len(message) > 0 {
+		tag, n := binary.Uvarint(message)
+		if n <= 0 {
+			return fmt.Errorf("malformed field tag")
+		}
+		message = message[n:]
+		field := int(tag >> 3)
+
+		var value []byte
+		var number uint64
+		switch tag & 7 {
+		case 0: // varint
+			number, n = binary.Uvarint(message)
+			if n <= 0 {
+				return fmt.Errorf("malformed varint in field %d", field)
+			}
+			message = message[n:]
+		case 1: // 64-bit
+			if len(message) < 8 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			number, message = binary.LittleEndian.Uint64(message), message[8:]
+		case 2: // length-delimited
+			length, n := binary.Uvarint(message)
+			if n <= 0 || length > uint64(len(message)-n) {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			value, message = message[n:n+int(length)], message[n+int(length):]
+		case 5: // 32-bit
+			if len(message) < 4 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			number, message = uint64(binary.LittleEndian.Uint32(message)), message[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d in field %d", tag&7, field)
+		}
+
+		if err := visit(field, value, number); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// URI returns the otpauth:// URI of a key, the form stored in entries
+func (k *Key) URI() string {
+	label := url.PathEscape(k.Account)
+	if k.Issuer != "" {
+		label = url.PathEscape(k.Issuer) + ":" + label
+	}
+
+	query := url.Values{}
+	query.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(k.Secret))
+	if k.Issuer != "" {
+		query.Set("issuer", k.Issuer)
+	}
+	query.Set("algorithm", k.Algorithm)
+	query.Set("digits", strconv.Itoa(k.Digits))
+	if k.Type == TypeHOTP {
+		query.Set("counter", strconv.FormatUint(k.Counter, 10))
+	} else {
+		query.Set("period", strconv.Itoa(k.Period))
+	}
+
+	return "otpauth://" + k.Type + "/" + label + "?" + query.Encode()
+}
@@ -0,0 +1,170 @@
+package otp
+
+import (
+	"bytes"
+	"encoding/base32"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Seeds from RFC 6238 Appendix B; the SHA1 one is also the RFC 4226 secret
+var (
+	seedSHA1   = []byte("12345678901234567890")
+	seedSHA256 = []byte("12345678901234567890123456789012")
+	seedSHA512 = []byte("1234567890123456789012345678901234567890123456789012345678901234")
+)
+
+func TestHOTPRFC4226(t *testing.T) {
+	// RFC 4226 Appendix D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	key := &Key{Type: TypeHOTP, Secret: seedSHA1, Algorithm: "SHA1", Digits: 6}
+	for counter, code := range want {
+		if got := key.HOTP(uint64(counter)); got != code {
+			t.Errorf("HOTP(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPRFC6238(t *testing.T) {
+	// RFC 6238 Appendix B
+	tests := []struct {
+		time                 int64
+		sha1, sha256, sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{1111111111, "14050471", "67062674", "99943326"},
+		{1234567890, "89005924", "91819424", "93441116"},
+		{2000000000, "69279037", "90698825", "38618901"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	}
+
+	for _, tt := range tests {
+		for _, c := range []struct {
+			algorithm string
+			seed      []byte
+			want      string
+		}{
+			{"SHA1", seedSHA1, tt.sha1},
+			{"SHA256", seedSHA256, tt.sha256},
+			{"SHA512", seedSHA512, tt.sha512},
+		} {
+			// Fewer digits keep the low-order ones of the 8-digit code
+			for digits := 6; digits <= 8; digits++ {
+				key := &Key{Type: TypeTOTP, Secret: c.seed, Algorithm: c.algorithm, Digits: digits, Period: DefaultPeriod}
+				got, _ := key.TOTP(time.Unix(tt.time, 0))
+				if want := c.want[8-digits:]; got != want {
+					t.Errorf("%s TOTP(%d) with %d digits = %s, want %s", c.algorithm, tt.time, digits, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestTOTPRemaining(t *testing.T) {
+	key := &Key{Type: TypeTOTP, Secret: seedSHA1, Algorithm: "SHA1", Digits: 6, Period: DefaultPeriod}
+	if _, remaining := key.TOTP(time.Unix(59, 0)); remaining != time.Second {
+		t.Errorf("remaining at 59s = %v, want 1s", remaining)
+	}
+	if _, remaining := key.TOTP(time.Unix(60, 0)); remaining != 30*time.Second {
+		t.Errorf("remaining at 60s = %v, want 30s", remaining)
+	}
+}
+
+func TestParse(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(seedSHA256)
+
+	key, err := Parse(fmt.Sprintf("otpauth://totp/ACME:alice@example.com?secret=%s&issuer=ACME&algorithm=sha256&digits=8&period=60", secret))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := &Key{Type: TypeTOTP, Issuer: "ACME", Account: "alice@example.com", Secret: seedSHA256, Algorithm: "SHA256", Digits: 8, Period: 60}
+	if !keysEqual(key, want) {
+		t.Errorf("got %+v, want %+v", key, want)
+	}
+
+	// The URI round-trips
+	again, err := Parse(key.URI())
+	if err != nil {
+		t.Fatalf("Parse(URI()): %v", err)
+	}
+	if !keysEqual(again, key) {
+		t.Errorf("round trip: got %+v, want %+v", again, key)
+	}
+
+	// A bare secret, spaced and in lower case, is a default TOTP key
+	key, err = Parse("jbsw y3dp ehpk 3pxp")
+	if err != nil {
+		t.Fatalf("Parse(bare secret): %v", err)
+	}
+	if !bytes.Equal(key.Secret, []byte("Hello!\xde\xad\xbe\xef")) || key.Type != TypeTOTP || key.Digits != DefaultDigits {
+		t.Errorf("bare secret: got %+v", key)
+	}
+
+	hotp, err := Parse("otpauth://hotp/bob?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=5")
+	if err != nil {
+		t.Fatalf("Parse(hotp): %v", err)
+	}
+	if hotp.Counter != 5 || hotp.HOTP(hotp.Counter) != "254676" {
+		t.Errorf("hotp: got counter %d, code %s", hotp.Counter, hotp.HOTP(hotp.Counter))
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, uri := range []string{
+		"otpauth://totp/x?secret=",
+		"otpauth://totp/x?secret=not-base32!",
+		"otpauth://totp/x?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+		"otpauth://totp/x?secret=JBSWY3DPEHPK3PXP&digits=5",
+		"otpauth://totp/x?secret=JBSWY3DPEHPK3PXP&period=0",
+		"otpauth://hotp/x?secret=JBSWY3DPEHPK3PXP",
+		"otpauth://yubi/x?secret=JBSWY3DPEHPK3PXP",
+	} {
+		if _, err := Parse(uri); err == nil {
+			t.Errorf("Parse(%q): expected an error", uri)
+		}
+	}
+}
+
+func TestParseMigration(t *testing.T) {
+	// A single TOTP key with the defaults, as Google Authenticator exports it
+	keys, err := ParseMigration("otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC")
+	if err != nil {
+		t.Fatalf("ParseMigration: %v", err)
+	}
+	want := &Key{Type: TypeTOTP, Issuer: "Example", Account: "alice@google.com", Secret: []byte("Hello!\xde\xad\xbe\xef"), Algorithm: "SHA1", Digits: 6, Period: DefaultPeriod}
+	if len(keys) != 1 || !keysEqual(keys[0], want) {
+		t.Fatalf("got %+v, want [%+v]", keys, want)
+	}
+
+	// An HOTP key with SHA256, 8 digits and counter 42, followed by the
+	// payload's version and batch fields
+	keys, err = ParseMigration("otpauth-migration://offline?data=CikKFDEyMzQ1Njc4OTAxMjM0NTY3ODkwEgNib2IaBEFDTUUgAigCMAE4KhABGAEgACiVmu86")
+	if err != nil {
+		t.Fatalf("ParseMigration: %v", err)
+	}
+	want = &Key{Type: TypeHOTP, Issuer: "ACME", Account: "bob", Secret: seedSHA1, Algorithm: "SHA256", Digits: 8, Period: DefaultPeriod, Counter: 42}
+	if len(keys) != 1 || !keysEqual(keys[0], want) {
+		t.Fatalf("got %+v, want [%+v]", keys, want)
+	}
+
+	for _, uri := range []string{
+		"otpauth://totp/x?secret=JBSWY3DPEHPK3PXP",
+		"otpauth-migration://offline",
+		"otpauth-migration://offline?data=%%%",
+		"otpauth-migration://offline?data=CjEKCkhlbGxv", // truncated
+		"otpauth-migration://offline?data=EAE",          // no keys
+	} {
+		if _, err := ParseMigration(uri); err == nil {
+			t.Errorf("ParseMigration(%q): expected an error", uri)
+		}
+	}
+}
+
+func keysEqual(a, b *Key) bool {
+	return a.Type == b.Type && a.Issuer == b.Issuer && a.Account == b.Account &&
+		bytes.Equal(a.Secret, b.Secret) && a.Algorithm == b.Algorithm &&
+		a.Digits == b.Digits && a.Period == b.Period && a.Counter == b.Counter
+}
@@ -0,0 +1,205 @@
+package passstore
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// Cipher encrypts and decrypts individual password files
+type Cipher interface {
+	Decrypt(ciphertext io.Reader) ([]byte, error)
+	Encrypt(w io.Writer, plaintext []byte) error
+	// RecipientsFile returns the name and content lines of the file that
+	// records the store's keys (.gpg-id for pass, .age-recipients for passage)
+	RecipientsFile() (string, []string)
+}
+
+// PassphraseFunc is called to unlock a passphrase-protected private key
+type PassphraseFunc func(keyDescription string) (string, error)
+
+// LoadKeys reads a key file and returns the matching cipher. The file may
+// contain an OpenPGP key ring (armored or binary; secret keys to import,
+// public keys to export) or age identities / recipients, one per line.
+func LoadKeys(path string, passphrase PassphraseFunc) (Cipher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	text := strings.TrimSpace(string(data))
+	switch {
+	case strings.Contains(text, "AGE-SECRET-KEY-"):
+		identities, err := age.ParseIdentities(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity file: %w", err)
+		}
+		var recipients []age.Recipient
+		for _, identity := range identities {
+			if x, ok := identity.(*age.X25519Identity); ok {
+				recipients = append(recipients, x.Recipient())
+			}
+		}
+		return &ageCipher{identities: identities, recipients: recipients}, nil
+
+	case strings.HasPrefix(text, "age1"):
+		recipients, err := age.ParseRecipients(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipients file: %w", err)
+		}
+		return &ageCipher{recipients: recipients}, nil
+
+	default:
+		var keyring openpgp.EntityList
+		if strings.HasPrefix(text, "-----BEGIN PGP") {
+			keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+		} else {
+			keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid OpenPGP key file: %w", err)
+		}
+		if err := unlockKeyring(keyring, passphrase); err != nil {
+			return nil, err
+		}
+		return &openpgpCipher{keyring: keyring}, nil
+	}
+}
+
+// unlockKeyring decrypts any passphrase-protected private keys in the key ring
+func unlockKeyring(keyring openpgp.EntityList, passphrase PassphraseFunc) error {
+	for _, entity := range keyring {
+		var description string
+		for name := range entity.Identities {
+			description = name
+			break
+		}
+		if description == "" {
+			description = entity.PrimaryKey.KeyIdString()
+		}
+
+		var cached string
+		unlock := func(encrypted bool, decrypt func([]byte) error) error {
+			if !encrypted {
+				return nil
+			}
+			if cached == "" {
+				p, err := passphrase(description)
+				if err != nil {
+					return err
+				}
+				cached = p
+			}
+			if err := decrypt([]byte(cached)); err != nil {
+				return fmt.Errorf("failed to unlock key for %s: wrong passphrase", description)
+			}
+			return nil
+		}
+
+		if entity.PrivateKey != nil {
+			if err := unlock(entity.PrivateKey.Encrypted, entity.PrivateKey.Decrypt); err != nil {
+				return err
+			}
+		}
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil {
+				if err := unlock(subkey.PrivateKey.Encrypted, subkey.PrivateKey.Decrypt); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type openpgpCipher struct {
+	keyring openpgp.EntityList
+}
+
+func (c *openpgpCipher) Decrypt(ciphertext io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	// pass writes binary messages, but armored ones are common in hand-made stores
+	var source io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN PGP")) {
+		block, err := armor.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid armored message: %w", err)
+		}
+		source = block.Body
+	}
+
+	md, err := openpgp.ReadMessage(source, c.keyring, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return io.ReadAll(md.UnverifiedBody)
+}
+
+func (c *openpgpCipher) Encrypt(w io.Writer, plaintext []byte) error {
+	plain, err := openpgp.Encrypt(w, c.keyring, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt: %w", err)
+	}
+	if _, err := plain.Write(plaintext); err != nil {
+		return err
+	}
+	return plain.Close()
+}
+
+func (c *openpgpCipher) RecipientsFile() (string, []string) {
+	var ids []string
+	for _, entity := range c.keyring {
+		ids = append(ids, fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint))
+	}
+	return ".gpg-id", ids
+}
+
+type ageCipher struct {
+	identities []age.Identity
+	recipients []age.Recipient
+}
+
+func (c *ageCipher) Decrypt(ciphertext io.Reader) ([]byte, error) {
+	if len(c.identities) == 0 {
+		return nil, fmt.Errorf("no age identities available for decryption")
+	}
+	r, err := age.Decrypt(ciphertext, c.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return io.ReadAll(r)
+}
+
+func (c *ageCipher) Encrypt(w io.Writer, plaintext []byte) error {
+	if len(c.recipients) == 0 {
+		return fmt.Errorf("no age recipients available for encryption")
+	}
+	plain, err := age.Encrypt(w, c.recipients...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt: %w", err)
+	}
+	if _, err := plain.Write(plaintext); err != nil {
+		return err
+	}
+	return plain.Close()
+}
+
+func (c *ageCipher) RecipientsFile() (string, []string) {
+	var ids []string
+	for _, recipient := range c.recipients {
+		if x, ok := recipient.(*age.X25519Recipient); ok {
+			ids = append(ids, x.String())
+		}
+	}
+	return ".age-recipients", ids
+}
@@ -0,0 +1,254 @@
+// Package passstore reads and writes password-store directories as used by
+// pass (https://www.passwordstore.org) and its age-based sibling passage.
+//
+// Each entry is an encrypted file named after the entry. The first line of
+// the decrypted content is the password; following "key: value" lines are
+// fields and any other lines are kept as notes. A line holding an otpauth://
+// URI is treated as the OTP secret, as the pass-otp extension does.
+package passstore
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Extension is the file extension of entries in the store
+const Extension = ".gpg"
+
+// Entry is a decrypted password-store entry
+type Entry struct {
+	// Name is the slash-separated path of the entry without extension
+	Name     string
+	Password string
+	Fields   map[string]string
+	Notes    string
+	OTP      string
+}
+
+// Read walks the store and decrypts every entry
+func Read(dir string, cipher Cipher) ([]*Entry, error) {
+	var entries []*Entry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir // .git and friends
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), Extension) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(strings.TrimSuffix(rel, Extension))
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		content, err := cipher.Decrypt(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		entries = append(entries, Parse(name, content))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Parse splits decrypted content into password, fields, OTP and notes
+func Parse(name string, content []byte) *Entry {
+	entry := &Entry{Name: name, Fields: make(map[string]string)}
+
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	entry.Password = strings.TrimRight(lines[0], "\r")
+
+	var notes []string
+	for _, line := range lines[1:] {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "otpauth://") && entry.OTP == "" {
+			entry.OTP = line
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok && isFieldKey(key) {
+			key = strings.ToLower(strings.TrimSpace(key))
+			if _, exists := entry.Fields[key]; !exists {
+				entry.Fields[key] = strings.TrimSpace(value)
+				continue
+			}
+		}
+		notes = append(notes, line)
+	}
+	entry.Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+
+	return entry
+}
+
+// isFieldKey reports whether text before a colon looks like a field name
+// rather than prose or a URL scheme
+func isFieldKey(key string) bool {
+	key = strings.TrimSpace(key)
+	if key == "" || len(key) > 32 {
+		return false
+	}
+	for _, r := range key {
+		if !(r == '_' || r == '-' || r == ' ' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Format renders an entry in the pass file layout
+func Format(entry *Entry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(entry.Password)
+	buf.WriteByte('\n')
+
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := entry.Fields[key]
+		if value == "" || strings.Contains(value, "\n") {
+			continue
+		}
+		fmt.Fprintf(&buf, "%s: %s\n", key, value)
+	}
+
+	if entry.OTP != "" {
+		buf.WriteString(entry.OTP)
+		buf.WriteByte('\n')
+	}
+	if entry.Notes != "" {
+		buf.WriteString(entry.Notes)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// Write encrypts entries into a store directory, creating it if needed.
+// Existing entry files are never overwritten: conflicts are checked before
+// anything is written, and if writing fails halfway the files and
+// directories created so far are removed again.
+func Write(dir string, entries []*Entry, cipher Cipher) (err error) {
+	paths := make([]string, len(entries))
+	seen := make(map[string]bool)
+	for i, entry := range entries {
+		path, err := entryPath(dir, entry.Name)
+		if err != nil {
+			return err
+		}
+		if seen[path] {
+			return fmt.Errorf("'%s' appears more than once", entry.Name)
+		}
+		if _, err := os.Lstat(path); err == nil {
+			return fmt.Errorf("'%s' already exists in the store, refusing to overwrite it", entry.Name)
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to check '%s': %w", entry.Name, err)
+		}
+		seen[path] = true
+		paths[i] = path
+	}
+
+	var created []string
+	defer func() {
+		if err != nil {
+			for i := len(created) - 1; i >= 0; i-- {
+				os.Remove(created[i])
+			}
+		}
+	}()
+
+	if err := makeDirs(dir, &created); err != nil {
+		return fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	idFile, ids := cipher.RecipientsFile()
+	idPath := filepath.Join(dir, idFile)
+	if _, err := os.Stat(idPath); os.IsNotExist(err) && len(ids) > 0 {
+		if err := os.WriteFile(idPath, []byte(strings.Join(ids, "\n")+"\n"), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", idFile, err)
+		}
+		created = append(created, idPath)
+	}
+
+	for i, entry := range entries {
+		path := paths[i]
+		if err := makeDirs(filepath.Dir(path), &created); err != nil {
+			return fmt.Errorf("failed to create directory for '%s': %w", entry.Name, err)
+		}
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			if os.IsExist(err) {
+				return fmt.Errorf("'%s' already exists in the store, refusing to overwrite it", entry.Name)
+			}
+			return fmt.Errorf("failed to create file for '%s': %w", entry.Name, err)
+		}
+		created = append(created, path)
+		if err := cipher.Encrypt(file, Format(entry)); err != nil {
+			file.Close()
+			return fmt.Errorf("%s: %w", entry.Name, err)
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// makeDirs creates a directory and any missing parents like os.MkdirAll,
+// adding each one it creates to created
+func makeDirs(dir string, created *[]string) error {
+	var missing []string
+	for path := dir; ; path = filepath.Dir(path) {
+		if _, err := os.Stat(path); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		missing = append(missing, path)
+		if filepath.Dir(path) == path {
+			break
+		}
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], 0700); err != nil {
+			return err
+		}
+		*created = append(*created, missing[i])
+	}
+	return nil
+}
+
+// entryPath maps an entry name to a file path inside the store, rejecting
+// names that would escape it
+func entryPath(dir, name string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(name))
+	if clean == "/" || strings.HasPrefix(filepath.Base(clean), ".") {
+		return "", fmt.Errorf("'%s' is not a valid password-store entry name", name)
+	}
+	return filepath.Join(dir, clean+Extension), nil
+}
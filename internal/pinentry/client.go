@@ -0,0 +1,99 @@
+package pinentry
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// Assuan error codes a pinentry returns when the user says no
+const (
+	codeCanceled     = "83886179"
+	codeNotConfirmed = "83886194"
+)
+
+// Confirm runs a pinentry program and asks it to show a yes/no dialog,
+// reporting whether the user chose OK
+func Confirm(program, title, description string) (bool, error) {
+	cmd := exec.Command(program)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return false, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return false, fmt.Errorf("failed to start %s: %w", program, err)
+	}
+	defer func() {
+		stdin.Close()
+		cmd.Wait()
+	}()
+
+	lines := bufio.NewScanner(stdout)
+	if _, err := response(lines); err != nil {
+		return false, fmt.Errorf("%s: %w", program, err)
+	}
+	for _, command := range []string{
+		"SETTITLE " + escape(title),
+		"SETDESC " + escape(description),
+		"SETOK Allow",
+		"SETCANCEL Deny",
+	} {
+		if err := send(stdin, lines, command); err != nil {
+			return false, fmt.Errorf("%s: %w", program, err)
+		}
+	}
+
+	if _, err := io.WriteString(stdin, "CONFIRM\n"); err != nil {
+		return false, fmt.Errorf("%s: %w", program, err)
+	}
+	ok, err := response(lines)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", program, err)
+	}
+	io.WriteString(stdin, "BYE\n")
+	return ok, nil
+}
+
+// send writes a command and fails unless the pinentry answers OK
+func send(w io.Writer, lines *bufio.Scanner, command string) error {
+	if _, err := io.WriteString(w, command+"\n"); err != nil {
+		return err
+	}
+	ok, err := response(lines)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s was cancelled", strings.Fields(command)[0])
+	}
+	return nil
+}
+
+// response reads up to the OK or ERR line ending a reply. A cancel or "not
+// confirmed" error reads as false; any other error is returned.
+func response(lines *bufio.Scanner) (bool, error) {
+	for lines.Scan() {
+		line := lines.Text()
+		switch {
+		case line == "OK" || strings.HasPrefix(line, "OK "):
+			return true, nil
+		case strings.HasPrefix(line, "ERR "):
+			code, message, _ := strings.Cut(strings.TrimPrefix(line, "ERR "), " ")
+			if code == codeCanceled || code == codeNotConfirmed {
+				return false, nil
+			}
+			return false, fmt.Errorf("%s", unescape(message))
+		}
+		// status (S), comment (#) and data (D) lines
+	}
+	if err := lines.Err(); err != nil {
+		return false, err
+	}
+	return false, fmt.Errorf("pinentry closed the connection")
+}
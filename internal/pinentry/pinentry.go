@@ -0,0 +1,146 @@
+// Package pinentry implements the pinentry side of the Assuan protocol that
+// gpg-agent uses to ask for passphrases, and a client that asks an installed
+// pinentry program for confirmation.
+//
+// Only the commands gpg-agent relies on are understood: settings such as
+// SETDESC and SETPROMPT are recorded, GETPIN answers with a secret from the
+// lookup function, and CONFIRM is always declined since a vault cannot
+// answer yes/no questions on the user's behalf.
+package pinentry
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// errCanceled is the Assuan error gpg-agent treats as "operation cancelled"
+const errCanceled = "ERR " + codeCanceled + " Operation cancelled <Pinentry>"
+
+// Request describes the passphrase gpg-agent is asking for
+type Request struct {
+	// KeyInfo identifies the key, e.g. "n/<keygrip>"
+	KeyInfo     string
+	Description string
+	Prompt      string
+	Title       string
+}
+
+// LookupFunc returns the passphrase for a request
+type LookupFunc func(req *Request) (string, error)
+
+// Serve answers Assuan commands until BYE or end of input
+func Serve(in io.Reader, out io.Writer, lookup LookupFunc) error {
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	reply := func(lines ...string) error {
+		for _, line := range lines {
+			if _, err := w.WriteString(line + "\n"); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+
+	if err := reply("OK Pleased to meet you"); err != nil {
+		return err
+	}
+
+	req := &Request{}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		command, arg, _ := strings.Cut(scanner.Text(), " ")
+		arg = unescape(arg)
+
+		var err error
+		switch strings.ToUpper(command) {
+		case "SETKEYINFO":
+			req.KeyInfo = arg
+			err = reply("OK")
+		case "SETDESC":
+			req.Description = arg
+			err = reply("OK")
+		case "SETPROMPT":
+			req.Prompt = arg
+			err = reply("OK")
+		case "SETTITLE":
+			req.Title = arg
+			err = reply("OK")
+		case "GETPIN":
+			pin, lookupErr := lookup(req)
+			if lookupErr != nil {
+				fmt.Fprintf(os.Stderr, "pinentry: %v\n", lookupErr)
+				err = reply(errCanceled)
+				break
+			}
+			err = reply("D "+escape(pin), "OK")
+		case "CONFIRM":
+			err = reply(errCanceled)
+		case "GETINFO":
+			err = getInfo(arg, reply)
+		case "BYE":
+			return reply("OK closing connection")
+		case "":
+			continue
+		default:
+			// OPTION, SETOK, SETERROR, SETQUALITYBAR, MESSAGE, RESET, ...
+			err = reply("OK")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func getInfo(what string, reply func(lines ...string) error) error {
+	switch what {
+	case "pid":
+		return reply("D "+strconv.Itoa(os.Getpid()), "OK")
+	case "version":
+		return reply("D 1.0.0", "OK")
+	case "flavor":
+		return reply("D remembrall", "OK")
+	default:
+		return reply("OK")
+	}
+}
+
+// escape percent-encodes the characters Assuan data lines can't contain
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '%', '\r', '\n':
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// unescape decodes %XX escapes in command arguments
+func unescape(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+// Package prompts maps password prompts shown by other programs (sudo, ssh,
+// gpg) to vault references.
+//
+// A rules file has one rule per line: a reference, whitespace, and a regular
+// expression matched against the prompt. The reference may use $1, ${name}
+// etc. to insert groups captured by the expression. Blank lines and lines
+// starting with # are ignored. The first matching rule wins.
+//
+//	# sudo and ssh key passphrases
+//	sudo-password        ^\[sudo\] password for
+//	ssh/$1:passphrase    Enter passphrase for key '.*/([^/']+)'
+package prompts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Rule maps prompts matching Pattern to the reference Ref
+type Rule struct {
+	Ref     string
+	Pattern *regexp.Regexp
+}
+
+// Parse reads a rules file
+func Parse(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected a reference and a pattern", lineNo)
+		}
+		ref := fields[0]
+		pattern := strings.TrimSpace(strings.TrimPrefix(line, ref))
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern: %w", lineNo, err)
+		}
+		rules = append(rules, Rule{Ref: ref, Pattern: re})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Match returns the reference of the first rule matching the prompt
+func Match(rules []Rule, prompt string) (string, bool) {
+	for _, rule := range rules {
+		match := rule.Pattern.FindStringSubmatchIndex(prompt)
+		if match == nil {
+			continue
+		}
+		ref := rule.Pattern.ExpandString(nil, rule.Ref, prompt, match)
+		return string(ref), true
+	}
+	return "", false
+}
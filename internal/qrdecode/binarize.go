@@ -0,0 +1,105 @@
+package qrdecode
+
+import "image"
+
+// bitmap is a black and white image; true is black
+type bitmap struct {
+	width, height int
+	bits          []bool
+}
+
+func (b *bitmap) black(x, y int) bool {
+	return b.bits[y*b.width+x]
+}
+
+// luminance converts an image to 8-bit grey levels, drawing transparent
+// pixels over white
+func luminance(img image.Image) ([]uint8, int, int) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	grey := make([]uint8, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			// Colors are premultiplied by alpha, so add white for the rest
+			l := (299*r + 587*g + 114*b) / 1000
+			l += 0xffff - a
+			grey[y*width+x] = uint8(l >> 8)
+		}
+	}
+	return grey, width, height
+}
+
+// globalThreshold binarizes with a single threshold chosen by Otsu's
+// method, which suits screenshots and evenly lit images
+func globalThreshold(grey []uint8, width, height int) *bitmap {
+	var histogram [256]int
+	for _, l := range grey {
+		histogram[l]++
+	}
+
+	total := len(grey)
+	var sum float64
+	for l, count := range histogram {
+		sum += float64(l * count)
+	}
+
+	var sumBackground float64
+	weightBackground := 0
+	best, threshold := -1.0, 128
+	for l, count := range histogram {
+		weightBackground += count
+		if weightBackground == 0 {
+			continue
+		}
+		weightForeground := total - weightBackground
+		if weightForeground == 0 {
+			break
+		}
+		sumBackground += float64(l * count)
+		meanBackground := sumBackground / float64(weightBackground)
+		meanForeground := (sum - sumBackground) / float64(weightForeground)
+		between := float64(weightBackground) * float64(weightForeground) * (meanBackground - meanForeground) * (meanBackground - meanForeground)
+		if between > best {
+			best, threshold = between, l
+		}
+	}
+
+	b := &bitmap{width: width, height: height, bits: make([]bool, len(grey))}
+	for i, l := range grey {
+		b.bits[i] = int(l) <= threshold
+	}
+	return b
+}
+
+// localThreshold binarizes each pixel against the mean of its
+// neighbourhood, which copes with shadows and uneven lighting in photos
+func localThreshold(grey []uint8, width, height int) *bitmap {
+	// Integral image for constant-time window sums
+	integral := make([]int64, (width+1)*(height+1))
+	for y := 0; y < height; y++ {
+		var row int64
+		for x := 0; x < width; x++ {
+			row += int64(grey[y*width+x])
+			integral[(y+1)*(width+1)+x+1] = integral[y*(width+1)+x+1] + row
+		}
+	}
+
+	radius := max(width, height) / 16
+	if radius < 7 {
+		radius = 7
+	}
+
+	b := &bitmap{width: width, height: height, bits: make([]bool, len(grey))}
+	for y := 0; y < height; y++ {
+		y0, y1 := max(y-radius, 0), min(y+radius+1, height)
+		for x := 0; x < width; x++ {
+			x0, x1 := max(x-radius, 0), min(x+radius+1, width)
+			sum := integral[y1*(width+1)+x1] - integral[y0*(width+1)+x1] - integral[y1*(width+1)+x0] + integral[y0*(width+1)+x0]
+			count := int64((y1 - y0) * (x1 - x0))
+			// Black when clearly darker than the surroundings
+			b.bits[y*width+x] = int64(grey[y*width+x])*count*100 < sum*85
+		}
+	}
+	return b
+}
@@ -0,0 +1,539 @@
+// Package qrdecode reads QR codes from images, enough to import the
+// otpauth:// URIs shown by websites and authenticator apps. It handles
+// versions 1 to 40 with numeric, alphanumeric and byte segments.
+package qrdecode
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math/bits"
+	"strings"
+	"unicode/utf8"
+)
+
+// Decode finds a QR code in an image and returns its text
+func Decode(img image.Image) (string, error) {
+	grey, width, height := luminance(img)
+	if width < 21 || height < 21 {
+		return "", errNotFound
+	}
+
+	// A single threshold suits screenshots; photos need a local one
+	var lastErr error = errNotFound
+	for _, binarize := range []func([]uint8, int, int) *bitmap{globalThreshold, localThreshold} {
+		b := binarize(grey, width, height)
+		for _, c := range selectCorners(findFinderPatterns(b)) {
+			text, err := b.decodeAt(c)
+			if err == nil {
+				return text, nil
+			}
+			lastErr = err
+		}
+	}
+	return "", lastErr
+}
+
+// decodeAt samples and decodes the code framed by three finder patterns,
+// trying each plausible size
+func (b *bitmap) decodeAt(c corners) (string, error) {
+	var lastErr error = errNotFound
+	tried := make(map[int]bool)
+	dims := c.dimensions()
+	for i := 0; i < len(dims); i++ {
+		dim := dims[i]
+		if tried[dim] {
+			continue
+		}
+		tried[dim] = true
+
+		t, ok := b.locate(c, dim)
+		if !ok {
+			continue
+		}
+		g, ok := b.sample(t, dim)
+		if !ok {
+			continue
+		}
+
+		// Large codes state their version; trust it over the estimate
+		if dim >= 45 {
+			if v, ok := g.readVersion(); ok && 17+4*v != dim {
+				dims = append(dims, 17+4*v)
+				continue
+			}
+		}
+
+		text, err := g.decode()
+		if err == nil {
+			return text, nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// grid is the sampled modules of a code; true is dark
+type grid struct {
+	size    int
+	modules []bool
+}
+
+func newGrid(size int) *grid {
+	return &grid{size: size, modules: make([]bool, size*size)}
+}
+
+func (g *grid) get(row, col int) bool {
+	return g.modules[row*g.size+col]
+}
+
+func (g *grid) set(row, col int, dark bool) {
+	g.modules[row*g.size+col] = dark
+}
+
+// formatCodes are the 32 valid format information words, indexed by the
+// five data bits: error correction level and mask
+var formatCodes [32]int
+
+func init() {
+	for data := range formatCodes {
+		remainder := data << 10
+		for i := 14; i >= 10; i-- {
+			if remainder&(1<<i) != 0 {
+				remainder ^= 0x537 << (i - 10)
+			}
+		}
+		formatCodes[data] = (data<<10 | remainder) ^ 0x5412
+	}
+}
+
+// readFormat returns the error correction level (0-3 for L, M, Q, H) and
+// mask, from whichever copy of the format information reads best
+func (g *grid) readFormat() (int, int, error) {
+	dim := g.size
+	bit := func(word *int, row, col int) {
+		*word <<= 1
+		if g.get(row, col) {
+			*word |= 1
+		}
+	}
+
+	// Around the top-left finder pattern, most significant bit first
+	var first int
+	for col := 0; col <= 5; col++ {
+		bit(&first, 8, col)
+	}
+	bit(&first, 8, 7)
+	bit(&first, 8, 8)
+	bit(&first, 7, 8)
+	for row := 5; row >= 0; row-- {
+		bit(&first, row, 8)
+	}
+
+	// Split between the other two
+	var second int
+	for row := dim - 1; row >= dim-7; row-- {
+		bit(&second, row, 8)
+	}
+	for col := dim - 8; col < dim; col++ {
+		bit(&second, 8, col)
+	}
+
+	best, bestDistance := 0, 16
+	for data, code := range formatCodes {
+		for _, word := range []int{first, second} {
+			if d := bits.OnesCount(uint(word ^ code)); d < bestDistance {
+				best, bestDistance = data, d
+			}
+		}
+	}
+	if bestDistance > 3 {
+		return 0, 0, fmt.Errorf("unreadable format information")
+	}
+
+	// The level bits are L=01, M=00, Q=11, H=10
+	return (best >> 3) ^ 1, best & 7, nil
+}
+
+// readVersion reads the version information of a version 7+ code
+func (g *grid) readVersion() (int, bool) {
+	dim := g.size
+	var topRight, bottomLeft int
+	for k := 17; k >= 0; k-- {
+		x, y := k/3, k%3
+		topRight <<= 1
+		if g.get(x, dim-11+y) {
+			topRight |= 1
+		}
+		bottomLeft <<= 1
+		if g.get(dim-11+y, x) {
+			bottomLeft |= 1
+		}
+	}
+
+	best, bestDistance := 0, 19
+	for v := 7; v < len(versions); v++ {
+		for _, word := range []int{topRight, bottomLeft} {
+			if d := bits.OnesCount(uint(word ^ versions[v].pattern)); d < bestDistance {
+				best, bestDistance = v, d
+			}
+		}
+	}
+	return best, bestDistance <= 3
+}
+
+// functionModules marks the finder, timing, alignment, format and version
+// modules, which carry no data
+func functionModules(v int) *grid {
+	dim := 17 + 4*v
+	g := newGrid(dim)
+	fill := func(row, col, height, width int) {
+		for r := row; r < row+height; r++ {
+			for c := col; c < col+width; c++ {
+				g.set(r, c, true)
+			}
+		}
+	}
+
+	// Finder patterns with separators and format information
+	fill(0, 0, 9, 9)
+	fill(0, dim-8, 9, 8)
+	fill(dim-8, 0, 8, 9)
+
+	// Timing patterns
+	fill(6, 0, 1, dim)
+	fill(0, 6, dim, 1)
+
+	// Alignment patterns, except where they would overlap a finder pattern
+	info := versions[v]
+	var starts []int
+	if v > 1 {
+		for corner := 4; corner+5 < dim; {
+			starts = append(starts, corner)
+			if corner == 4 {
+				corner = info.alignStart
+			} else {
+				corner += info.alignStride
+			}
+		}
+	}
+	for _, x := range starts {
+		for _, y := range starts {
+			if (x < 7 && y < 7) || (x < 7 && y+5 >= dim-7) || (x+5 >= dim-7 && y < 7) {
+				continue
+			}
+			fill(y, x, 5, 5)
+		}
+	}
+
+	// Version information
+	if v >= 7 {
+		fill(0, dim-11, 6, 3)
+		fill(dim-11, 0, 3, 6)
+	}
+
+	return g
+}
+
+// masks are the eight data mask patterns; a module is inverted where the
+// function returns true
+var masks = [8]func(i, j int) bool{
+	func(i, j int) bool { return (i+j)%2 == 0 },
+	func(i, j int) bool { return i%2 == 0 },
+	func(i, j int) bool { return j%3 == 0 },
+	func(i, j int) bool { return (i+j)%3 == 0 },
+	func(i, j int) bool { return (i/2+j/3)%2 == 0 },
+	func(i, j int) bool { return i*j%2+i*j%3 == 0 },
+	func(i, j int) bool { return (i*j%2+i*j%3)%2 == 0 },
+	func(i, j int) bool { return (i*j%3+(i+j)%2)%2 == 0 },
+}
+
+// decode reads the text of a sampled code
+func (g *grid) decode() (string, error) {
+	v := (g.size - 17) / 4
+	if v < 1 || v >= len(versions) || 17+4*v != g.size {
+		return "", fmt.Errorf("invalid code size %d", g.size)
+	}
+
+	level, mask, err := g.readFormat()
+	if err != nil {
+		return "", err
+	}
+
+	codewords := g.readCodewords(v, mask)
+	data, err := correctBlocks(codewords, versions[v], versions[v].levels[level])
+	if err != nil {
+		return "", err
+	}
+	return parseSegments(data, v)
+}
+
+// readCodewords collects the data modules in the zigzag order of the
+// standard, two columns at a time from the bottom-right corner
+func (g *grid) readCodewords(v, mask int) []byte {
+	function := functionModules(v)
+	invert := masks[mask]
+	dim := g.size
+	total := versions[v].bytes
+
+	codewords := make([]byte, 0, total)
+	var current byte
+	count := 0
+	read := func(row, col int) {
+		if function.get(row, col) || len(codewords) == total {
+			return
+		}
+		current <<= 1
+		if g.get(row, col) != invert(row, col) {
+			current |= 1
+		}
+		if count++; count == 8 {
+			codewords = append(codewords, current)
+			current, count = 0, 0
+		}
+	}
+
+	for x := dim; x > 0; {
+		for row := dim - 1; row >= 0; row-- {
+			read(row, x-1)
+			read(row, x-2)
+		}
+		x -= 2
+		// Skip the vertical timing pattern
+		if x == 7 {
+			x--
+		}
+		for row := 0; row < dim; row++ {
+			read(row, x-1)
+			read(row, x-2)
+		}
+		x -= 2
+	}
+
+	return codewords
+}
+
+// correctBlocks de-interleaves the codewords into their blocks, corrects
+// errors and returns the data bytes in order
+func correctBlocks(codewords []byte, info version, level errorBlocks) ([]byte, error) {
+	dataBytes := info.bytes - level.check*level.count
+	short := dataBytes / level.count
+	longBlocks := dataBytes % level.count
+
+	// The last blocks are one data byte longer
+	blocks := make([][]byte, level.count)
+	lengths := make([]int, level.count)
+	for i := range blocks {
+		lengths[i] = short
+		if i >= level.count-longBlocks {
+			lengths[i]++
+		}
+		blocks[i] = make([]byte, lengths[i]+level.check)
+	}
+
+	// Data bytes are interleaved first, then the check bytes
+	next := 0
+	for i := 0; i <= short; i++ {
+		for b := range blocks {
+			if i < lengths[b] {
+				blocks[b][i] = codewords[next]
+				next++
+			}
+		}
+	}
+	for i := 0; i < level.check; i++ {
+		for b := range blocks {
+			blocks[b][lengths[b]+i] = codewords[next]
+			next++
+		}
+	}
+
+	data := make([]byte, 0, dataBytes)
+	for b, block := range blocks {
+		if _, err := correct(block, level.check); err != nil {
+			return nil, fmt.Errorf("damaged code: %w", err)
+		}
+		data = append(data, block[:lengths[b]]...)
+	}
+	return data, nil
+}
+
+// bitReader reads big-endian bit fields
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) remaining() int {
+	return len(r.data)*8 - r.pos
+}
+
+func (r *bitReader) read(n int) (int, error) {
+	if n > r.remaining() {
+		return 0, errTruncated
+	}
+	value := 0
+	for i := 0; i < n; i++ {
+		value <<= 1
+		if r.data[r.pos/8]&(0x80>>(r.pos%8)) != 0 {
+			value |= 1
+		}
+		r.pos++
+	}
+	return value, nil
+}
+
+var errTruncated = errors.New("truncated data")
+
+// Segment modes
+const (
+	modeTerminator      = 0x0
+	modeNumeric         = 0x1
+	modeAlphanumeric    = 0x2
+	modeStructured      = 0x3
+	modeByte            = 0x4
+	modeFNC1First       = 0x5
+	modeECI             = 0x7
+	modeKanji           = 0x8
+	modeFNC1Second      = 0x9
+	alphanumericCharset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+)
+
+// countBits returns the width of a segment's character count, which grows
+// with the version
+func countBits(mode, v int) int {
+	class := 0
+	switch {
+	case v >= 27:
+		class = 2
+	case v >= 10:
+		class = 1
+	}
+	switch mode {
+	case modeNumeric:
+		return [3]int{10, 12, 14}[class]
+	case modeAlphanumeric:
+		return [3]int{9, 11, 13}[class]
+	case modeByte:
+		return [3]int{8, 16, 16}[class]
+	default:
+		return [3]int{8, 10, 12}[class]
+	}
+}
+
+// parseSegments decodes the data bytes into text
+func parseSegments(data []byte, v int) (string, error) {
+	r := &bitReader{data: data}
+	var text strings.Builder
+	for r.remaining() >= 4 {
+		mode, _ := r.read(4)
+		switch mode {
+		case modeTerminator:
+			return finishText(text.String()), nil
+
+		case modeNumeric:
+			count, err := r.read(countBits(mode, v))
+			if err != nil {
+				return "", err
+			}
+			for ; count > 0; count -= 3 {
+				digits := min(count, 3)
+				value, err := r.read([4]int{0, 4, 7, 10}[digits])
+				if err != nil {
+					return "", err
+				}
+				fmt.Fprintf(&text, "%0*d", digits, value)
+			}
+
+		case modeAlphanumeric:
+			count, err := r.read(countBits(mode, v))
+			if err != nil {
+				return "", err
+			}
+			for ; count > 1; count -= 2 {
+				value, err := r.read(11)
+				if err != nil {
+					return "", err
+				}
+				if value >= 45*45 {
+					return "", fmt.Errorf("invalid alphanumeric data")
+				}
+				text.WriteByte(alphanumericCharset[value/45])
+				text.WriteByte(alphanumericCharset[value%45])
+			}
+			if count == 1 {
+				value, err := r.read(6)
+				if err != nil {
+					return "", err
+				}
+				if value >= 45 {
+					return "", fmt.Errorf("invalid alphanumeric data")
+				}
+				text.WriteByte(alphanumericCharset[value])
+			}
+
+		case modeByte:
+			count, err := r.read(countBits(mode, v))
+			if err != nil {
+				return "", err
+			}
+			for ; count > 0; count-- {
+				value, err := r.read(8)
+				if err != nil {
+					return "", err
+				}
+				text.WriteByte(byte(value))
+			}
+
+		case modeECI:
+			// The designator is 1 to 3 bytes, flagged by its leading bits;
+			// byte segments are read as UTF-8 regardless
+			first, err := r.read(8)
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case first&0x80 == 0:
+			case first&0xc0 == 0x80:
+				_, err = r.read(8)
+			default:
+				_, err = r.read(16)
+			}
+			if err != nil {
+				return "", err
+			}
+
+		case modeStructured:
+			if _, err := r.read(16); err != nil {
+				return "", err
+			}
+
+		case modeFNC1First:
+
+		case modeFNC1Second:
+			if _, err := r.read(8); err != nil {
+				return "", err
+			}
+
+		case modeKanji:
+			return "", fmt.Errorf("kanji QR codes are not supported")
+
+		default:
+			return "", fmt.Errorf("invalid segment mode %d", mode)
+		}
+	}
+	return finishText(text.String()), nil
+}
+
+// finishText interprets byte data as UTF-8 when it is valid, and as
+// ISO 8859-1, the standard's default, otherwise
+func finishText(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,261 @@
+package qrdecode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"remembrall/internal/otp"
+
+	"rsc.io/qr"
+	"rsc.io/qr/coding"
+)
+
+const (
+	totpURI      = "otpauth://totp/ACME:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=ACME&period=30"
+	migrationURI = "otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC"
+)
+
+// encode builds a code with an explicit version, level and mask
+func encode(t *testing.T, version coding.Version, level coding.Level, mask coding.Mask, text ...coding.Encoding) (*coding.Plan, *coding.Code) {
+	t.Helper()
+	plan, err := coding.NewPlan(version, level, mask)
+	if err != nil {
+		t.Fatalf("NewPlan: %v", err)
+	}
+	code, err := plan.Encode(text...)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return plan, code
+}
+
+// render draws a code with a four-module quiet zone, inverting the modules
+// in flip
+func render(code *coding.Code, scale int, flip map[image.Point]bool) *image.Gray {
+	size := (code.Size + 8) * scale
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			mx, my := x/scale-4, y/scale-4
+			if code.Black(mx, my) != flip[image.Pt(mx, my)] {
+				img.SetGray(x, y, color.Gray{0})
+			} else {
+				img.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	return img
+}
+
+// damage returns the modules of the first count data codewords of every
+// block, or of the first count check codewords with check set. Module
+// offsets number the codewords block by block, before interleaving.
+func damage(plan *coding.Plan, count int, check bool) map[image.Point]bool {
+	codewords := make(map[int]bool)
+	size, extra := plan.DataBytes/plan.Blocks, plan.DataBytes%plan.Blocks
+	start := 0
+	for block := 0; block < plan.Blocks; block++ {
+		if check {
+			start = plan.DataBytes + block*plan.CheckBytes/plan.Blocks
+		}
+		for i := 0; i < count; i++ {
+			codewords[start+i] = true
+		}
+		// The last blocks hold one more data codeword
+		start += size
+		if block >= plan.Blocks-extra {
+			start++
+		}
+	}
+
+	flip := make(map[image.Point]bool)
+	for y, row := range plan.Pixel {
+		for x, pix := range row {
+			if role := pix.Role(); role != coding.Data && role != coding.Check {
+				continue
+			}
+			if codewords[int(pix.Offset()/8)] {
+				flip[image.Pt(x, y)] = true
+			}
+		}
+	}
+	return flip
+}
+
+func TestDecodeLevels(t *testing.T) {
+	for _, level := range []qr.Level{qr.L, qr.M, qr.Q, qr.H} {
+		code, err := qr.Encode(totpURI, level)
+		if err != nil {
+			t.Fatalf("qr.Encode: %v", err)
+		}
+
+		// Through PNG, as a screenshot would arrive
+		img, err := png.Decode(bytes.NewReader(code.PNG()))
+		if err != nil {
+			t.Fatalf("png.Decode: %v", err)
+		}
+		if got, err := Decode(img); err != nil {
+			t.Errorf("level %d: %v", level, err)
+		} else if got != totpURI {
+			t.Errorf("level %d: got %q, want %q", level, got, totpURI)
+		}
+	}
+}
+
+func TestDecodeMigration(t *testing.T) {
+	code, err := qr.Encode(migrationURI, qr.M)
+	if err != nil {
+		t.Fatalf("qr.Encode: %v", err)
+	}
+	got, err := Decode(code.Image())
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got != migrationURI {
+		t.Fatalf("got %q, want %q", got, migrationURI)
+	}
+
+	keys, err := otp.ParseMigration(got)
+	if err != nil {
+		t.Fatalf("ParseMigration: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Issuer != "Example" || keys[0].Account != "alice@google.com" {
+		t.Errorf("got %+v", keys)
+	}
+}
+
+func TestDecodeSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		text []coding.Encoding
+		want string
+	}{
+		{"numeric", []coding.Encoding{coding.Num("0123456789012")}, "0123456789012"},
+		{"alphanumeric", []coding.Encoding{coding.Alpha("HELLO WORLD $%*+-./:")}, "HELLO WORLD $%*+-./:"},
+		{"byte", []coding.Encoding{coding.String("hello, world")}, "hello, world"},
+		{"utf-8", []coding.Encoding{coding.String("Zürich ☃")}, "Zürich ☃"},
+		{"latin-1", []coding.Encoding{coding.String("caf\xe9")}, "café"},
+		{"mixed", []coding.Encoding{coding.Alpha("ID:"), coding.Num("31415926"), coding.String("/pi")}, "ID:31415926/pi"},
+	}
+
+	for _, tt := range tests {
+		_, code := encode(t, 3, coding.M, 0, tt.text...)
+		if got, err := Decode(render(code, 4, nil)); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeMasks(t *testing.T) {
+	for mask := coding.Mask(0); mask < 8; mask++ {
+		_, code := encode(t, 2, coding.Q, mask, coding.String("mask test"))
+		if got, err := Decode(render(code, 3, nil)); err != nil {
+			t.Errorf("mask %d: %v", mask, err)
+		} else if got != "mask test" {
+			t.Errorf("mask %d: got %q", mask, got)
+		}
+	}
+}
+
+func TestDecodeVersions(t *testing.T) {
+	// Version 7 is the first to carry version information, and 40 the largest
+	for _, version := range []coding.Version{1, 6, 7, 15, 25, 40} {
+		text := strings.Repeat("v", int(version))
+		_, code := encode(t, version, coding.L, 3, coding.String(text))
+		if got, err := Decode(render(code, 2, nil)); err != nil {
+			t.Errorf("version %d: %v", version, err)
+		} else if got != text {
+			t.Errorf("version %d: got %q, want %q", version, got, text)
+		}
+	}
+}
+
+func TestDecodeDamaged(t *testing.T) {
+	for _, level := range []coding.Level{coding.L, coding.M, coding.Q, coding.H} {
+		plan, code := encode(t, 5, level, 2, coding.String(totpURI[:40]))
+
+		// As many errors in every block as its check bytes can correct
+		perBlock := plan.CheckBytes / plan.Blocks / 2
+		for _, check := range []bool{false, true} {
+			got, err := Decode(render(code, 4, damage(plan, perBlock, check)))
+			if err != nil {
+				t.Errorf("level %s, %d codewords per block, check %v: %v", level, perBlock, check, err)
+			} else if got != totpURI[:40] {
+				t.Errorf("level %s, %d codewords per block, check %v: got %q", level, perBlock, check, got)
+			}
+		}
+
+		// One more is beyond repair
+		flip := damage(plan, perBlock+1, false)
+		if got, err := Decode(render(code, 4, flip)); err == nil {
+			t.Errorf("level %s: decoded %q from an unrecoverable code", level, got)
+		}
+	}
+}
+
+func TestDecodePlacement(t *testing.T) {
+	_, code := encode(t, 6, coding.M, 5, coding.String(totpURI))
+	base := render(code, 5, nil)
+	size := base.Bounds().Dx()
+
+	// Rotated a quarter turn at a time, off-centre on a larger grey canvas
+	for turn := 0; turn < 4; turn++ {
+		img := image.NewGray(image.Rect(0, 0, size+130, size+70))
+		for i := range img.Pix {
+			img.Pix[i] = 200
+		}
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				sx, sy := x, y
+				for i := 0; i < turn; i++ {
+					sx, sy = sy, size-1-sx
+				}
+				img.SetGray(x+100, y+20, base.GrayAt(sx, sy))
+			}
+		}
+
+		if got, err := Decode(img); err != nil {
+			t.Errorf("%d quarter turns: %v", turn, err)
+		} else if got != totpURI {
+			t.Errorf("%d quarter turns: got %q", turn, got)
+		}
+	}
+}
+
+func TestDecodeNoCode(t *testing.T) {
+	blank := image.NewGray(image.Rect(0, 0, 200, 200))
+	for i := range blank.Pix {
+		blank.Pix[i] = 255
+	}
+
+	noise := image.NewGray(image.Rect(0, 0, 200, 200))
+	rng := rand.New(rand.NewSource(1))
+	for i := range noise.Pix {
+		noise.Pix[i] = uint8(rng.Intn(2) * 255)
+	}
+
+	for name, img := range map[string]image.Image{
+		"blank": blank,
+		"noise": noise,
+		"tiny":  image.NewGray(image.Rect(0, 0, 10, 10)),
+	} {
+		if got, err := Decode(img); err == nil {
+			t.Errorf("%s: decoded %q", name, got)
+		}
+	}
+}
+
+func ExampleDecode() {
+	code, _ := qr.Encode("otpauth://totp/bob?secret=JBSWY3DPEHPK3PXP", qr.L)
+	text, err := Decode(code.Image())
+	fmt.Println(text, err)
+	// Output: otpauth://totp/bob?secret=JBSWY3DPEHPK3PXP <nil>
+}
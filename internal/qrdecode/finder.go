@@ -0,0 +1,468 @@
+package qrdecode
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// errNotFound is returned when an image has no recognizable QR code
+var errNotFound = errors.New("no QR code found in the image")
+
+// point is a position in image coordinates; pixel (x, y) covers
+// [x, x+1) × [y, y+1)
+type point struct {
+	x, y float64
+}
+
+func distance(a, b point) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+// finderPattern is a candidate centre of one of the three corner squares
+type finderPattern struct {
+	point
+	moduleSize float64
+	count      int // times it was found, from different rows
+}
+
+// findFinderPatterns scans the rows for the 1:1:3:1:1 dark:light ratio of
+// the finder squares and confirms each hit across the other axis
+func findFinderPatterns(b *bitmap) []finderPattern {
+	var patterns []finderPattern
+	step := max(b.height/400, 1)
+
+	for y := 0; y < b.height; y += step {
+		var counts [5]int
+		state := 0
+		for x := 0; x < b.width; x++ {
+			if b.black(x, y) {
+				// Odd states count light runs
+				if state&1 == 1 {
+					state++
+				}
+				counts[state]++
+				continue
+			}
+
+			switch {
+			case state == 0 && counts[0] == 0:
+				// Light margin before the first dark run
+			case state&1 == 1:
+				counts[state]++
+			case state < 4:
+				state++
+				counts[state]++
+			default:
+				if foundPatternCross(counts) {
+					patterns = confirmPattern(b, patterns, counts, x, y)
+				}
+				// Slide along by one dark and one light run
+				counts = [5]int{counts[2], counts[3], counts[4], 1, 0}
+				state = 3
+			}
+		}
+		if state == 4 && foundPatternCross(counts) {
+			patterns = confirmPattern(b, patterns, counts, b.width, y)
+		}
+	}
+
+	return patterns
+}
+
+// foundPatternCross reports whether run lengths are close to 1:1:3:1:1
+func foundPatternCross(counts [5]int) bool {
+	total := 0
+	for _, count := range counts {
+		if count == 0 {
+			return false
+		}
+		total += count
+	}
+	if total < 7 {
+		return false
+	}
+
+	moduleSize := float64(total) / 7
+	variance := moduleSize / 2
+	return math.Abs(moduleSize-float64(counts[0])) < variance &&
+		math.Abs(moduleSize-float64(counts[1])) < variance &&
+		math.Abs(3*moduleSize-float64(counts[2])) < 3*variance &&
+		math.Abs(moduleSize-float64(counts[3])) < variance &&
+		math.Abs(moduleSize-float64(counts[4])) < variance
+}
+
+// confirmPattern checks a row hit ending at x vertically and then
+// horizontally again, and merges it into the known patterns
+func confirmPattern(b *bitmap, patterns []finderPattern, counts [5]int, x, y int) []finderPattern {
+	total := counts[0] + counts[1] + counts[2] + counts[3] + counts[4]
+	centerX := float64(x-counts[4]-counts[3]) - float64(counts[2])/2
+
+	startX := int(centerX)
+	offset, _, ok := b.crossCheck(startX, y, 0, 1, counts[2], total)
+	if !ok {
+		return patterns
+	}
+	centerY := float64(y) + offset
+
+	startY := int(centerY)
+	offset, moduleSize, ok := b.crossCheck(startX, startY, 1, 0, counts[2], total)
+	if !ok {
+		return patterns
+	}
+	centerX = float64(startX) + offset
+
+	found := finderPattern{point: point{centerX, centerY}, moduleSize: moduleSize, count: 1}
+	for i, p := range patterns {
+		if math.Abs(p.x-found.x) <= moduleSize && math.Abs(p.y-found.y) <= moduleSize &&
+			math.Abs(p.moduleSize-moduleSize) <= math.Max(1, moduleSize/2) {
+			// Running average weighted by how often each was seen
+			n := float64(p.count)
+			patterns[i] = finderPattern{
+				point:      point{(p.x*n + found.x) / (n + 1), (p.y*n + found.y) / (n + 1)},
+				moduleSize: (p.moduleSize*n + moduleSize) / (n + 1),
+				count:      p.count + 1,
+			}
+			return patterns
+		}
+	}
+	return append(patterns, found)
+}
+
+// crossCheck walks from (x, y) in direction (dx, dy) both ways, measuring
+// a finder pattern. It returns the offset of the centre from the start,
+// the module size, and whether the runs have the expected ratio.
+func (b *bitmap) crossCheck(x, y, dx, dy, maxCount, originalTotal int) (float64, float64, bool) {
+	inside := func(i int) bool {
+		px, py := x+i*dx, y+i*dy
+		return px >= 0 && py >= 0 && px < b.width && py < b.height
+	}
+	black := func(i int) bool {
+		return b.black(x+i*dx, y+i*dy)
+	}
+
+	if !inside(0) || !black(0) {
+		return 0, 0, false
+	}
+
+	// Backwards from the centre: the rest of the centre, light, dark
+	var counts [5]int
+	i := 0
+	for ; inside(i) && black(i); i-- {
+		counts[2]++
+	}
+	for ; inside(i) && !black(i) && counts[1] <= maxCount; i-- {
+		counts[1]++
+	}
+	if !inside(i) || counts[1] > maxCount {
+		return 0, 0, false
+	}
+	for ; inside(i) && black(i) && counts[0] <= maxCount; i-- {
+		counts[0]++
+	}
+	if counts[0] > maxCount {
+		return 0, 0, false
+	}
+
+	// Forwards: the centre, light, dark
+	for i = 1; inside(i) && black(i); i++ {
+		counts[2]++
+	}
+	for ; inside(i) && !black(i) && counts[3] <= maxCount; i++ {
+		counts[3]++
+	}
+	if !inside(i) || counts[3] > maxCount {
+		return 0, 0, false
+	}
+	for ; inside(i) && black(i) && counts[4] <= maxCount; i++ {
+		counts[4]++
+	}
+	if counts[4] > maxCount {
+		return 0, 0, false
+	}
+
+	// The size across must roughly match the size along the row
+	total := counts[0] + counts[1] + counts[2] + counts[3] + counts[4]
+	if 5*abs(total-originalTotal) >= 2*originalTotal || !foundPatternCross(counts) {
+		return 0, 0, false
+	}
+
+	center := float64(i-counts[4]-counts[3]) - float64(counts[2])/2
+	return center, float64(total) / 7, true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// corners are the finder pattern centres in reading orientation
+type corners struct {
+	topLeft, topRight, bottomLeft point
+	moduleSize                    float64
+}
+
+// selectCorners picks the likeliest triples of finder patterns, best first:
+// the centres of a QR code form a right isosceles triangle and have about
+// the same module size
+func selectCorners(patterns []finderPattern) []corners {
+	// Patterns seen on several rows are far more likely to be real
+	var confirmed []finderPattern
+	for _, p := range patterns {
+		if p.count >= 2 {
+			confirmed = append(confirmed, p)
+		}
+	}
+	if len(confirmed) >= 3 {
+		patterns = confirmed
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return patterns[i].count > patterns[j].count
+	})
+	if len(patterns) > 12 {
+		patterns = patterns[:12]
+	}
+
+	type scored struct {
+		corners
+		score float64
+	}
+	var triples []scored
+	for i := 0; i < len(patterns); i++ {
+		for j := i + 1; j < len(patterns); j++ {
+			for k := j + 1; k < len(patterns); k++ {
+				c, score, ok := orderCorners(patterns[i], patterns[j], patterns[k])
+				if ok {
+					triples = append(triples, scored{c, score})
+				}
+			}
+		}
+	}
+	sort.Slice(triples, func(i, j int) bool {
+		return triples[i].score < triples[j].score
+	})
+
+	result := make([]corners, 0, len(triples))
+	for _, t := range triples {
+		result = append(result, t.corners)
+	}
+	return result
+}
+
+// orderCorners arranges three patterns as top-left, top-right and
+// bottom-left and scores how far they are from an ideal code; lower is
+// better
+func orderCorners(a, b, c finderPattern) (corners, float64, bool) {
+	sizes := []float64{a.moduleSize, b.moduleSize, c.moduleSize}
+	sort.Float64s(sizes)
+	if sizes[2] > sizes[0]*1.5 {
+		return corners{}, 0, false
+	}
+
+	// The top-left pattern is opposite the longest side
+	ab, bc, ca := distance(a.point, b.point), distance(b.point, c.point), distance(c.point, a.point)
+	var topLeft, p, q finderPattern
+	var legs [2]float64
+	var hypotenuse float64
+	switch {
+	case bc >= ab && bc >= ca:
+		topLeft, p, q, hypotenuse, legs = a, b, c, bc, [2]float64{ab, ca}
+	case ca >= ab && ca >= bc:
+		topLeft, p, q, hypotenuse, legs = b, c, a, ca, [2]float64{ab, bc}
+	default:
+		topLeft, p, q, hypotenuse, legs = c, a, b, ab, [2]float64{bc, ca}
+	}
+
+	moduleSize := (a.moduleSize + b.moduleSize + c.moduleSize) / 3
+	if legs[0] < 14*moduleSize || legs[1] < 14*moduleSize {
+		return corners{}, 0, false
+	}
+
+	// Equal legs, and Pythagoras for the hypotenuse
+	score := math.Abs(legs[0]-legs[1])/math.Max(legs[0], legs[1]) +
+		math.Abs(hypotenuse*hypotenuse-legs[0]*legs[0]-legs[1]*legs[1])/(hypotenuse*hypotenuse) +
+		(sizes[2]-sizes[0])/sizes[2]
+	if score > 0.5 {
+		return corners{}, 0, false
+	}
+
+	// With y pointing down, top-right follows top-left clockwise
+	cross := (q.x-topLeft.x)*(p.y-topLeft.y) - (q.y-topLeft.y)*(p.x-topLeft.x)
+	if cross < 0 {
+		p, q = q, p
+	}
+	return corners{topLeft: topLeft.point, topRight: q.point, bottomLeft: p.point, moduleSize: moduleSize}, score, true
+}
+
+// dimensions returns the likely sizes of the code in modules, best first
+func (c corners) dimensions() []int {
+	across := (distance(c.topLeft, c.topRight) + distance(c.topLeft, c.bottomLeft)) / 2
+	estimate := int(math.Round(across/c.moduleSize)) + 7
+
+	// Sizes are 21, 25, ... 177
+	var dims []int
+	base := estimate - (estimate-1)%4
+	for _, d := range []int{base, base + 4, base - 4} {
+		if d >= 21 && d <= 177 {
+			dims = append(dims, d)
+		}
+	}
+	sort.SliceStable(dims, func(i, j int) bool {
+		return abs(dims[i]-estimate) < abs(dims[j]-estimate)
+	})
+	return dims
+}
+
+// transform maps module coordinates (column, row) to image coordinates
+type transform [8]float64
+
+func (t transform) apply(u, v float64) point {
+	d := t[6]*u + t[7]*v + 1
+	return point{(t[0]*u + t[1]*v + t[2]) / d, (t[3]*u + t[4]*v + t[5]) / d}
+}
+
+// perspective solves for the projective transform taking four module
+// positions to four image positions
+func perspective(from, to [4]point) (transform, bool) {
+	// Two equations per correspondence in the eight unknowns
+	var m [8][9]float64
+	for i := 0; i < 4; i++ {
+		u, v, x, y := from[i].x, from[i].y, to[i].x, to[i].y
+		m[2*i] = [9]float64{u, v, 1, 0, 0, 0, -u * x, -v * x, x}
+		m[2*i+1] = [9]float64{0, 0, 0, u, v, 1, -u * y, -v * y, y}
+	}
+
+	// Gaussian elimination with partial pivoting
+	for col := 0; col < 8; col++ {
+		pivot := col
+		for row := col + 1; row < 8; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return transform{}, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for row := 0; row < 8; row++ {
+			if row == col {
+				continue
+			}
+			factor := m[row][col] / m[col][col]
+			for k := col; k < 9; k++ {
+				m[row][k] -= factor * m[col][k]
+			}
+		}
+	}
+
+	var t transform
+	for i := range t {
+		t[i] = m[i][8] / m[i][i]
+	}
+	return t, true
+}
+
+// locate returns the transform for a code of the given size, refined with
+// the bottom-right alignment pattern when there is one
+func (b *bitmap) locate(c corners, dim int) (transform, bool) {
+	// An affine estimate from the three finder patterns
+	span := float64(dim - 7)
+	ux := point{(c.topRight.x - c.topLeft.x) / span, (c.topRight.y - c.topLeft.y) / span}
+	uy := point{(c.bottomLeft.x - c.topLeft.x) / span, (c.bottomLeft.y - c.topLeft.y) / span}
+	affine := func(u, v float64) point {
+		u, v = u-3.5, v-3.5
+		return point{c.topLeft.x + u*ux.x + v*uy.x, c.topLeft.y + u*ux.y + v*uy.y}
+	}
+
+	from := [4]point{{3.5, 3.5}, {float64(dim) - 3.5, 3.5}, {3.5, float64(dim) - 3.5}, {float64(dim) - 3.5, float64(dim) - 3.5}}
+	to := [4]point{c.topLeft, c.topRight, c.bottomLeft, affine(float64(dim)-3.5, float64(dim)-3.5)}
+
+	// Versions 2 and up have an alignment pattern three modules in from
+	// the finder pattern lines, which corrects for perspective
+	if dim > 21 {
+		center := float64(dim) - 6.5
+		if found, ok := b.findAlignment(affine(center, center), ux, uy, dim); ok {
+			from[3], to[3] = point{center, center}, found
+		}
+	}
+
+	return perspective(from, to)
+}
+
+// findAlignment searches around an estimated position for the 5×5
+// alignment pattern, using the module vectors of the code. The search
+// widens with the size of the code, as perspective moves the pattern
+// further from the estimate.
+func (b *bitmap) findAlignment(estimate, ux, uy point, dim int) (point, bool) {
+	moduleSize := (math.Hypot(ux.x, ux.y) + math.Hypot(uy.x, uy.y)) / 2
+	radius := int(math.Ceil(moduleSize * math.Max(4, float64(dim)/8)))
+
+	type hit struct {
+		point
+		score int
+	}
+	var hits []hit
+	best := 0
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			center := point{estimate.x + float64(dx), estimate.y + float64(dy)}
+			score := 0
+			for v := -2; v <= 2; v++ {
+				for u := -2; u <= 2; u++ {
+					x := int(math.Floor(center.x + float64(u)*ux.x + float64(v)*uy.x))
+					y := int(math.Floor(center.y + float64(u)*ux.y + float64(v)*uy.y))
+					if x < 0 || y < 0 || x >= b.width || y >= b.height {
+						continue
+					}
+					// Dark ring, light ring, dark centre
+					ring := max(abs(u), abs(v))
+					if b.black(x, y) == (ring != 1) {
+						score++
+					}
+				}
+			}
+			if score >= 23 && score >= best {
+				best = score
+				hits = append(hits, hit{center, score})
+			}
+		}
+	}
+	if best == 0 {
+		return point{}, false
+	}
+
+	// Average the best matches around the one nearest the estimate, so a
+	// neighbouring alignment pattern does not pull the result away
+	nearest := -1
+	for i, h := range hits {
+		if h.score == best && (nearest < 0 || distance(h.point, estimate) < distance(hits[nearest].point, estimate)) {
+			nearest = i
+		}
+	}
+	var sum point
+	n := 0.0
+	for _, h := range hits {
+		if h.score == best && distance(h.point, hits[nearest].point) <= moduleSize {
+			sum.x, sum.y, n = sum.x+h.x, sum.y+h.y, n+1
+		}
+	}
+	return point{sum.x / n, sum.y / n}, true
+}
+
+// sample reads the module grid of a code through a transform
+func (b *bitmap) sample(t transform, dim int) (*grid, bool) {
+	g := newGrid(dim)
+	for row := 0; row < dim; row++ {
+		for col := 0; col < dim; col++ {
+			p := t.apply(float64(col)+0.5, float64(row)+0.5)
+			x, y := int(math.Floor(p.x)), int(math.Floor(p.y))
+			if x < 0 || y < 0 || x >= b.width || y >= b.height {
+				return nil, false
+			}
+			g.set(row, col, b.black(x, y))
+		}
+	}
+	return g, true
+}
@@ -0,0 +1,155 @@
+package qrdecode
+
+import "errors"
+
+// errTooManyErrors is returned when a block has more errors than its check
+// bytes can correct
+var errTooManyErrors = errors.New("too many errors to correct")
+
+// Arithmetic in GF(256) with the QR code polynomial x^8+x^4+x^3+x^2+1
+var (
+	gfExp [512]byte
+	gfLog [256]int
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+// gfPow returns the generator raised to the power n
+func gfPow(n int) byte {
+	n %= 255
+	if n < 0 {
+		n += 255
+	}
+	return gfExp[n]
+}
+
+// evalPoly evaluates a polynomial with coefficients in ascending order
+func evalPoly(poly []byte, x byte) byte {
+	var y byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ poly[i]
+	}
+	return y
+}
+
+// correct fixes up to check/2 byte errors in a block of data followed by
+// check bytes, in place. It returns the number of corrected bytes.
+func correct(block []byte, check int) (int, error) {
+	n := len(block)
+
+	// Syndromes S_j = r(α^j); the first byte is the highest coefficient
+	syndromes := make([]byte, check)
+	clean := true
+	for j := range syndromes {
+		alpha := gfPow(j)
+		var s byte
+		for _, c := range block {
+			s = gfMul(s, alpha) ^ c
+		}
+		syndromes[j] = s
+		if s != 0 {
+			clean = false
+		}
+	}
+	if clean {
+		return 0, nil
+	}
+
+	// Berlekamp-Massey finds the error locator polynomial
+	locator := []byte{1}
+	previous := []byte{1}
+	length, shift := 0, 1
+	var lastDiscrepancy byte = 1
+	for i := 0; i < check; i++ {
+		discrepancy := syndromes[i]
+		for k := 1; k <= length && k < len(locator); k++ {
+			discrepancy ^= gfMul(locator[k], syndromes[i-k])
+		}
+		if discrepancy == 0 {
+			shift++
+			continue
+		}
+
+		scale := gfDiv(discrepancy, lastDiscrepancy)
+		updated := make([]byte, max(len(locator), len(previous)+shift))
+		copy(updated, locator)
+		for k, c := range previous {
+			updated[k+shift] ^= gfMul(scale, c)
+		}
+
+		if 2*length <= i {
+			previous = locator
+			length = i + 1 - length
+			lastDiscrepancy = discrepancy
+			shift = 1
+		} else {
+			shift++
+		}
+		locator = updated
+	}
+	if length*2 > check {
+		return 0, errTooManyErrors
+	}
+
+	// Chien search: an error at byte i makes α^-(n-1-i) a root
+	var positions []int
+	for i := 0; i < n; i++ {
+		if evalPoly(locator, gfPow(-(n-1-i))) == 0 {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) != length {
+		return 0, errTooManyErrors
+	}
+
+	// Forney: e = X * Ω(X^-1) / Λ'(X^-1), with Ω = S·Λ mod x^check
+	evaluator := make([]byte, check)
+	for i := range evaluator {
+		for k := 0; k <= i && k < len(locator); k++ {
+			evaluator[i] ^= gfMul(locator[k], syndromes[i-k])
+		}
+	}
+	derivative := make([]byte, len(locator))
+	for k := 1; k < len(locator); k += 2 {
+		derivative[k-1] = locator[k]
+	}
+
+	for _, i := range positions {
+		x := gfPow(n - 1 - i)
+		xInverse := gfPow(-(n - 1 - i))
+		denominator := evalPoly(derivative, xInverse)
+		if denominator == 0 {
+			return 0, errTooManyErrors
+		}
+		block[i] ^= gfMul(x, gfDiv(evalPoly(evaluator, xInverse), denominator))
+	}
+
+	return len(positions), nil
+}
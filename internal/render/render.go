@@ -0,0 +1,65 @@
+// Package render formats logins as the credential files read by other
+// programs: ~/.netrc (curl, ftp, git) and ~/.pgpass (psql, libpq).
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Login is one machine or database login
+type Login struct {
+	Host     string
+	Port     string // empty for any port
+	Database string // empty for any database; pgpass only
+	Login    string
+	Password string
+}
+
+// Netrc formats logins as a .netrc file. Ports and databases are ignored,
+// since netrc matches machines by name only.
+func Netrc(logins []Login) []byte {
+	var buf bytes.Buffer
+	for _, login := range logins {
+		fmt.Fprintf(&buf, "machine %s", netrcToken(login.Host))
+		if login.Login != "" {
+			fmt.Fprintf(&buf, " login %s", netrcToken(login.Login))
+		}
+		fmt.Fprintf(&buf, " password %s\n", netrcToken(login.Password))
+	}
+	return buf.Bytes()
+}
+
+// netrcToken quotes values containing whitespace or quotes, as understood
+// by curl and Python's netrc module
+func netrcToken(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r\"\\") {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// Pgpass formats logins as hostname:port:database:username:password lines,
+// using "*" for an empty port, database or login
+func Pgpass(logins []Login) []byte {
+	var buf bytes.Buffer
+	for _, login := range logins {
+		fmt.Fprintf(&buf, "%s:%s:%s:%s:%s\n",
+			pgpassField(login.Host, false),
+			pgpassField(login.Port, true),
+			pgpassField(login.Database, true),
+			pgpassField(login.Login, true),
+			pgpassField(login.Password, false))
+	}
+	return buf.Bytes()
+}
+
+// pgpassField escapes colons and backslashes
+func pgpassField(value string, wildcard bool) string {
+	if value == "" && wildcard {
+		return "*"
+	}
+	return strings.NewReplacer(`\`, `\\`, `:`, `\:`).Replace(value)
+}
@@ -0,0 +1,215 @@
+// Package sops encrypts YAML documents in the format of Mozilla SOPS, with
+// age recipients, so they can be committed and later decrypted with 'sops -d'.
+//
+// Values are encrypted with AES-256-GCM under a random data key, using the
+// path of the value as additional data. The data key is encrypted to each
+// age recipient, and a MAC over all values guards against tampering.
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the SOPS format version written to the metadata
+const Version = "3.9.0"
+
+// nonceSize is the GCM nonce size used by SOPS
+const nonceSize = 32
+
+// Encrypt encrypts, in place, the values of a YAML mapping document whose
+// path contains a key matching encryptedRegex, and adds the "sops" metadata
+// key. Only string values are supported.
+func Encrypt(doc *yaml.Node, encryptedRegex string, recipients []string) error {
+	if len(recipients) == 0 {
+		return fmt.Errorf("at least one age recipient is required")
+	}
+	pattern, err := regexp.Compile(encryptedRegex)
+	if err != nil {
+		return fmt.Errorf("invalid encrypted regex: %w", err)
+	}
+
+	root := doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("document must be a mapping")
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	// Encrypt the data key to every recipient first, so a bad recipient
+	// leaves the document untouched
+	var keys []*yaml.Node
+	for _, recipient := range recipients {
+		enc, err := encryptDataKey(dataKey, recipient)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, mapping(
+			"recipient", scalar(recipient),
+			"enc", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: enc, Style: yaml.LiteralStyle},
+		))
+	}
+
+	// The MAC covers every value in document order, before encryption
+	mac := sha512.New()
+	if err := walk(root, nil, func(node *yaml.Node, path []string) error {
+		mac.Write([]byte(node.Value))
+		if !matchesPath(pattern, path) {
+			return nil
+		}
+		value, err := encryptValue(dataKey, node.Value, strings.Join(path, ":")+":")
+		if err != nil {
+			return err
+		}
+		node.Value = value
+		node.Style = 0
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	lastModified := time.Now().UTC().Format(time.RFC3339)
+	encryptedMAC, err := encryptValue(dataKey, fmt.Sprintf("%X", mac.Sum(nil)), lastModified)
+	if err != nil {
+		return err
+	}
+
+	metadata := mapping(
+		"age", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: keys},
+		"lastmodified", scalar(lastModified),
+		"mac", scalar(encryptedMAC),
+		"encrypted_regex", scalar(encryptedRegex),
+		"version", scalar(Version),
+	)
+	root.Content = append(root.Content, scalar("sops"), metadata)
+	return nil
+}
+
+// CheckRecipients reports the first recipient that isn't an age public key
+func CheckRecipients(recipients []string) error {
+	for _, recipient := range recipients {
+		if _, err := age.ParseX25519Recipient(recipient); err != nil {
+			return fmt.Errorf("invalid age recipient '%s': %w", recipient, err)
+		}
+	}
+	return nil
+}
+
+// walk calls fn for every scalar value with the mapping keys leading to it
+func walk(node *yaml.Node, path []string, fn func(*yaml.Node, []string) error) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if err := walk(node.Content[i+1], append(path[:len(path):len(path)], key), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := walk(item, path, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if node.ShortTag() != "!!str" {
+			return fmt.Errorf("value of '%s' is not a string", strings.Join(path, "."))
+		}
+		return fn(node, path)
+	default:
+		return fmt.Errorf("unsupported YAML at '%s'", strings.Join(path, "."))
+	}
+	return nil
+}
+
+// matchesPath reports whether any key on the path matches the pattern
+func matchesPath(pattern *regexp.Regexp, path []string) bool {
+	for _, key := range path {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptValue encrypts a string as ENC[AES256_GCM,data:...,iv:...,tag:...,type:str]
+func encryptValue(key []byte, plaintext, additionalData string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, nonceSize)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	iv := make([]byte, nonceSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), []byte(additionalData))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag)), nil
+}
+
+// encryptDataKey encrypts the data key to an age recipient, armored
+func encryptDataKey(dataKey []byte, recipient string) (string, error) {
+	r, err := age.ParseX25519Recipient(recipient)
+	if err != nil {
+		return "", fmt.Errorf("invalid age recipient '%s': %w", recipient, err)
+	}
+
+	var buf bytes.Buffer
+	armorWriter := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armorWriter, r)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt data key: %w", err)
+	}
+	if _, err := w.Write(dataKey); err != nil {
+		return "", fmt.Errorf("failed to encrypt data key: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to encrypt data key: %w", err)
+	}
+	if err := armorWriter.Close(); err != nil {
+		return "", fmt.Errorf("failed to encrypt data key: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// mapping builds a mapping node from alternating keys and values
+func mapping(pairs ...interface{}) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		node.Content = append(node.Content, scalar(pairs[i].(string)), pairs[i+1].(*yaml.Node))
+	}
+	return node
+}
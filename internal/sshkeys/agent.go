@@ -0,0 +1,178 @@
+package sshkeys
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// errReadOnly is returned for requests that would change the agent's keys,
+// which are managed in the vault instead
+var errReadOnly = errors.New("keys are managed with 'remembrall ssh-key'")
+
+// Key is a private key served by the agent
+type Key struct {
+	Name    string
+	Comment string
+	Signer  ssh.Signer
+	// Confirm asks the user before every signature with this key
+	Confirm bool
+	// Expires is when the agent stops offering the key, zero for never
+	Expires time.Time
+}
+
+// ConfirmFunc asks the user whether a key may be used
+type ConfirmFunc func(key *Key) bool
+
+// Agent serves vault keys over the SSH agent protocol. It is read-only:
+// ssh-add can list keys but not add or remove them.
+type Agent struct {
+	keys    []*Key
+	confirm ConfirmFunc
+
+	// mu serializes confirmation prompts
+	mu sync.Mutex
+}
+
+var _ agent.ExtendedAgent = (*Agent)(nil)
+
+// NewAgent creates an agent serving the given keys
+func NewAgent(keys []*Key, confirm ConfirmFunc) *Agent {
+	return &Agent{keys: keys, confirm: confirm}
+}
+
+// Serve answers agent connections until the listener is closed
+func (a *Agent) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		go func() {
+			defer conn.Close()
+			agent.ServeAgent(a, conn)
+		}()
+	}
+}
+
+// active returns the keys that have not expired
+func (a *Agent) active() []*Key {
+	now := time.Now()
+	var keys []*Key
+	for _, key := range a.keys {
+		if key.Expires.IsZero() || now.Before(key.Expires) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// find returns the active key with the given public key
+func (a *Agent) find(pub ssh.PublicKey) *Key {
+	wanted := pub.Marshal()
+	for _, key := range a.active() {
+		if bytes.Equal(key.Signer.PublicKey().Marshal(), wanted) {
+			return key
+		}
+	}
+	return nil
+}
+
+// List returns the identities of the active keys
+func (a *Agent) List() ([]*agent.Key, error) {
+	var identities []*agent.Key
+	for _, key := range a.active() {
+		pub := key.Signer.PublicKey()
+		comment := key.Comment
+		if comment == "" {
+			comment = key.Name
+		}
+		identities = append(identities, &agent.Key{
+			Format:  pub.Type(),
+			Blob:    pub.Marshal(),
+			Comment: comment,
+		})
+	}
+	return identities, nil
+}
+
+// Sign signs data with the default algorithm of the key
+func (a *Agent) Sign(pub ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(pub, data, 0)
+}
+
+// SignWithFlags signs data, using SHA-2 for RSA keys when asked to
+func (a *Agent) SignWithFlags(pub ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	key := a.find(pub)
+	if key == nil {
+		return nil, errors.New("key not found")
+	}
+
+	if key.Confirm {
+		a.mu.Lock()
+		allowed := a.confirm != nil && a.confirm(key)
+		a.mu.Unlock()
+		if !allowed {
+			return nil, fmt.Errorf("use of key '%s' was not confirmed", key.Name)
+		}
+	}
+
+	if flags == 0 {
+		return key.Signer.Sign(rand.Reader, data)
+	}
+
+	algorithmSigner, ok := key.Signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return nil, fmt.Errorf("key '%s' does not support signature flags", key.Name)
+	}
+	var algorithm string
+	switch {
+	case flags&agent.SignatureFlagRsaSha256 != 0:
+		algorithm = ssh.KeyAlgoRSASHA256
+	case flags&agent.SignatureFlagRsaSha512 != 0:
+		algorithm = ssh.KeyAlgoRSASHA512
+	default:
+		return nil, fmt.Errorf("unsupported signature flags %d", flags)
+	}
+	return algorithmSigner.SignWithAlgorithm(rand.Reader, data, algorithm)
+}
+
+// Signers returns signers for the active keys
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	var signers []ssh.Signer
+	for _, key := range a.active() {
+		signers = append(signers, key.Signer)
+	}
+	return signers, nil
+}
+
+// Add is not supported
+func (a *Agent) Add(key agent.AddedKey) error { return errReadOnly }
+
+// Remove is not supported
+func (a *Agent) Remove(key ssh.PublicKey) error { return errReadOnly }
+
+// RemoveAll is not supported
+func (a *Agent) RemoveAll() error { return errReadOnly }
+
+// Lock is not supported; stop the agent instead
+func (a *Agent) Lock(passphrase []byte) error { return errReadOnly }
+
+// Unlock is not supported
+func (a *Agent) Unlock(passphrase []byte) error { return errReadOnly }
+
+// Extension reports that no extensions are supported
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
@@ -0,0 +1,92 @@
+// Package sshkeys generates, parses and serves SSH private keys kept in the vault.
+package sshkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Supported key types for Generate
+const (
+	TypeEd25519 = "ed25519"
+	TypeRSA     = "rsa"
+)
+
+// Generate creates a new private key. Bits is only used for RSA keys.
+func Generate(keyType string, bits int) (crypto.PrivateKey, error) {
+	switch keyType {
+	case TypeEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		return key, nil
+	case TypeRSA:
+		if bits < 2048 {
+			return nil, fmt.Errorf("RSA keys must have at least 2048 bits")
+		}
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type '%s' (use %s or %s)", keyType, TypeEd25519, TypeRSA)
+	}
+}
+
+// Parse reads a PEM or OpenSSH private key. If the key is protected,
+// passphrase is called to obtain its passphrase.
+func Parse(data []byte, passphrase func() (string, error)) (crypto.PrivateKey, error) {
+	key, err := ssh.ParseRawPrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		secret, perr := passphrase()
+		if perr != nil {
+			return nil, perr
+		}
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(data, []byte(secret))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	// OpenSSH ed25519 keys are returned by pointer, everything else by value
+	if k, ok := key.(*ed25519.PrivateKey); ok {
+		key = *k
+	}
+	return key, nil
+}
+
+// Marshal encodes a private key in the unencrypted OpenSSH format. The
+// result is meant to be stored encrypted in the vault.
+func Marshal(key crypto.PrivateKey, comment string) (string, error) {
+	block, err := ssh.MarshalPrivateKey(key, comment)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// AuthorizedKey returns the public half of a private key as an
+// authorized_keys line
+func AuthorizedKey(key crypto.PrivateKey, comment string) (string, error) {
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return "", fmt.Errorf("unsupported private key: %w", err)
+	}
+
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	if comment != "" {
+		line += " " + comment
+	}
+	return line, nil
+}
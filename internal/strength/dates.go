@@ -0,0 +1,235 @@
+package strength
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Years read as part of a date
+	dateMinYear = 1000
+	dateMaxYear = 2050
+
+	// minYearSpace is the fewest years an attacker tries around the
+	// current one, as dates close to it are guessed first
+	minYearSpace = 20
+)
+
+// referenceYear is the year dates are assumed to be close to
+var referenceYear = time.Now().Year()
+
+// dateSplits lists where a run of 4 to 8 digits may be cut into day,
+// month and year, as offsets of the second and third part
+var dateSplits = map[int][][2]int{
+	4: {{1, 2}, {2, 3}},         // 1 1 91, 11 9 1
+	5: {{1, 3}, {2, 3}},         // 1 11 91, 11 1 91
+	6: {{1, 2}, {2, 4}, {4, 5}}, // 1 1 1991, 11 11 91, 1991 1 1
+	7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+	8: {{2, 4}, {4, 6}}, // 11 11 1991, 1991 11 11
+}
+
+// dateSeparators may separate the parts of a date
+const dateSeparators = " /\\_.-"
+
+// dateMatches finds dates such as "13.5.1984", "1984-05-13" or "130584",
+// in any order of day, month and year. A date inside a longer date is
+// left out.
+func dateMatches(password []rune) []*Match {
+	var matches []*Match
+
+	// Digits only
+	for i := 0; i+4 <= len(password); i++ {
+		for j := i + 3; j <= i+7 && j < len(password); j++ {
+			token := password[i : j+1]
+			if !allDigits(token) {
+				break
+			}
+
+			var best *Match
+			for _, split := range dateSplits[len(token)] {
+				day, month, year, ok := dateFromInts(
+					atoi(token[:split[0]]), atoi(token[split[0]:split[1]]), atoi(token[split[1]:]))
+				if !ok {
+					continue
+				}
+				if best == nil || abs(year-referenceYear) < abs(best.Year-referenceYear) {
+					best = &Match{Pattern: PatternDate, I: i, J: j, Token: string(token), Year: year, Month: month, Day: day}
+				}
+			}
+			if best != nil {
+				matches = append(matches, best)
+			}
+		}
+	}
+
+	// With separators, the same one twice
+	for i := 0; i+6 <= len(password); i++ {
+		for j := i + 5; j <= i+9 && j < len(password); j++ {
+			token := password[i : j+1]
+			parts, separator, ok := splitDate(token)
+			if !ok {
+				continue
+			}
+			day, month, year, ok := dateFromInts(atoi(parts[0]), atoi(parts[1]), atoi(parts[2]))
+			if !ok {
+				continue
+			}
+			matches = append(matches, &Match{
+				Pattern:   PatternDate,
+				I:         i,
+				J:         j,
+				Token:     string(token),
+				Year:      year,
+				Month:     month,
+				Day:       day,
+				Separator: string(separator),
+			})
+		}
+	}
+
+	var outer []*Match
+	for _, m := range matches {
+		inside := false
+		for _, other := range matches {
+			if other != m && other.I <= m.I && other.J >= m.J {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			outer = append(outer, m)
+		}
+	}
+	return outer
+}
+
+// splitDate splits a token like "1-2-1991" into its three runs of 1 to 4
+// digits and the separator between them
+func splitDate(token []rune) ([3][]rune, rune, bool) {
+	var parts [3][]rune
+	var separator rune
+	part, start := 0, 0
+	for k, r := range token {
+		if r >= '0' && r <= '9' {
+			continue
+		}
+		if part == 2 || !strings.ContainsRune(dateSeparators, r) || (part == 1 && r != separator) {
+			return parts, 0, false
+		}
+		separator = r
+		parts[part] = token[start:k]
+		part, start = part+1, k+1
+	}
+	if part != 2 {
+		return parts, 0, false
+	}
+	parts[2] = token[start:]
+
+	for k, p := range parts {
+		if len(p) == 0 || len(p) > 4 || (k == 1 && len(p) > 2) {
+			return parts, 0, false
+		}
+	}
+	return parts, separator, true
+}
+
+// dateFromInts reads three numbers as a day, month and year in any of the
+// usual orders, preferring a four digit year and two digit years expanded
+// to the nearest century
+func dateFromInts(a, b, c int) (int, int, int, bool) {
+	if b > 31 || b <= 0 {
+		return 0, 0, 0, false
+	}
+	over12, over31, under1 := 0, 0, 0
+	for _, n := range []int{a, b, c} {
+		if (n > 99 && n < dateMinYear) || n > dateMaxYear {
+			return 0, 0, 0, false
+		}
+		if n > 31 {
+			over31++
+		}
+		if n > 12 {
+			over12++
+		}
+		if n <= 0 {
+			under1++
+		}
+	}
+	if over31 >= 2 || over12 == 3 || under1 >= 2 {
+		return 0, 0, 0, false
+	}
+
+	splits := [][3]int{{c, a, b}, {a, b, c}} // year first, then day and month
+	for _, s := range splits {
+		if s[0] >= dateMinYear && s[0] <= dateMaxYear {
+			day, month, ok := dayMonth(s[1], s[2])
+			return day, month, s[0], ok
+		}
+	}
+	for _, s := range splits {
+		if day, month, ok := dayMonth(s[1], s[2]); ok {
+			year := s[0]
+			switch {
+			case year > 99:
+			case year > 50:
+				year += 1900
+			default:
+				year += 2000
+			}
+			return day, month, year, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// dayMonth reads two numbers as a day and month in either order
+func dayMonth(a, b int) (int, int, bool) {
+	for _, dm := range [][2]int{{a, b}, {b, a}} {
+		if dm[0] >= 1 && dm[0] <= 31 && dm[1] >= 1 && dm[1] <= 12 {
+			return dm[0], dm[1], true
+		}
+	}
+	return 0, 0, false
+}
+
+// yearMatches finds years from 1900 to 2099
+func yearMatches(password []rune) []*Match {
+	var matches []*Match
+	for i := 0; i+4 <= len(password); i++ {
+		token := password[i : i+4]
+		if !allDigits(token) || (token[0] != '1' || token[1] != '9') && (token[0] != '2' || token[1] != '0') {
+			continue
+		}
+		matches = append(matches, &Match{Pattern: PatternYear, I: i, J: i + 3, Token: string(token), Year: atoi(token)})
+	}
+	return matches
+}
+
+// dateGuesses counts the days of the years between the date and now, with
+// a minimum span; separators could be any of a few
+func dateGuesses(m *Match) float64 {
+	guesses := float64(max(abs(m.Year-referenceYear), minYearSpace)) * 365
+	if m.Separator != "" {
+		guesses *= 4
+	}
+	return guesses
+}
+
+func yearGuesses(m *Match) float64 {
+	return float64(max(abs(m.Year-referenceYear), minYearSpace))
+}
+
+func allDigits(runes []rune) bool {
+	for _, r := range runes {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func atoi(runes []rune) int {
+	n, _ := strconv.Atoi(string(runes))
+	return n
+}
@@ -0,0 +1,76 @@
+package strength
+
+import (
+	"bufio"
+	"embed"
+	"strings"
+	"sync"
+)
+
+// The ranked word lists come from the zxcvbn project (MIT licensed): common
+// passwords, English words by frequency in film and television, and US
+// census first names and surnames. Lower ranks are more common.
+//
+//go:embed dictionaries/*.txt
+var dictionaryFiles embed.FS
+
+// Dictionary names, as reported in matches
+const (
+	DictionaryPasswords  = "passwords"
+	DictionaryEnglish    = "english"
+	DictionaryNames      = "names"
+	DictionarySurnames   = "surnames"
+	DictionaryUserInputs = "user_inputs"
+)
+
+// dictionary maps lowercase words to their frequency rank, starting at 1
+type dictionary struct {
+	name   string
+	ranks  map[string]int
+	maxLen int
+}
+
+func newDictionary(name string, words []string) *dictionary {
+	d := &dictionary{name: name, ranks: make(map[string]int, len(words))}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+		if _, ok := d.ranks[word]; ok {
+			continue
+		}
+		d.ranks[word] = len(d.ranks) + 1
+		if n := len([]rune(word)); n > d.maxLen {
+			d.maxLen = n
+		}
+	}
+	return d
+}
+
+var (
+	loadDictionaries sync.Once
+	dictionaries     []*dictionary
+)
+
+// rankedDictionaries returns the embedded dictionaries, read on first use
+func rankedDictionaries() []*dictionary {
+	loadDictionaries.Do(func() {
+		for _, name := range []string{DictionaryPasswords, DictionaryEnglish, DictionaryNames, DictionarySurnames} {
+			file, err := dictionaryFiles.Open("dictionaries/" + name + ".txt")
+			if err != nil {
+				panic("strength: missing dictionary " + name)
+			}
+
+			var words []string
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				words = append(words, scanner.Text())
+			}
+			file.Close()
+
+			dictionaries = append(dictionaries, newDictionary(name, words))
+		}
+	})
+	return dictionaries
+}
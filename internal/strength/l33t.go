@@ -0,0 +1,152 @@
+package strength
+
+import (
+	"sort"
+	"unicode"
+)
+
+// l33tTable lists the characters commonly substituted for letters
+var l33tTable = map[rune][]rune{
+	'a': {'4', '@'},
+	'b': {'8'},
+	'c': {'(', '{', '[', '<'},
+	'e': {'3'},
+	'g': {'6', '9'},
+	'i': {'1', '!', '|'},
+	'l': {'1', '|', '7'},
+	'o': {'0'},
+	's': {'$', '5'},
+	't': {'+', '7'},
+	'x': {'%'},
+	'z': {'2'},
+}
+
+// l33tMatches finds dictionary words with letters substituted, such as
+// "p4ssw0rd". Characters that could stand for several letters, like "1"
+// for i or l, are tried each way.
+func l33tMatches(password []rune, dicts []*dictionary) []*Match {
+	type key struct {
+		i, j       int
+		dictionary string
+		rank       int
+	}
+	seen := make(map[key]bool)
+
+	var matches []*Match
+	for _, sub := range l33tSubstitutions(password) {
+		subbed := make([]rune, len(password))
+		for i, r := range password {
+			if letter, ok := sub[r]; ok {
+				subbed[i] = letter
+			} else {
+				subbed[i] = r
+			}
+		}
+
+		for _, m := range dictionaryMatches(subbed, dicts) {
+			token := password[m.I : m.J+1]
+			// Single characters and words without a substitution are
+			// matched elsewhere
+			if len(token) < 2 || equalRunes(lowerRunes(token), lowerRunes(subbed[m.I:m.J+1])) {
+				continue
+			}
+			k := key{m.I, m.J, m.Dictionary, m.Rank}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+
+			m.Token = string(token)
+			m.L33t = make(map[rune]rune)
+			for _, r := range token {
+				if letter, ok := sub[r]; ok {
+					m.L33t[r] = letter
+				}
+			}
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// l33tSubstitutions returns every way of reading the substitution
+// characters of a password as letters, each character standing for one
+// letter throughout
+func l33tSubstitutions(password []rune) []map[rune]rune {
+	candidates := make(map[rune][]rune)
+	for letter, subs := range l33tTable {
+		for _, sub := range subs {
+			for _, r := range password {
+				if r == sub {
+					candidates[sub] = append(candidates[sub], letter)
+					break
+				}
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	chars := make([]rune, 0, len(candidates))
+	for r := range candidates {
+		chars = append(chars, r)
+		sort.Slice(candidates[r], func(a, b int) bool { return candidates[r][a] < candidates[r][b] })
+	}
+	sort.Slice(chars, func(a, b int) bool { return chars[a] < chars[b] })
+
+	subs := []map[rune]rune{{}}
+	for _, r := range chars {
+		var next []map[rune]rune
+		for _, sub := range subs {
+			for _, letter := range candidates[r] {
+				extended := make(map[rune]rune, len(sub)+1)
+				for k, v := range sub {
+					extended[k] = v
+				}
+				extended[r] = letter
+				next = append(next, extended)
+			}
+		}
+		subs = next
+	}
+	return subs
+}
+
+// l33tVariations counts the ways the letters of a word could have been
+// substituted as often as they are in the token. Substituting every
+// occurrence of a letter, or none, only doubles the guesses.
+func l33tVariations(m *Match) float64 {
+	variations := 1.0
+	token := lowerRunes([]rune(m.Token))
+	for subbed, letter := range m.L33t {
+		s, u := 0, 0
+		for _, r := range token {
+			switch r {
+			case subbed:
+				s++
+			case letter:
+				u++
+			}
+		}
+		if s == 0 || u == 0 {
+			variations *= 2
+			continue
+		}
+
+		possibilities := 0.0
+		for i := 1; i <= min(s, u); i++ {
+			possibilities += binomial(s+u, i)
+		}
+		variations *= possibilities
+	}
+	return variations
+}
+
+func lowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
@@ -0,0 +1,215 @@
+package strength
+
+import "sort"
+
+// Patterns recognised in passwords
+const (
+	PatternDictionary = "dictionary"
+	PatternRepeat     = "repeat"
+	PatternSequence   = "sequence"
+	PatternSpatial    = "spatial"
+	PatternDate       = "date"
+	PatternYear       = "year"
+	PatternBruteforce = "bruteforce"
+)
+
+// maxSequenceDelta is the largest step between characters of a sequence,
+// so "aceg" and "9630" count but "adgj" only when typed on a keyboard
+const maxSequenceDelta = 5
+
+// Match is a part of a password recognised by a matcher. I and J are the
+// rune offsets of its first and last character.
+type Match struct {
+	Pattern string
+	I, J    int
+	Token   string
+	Guesses float64
+
+	// Dictionary matches. Reversed words are spelt backwards; L33t maps
+	// the substituted characters of the token to the letters they stand for.
+	Dictionary string
+	Rank       int
+	Reversed   bool
+	L33t       map[rune]rune
+
+	// Repeat matches: BaseToken repeated Repeats times
+	BaseToken   string
+	BaseGuesses float64
+	Repeats     int
+
+	// Sequence matches
+	Ascending bool
+
+	// Spatial matches: a path on a keyboard Graph, changing direction
+	// Turns times with Shifted characters typed with shift
+	Graph   string
+	Turns   int
+	Shifted int
+
+	// Date and year matches
+	Year, Month, Day int
+	Separator        string
+}
+
+// length returns the number of characters in the match
+func (m *Match) length() int {
+	return m.J - m.I + 1
+}
+
+// omnimatch runs every matcher over a password
+func omnimatch(password []rune, dicts []*dictionary) []*Match {
+	var matches []*Match
+	matches = append(matches, dictionaryMatches(password, dicts)...)
+	matches = append(matches, reversedDictionaryMatches(password, dicts)...)
+	matches = append(matches, l33tMatches(password, dicts)...)
+	matches = append(matches, spatialMatches(password)...)
+	matches = append(matches, repeatMatches(password, dicts)...)
+	matches = append(matches, sequenceMatches(password)...)
+	matches = append(matches, dateMatches(password)...)
+	matches = append(matches, yearMatches(password)...)
+
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].I != matches[b].I {
+			return matches[a].I < matches[b].I
+		}
+		return matches[a].J < matches[b].J
+	})
+	return matches
+}
+
+// dictionaryMatches finds every substring that is a dictionary word,
+// ignoring case
+func dictionaryMatches(password []rune, dicts []*dictionary) []*Match {
+	lower := lowerRunes(password)
+
+	var matches []*Match
+	for _, d := range dicts {
+		for i := range lower {
+			for j := i; j < len(lower) && j-i < d.maxLen; j++ {
+				rank, ok := d.ranks[string(lower[i:j+1])]
+				if !ok {
+					continue
+				}
+				matches = append(matches, &Match{
+					Pattern:    PatternDictionary,
+					I:          i,
+					J:          j,
+					Token:      string(password[i : j+1]),
+					Dictionary: d.name,
+					Rank:       rank,
+				})
+			}
+		}
+	}
+	return matches
+}
+
+// reversedDictionaryMatches finds dictionary words spelt backwards, such
+// as "drowssap"
+func reversedDictionaryMatches(password []rune, dicts []*dictionary) []*Match {
+	reversed := make([]rune, len(password))
+	for i, r := range password {
+		reversed[len(password)-1-i] = r
+	}
+
+	matches := dictionaryMatches(reversed, dicts)
+	for _, m := range matches {
+		m.I, m.J = len(password)-1-m.J, len(password)-1-m.I
+		m.Token = string(password[m.I : m.J+1])
+		m.Reversed = true
+	}
+	return matches
+}
+
+// repeatMatches finds runs of a repeated block, such as "aaa" or
+// "abcabc". At each position the longest run wins, and of runs of equal
+// length the one with the shortest block.
+func repeatMatches(password []rune, dicts []*dictionary) []*Match {
+	var matches []*Match
+	for i := 0; i < len(password)-1; {
+		end, size := -1, 0
+		for base := 1; i+2*base <= len(password); base++ {
+			repeats := 1
+			for i+(repeats+1)*base <= len(password) && equalRunes(password[i+repeats*base:i+(repeats+1)*base], password[i:i+base]) {
+				repeats++
+			}
+			if repeats >= 2 && i+repeats*base-1 > end {
+				end, size = i+repeats*base-1, base
+			}
+		}
+		if end < 0 {
+			i++
+			continue
+		}
+
+		// The block is scored like a password of its own
+		base := password[i : i+size]
+		matches = append(matches, &Match{
+			Pattern:     PatternRepeat,
+			I:           i,
+			J:           end,
+			Token:       string(password[i : end+1]),
+			BaseToken:   string(base),
+			BaseGuesses: mostGuessableSequence(base, omnimatch(base, dicts)).guesses,
+			Repeats:     (end - i + 1) / size,
+		})
+		i = end + 1
+	}
+	return matches
+}
+
+// sequenceMatches finds runs of characters with a constant step, such as
+// "abcd", "7531" or "zyx"
+func sequenceMatches(password []rune) []*Match {
+	var matches []*Match
+	add := func(i, j, delta int) {
+		if abs(delta) == 0 || abs(delta) > maxSequenceDelta {
+			return
+		}
+		// Two characters only count when adjacent, as in "ab"
+		if j-i > 1 || abs(delta) == 1 {
+			matches = append(matches, &Match{
+				Pattern:   PatternSequence,
+				I:         i,
+				J:         j,
+				Token:     string(password[i : j+1]),
+				Ascending: delta > 0,
+			})
+		}
+	}
+
+	if len(password) < 2 {
+		return nil
+	}
+	i, last := 0, int(password[1])-int(password[0])
+	for k := 2; k < len(password); k++ {
+		delta := int(password[k]) - int(password[k-1])
+		if delta == last {
+			continue
+		}
+		add(i, k-1, last)
+		i, last = k-1, delta
+	}
+	add(i, len(password)-1, last)
+
+	return matches
+}
+
+func equalRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
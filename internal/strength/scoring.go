@@ -0,0 +1,241 @@
+package strength
+
+import (
+	"math"
+	"unicode"
+)
+
+const (
+	// bruteforceCardinality is the number of characters assumed for
+	// parts of a password no matcher recognises
+	bruteforceCardinality = 10
+
+	// minGuessesBeforeGrowingSequence discourages splitting a password
+	// into more parts than needed, as an attacker tries few-part
+	// guesses first
+	minGuessesBeforeGrowingSequence = 10000
+
+	// Parts of a longer password are never guessed faster than these
+	minSubmatchGuessesSingleChar = 10
+	minSubmatchGuessesMultiChar  = 50
+)
+
+// optimum is the cheapest way found to guess a password, as a sequence of
+// non-overlapping matches covering it
+type optimum struct {
+	sequence []*Match
+	guesses  float64
+}
+
+// mostGuessableSequence finds the sequence of matches, filled out with
+// bruteforce, that an attacker guessing the cheapest patterns first would
+// need the fewest guesses for. Guessing a sequence of l parts costs l! times
+// the product of the parts' guesses, plus a penalty growing with l.
+func mostGuessableSequence(password []rune, matches []*Match) optimum {
+	n := len(password)
+	if n == 0 {
+		return optimum{guesses: 1}
+	}
+
+	matchesByEnd := make([][]*Match, n)
+	for _, m := range matches {
+		matchesByEnd[m.J] = append(matchesByEnd[m.J], m)
+	}
+
+	// For each end position k and sequence length l: the last match, the
+	// product of guesses and the overall guesses
+	last := make([]map[int]*Match, n)
+	product := make([]map[int]float64, n)
+	overall := make([]map[int]float64, n)
+	for k := range last {
+		last[k] = make(map[int]*Match)
+		product[k] = make(map[int]float64)
+		overall[k] = make(map[int]float64)
+	}
+
+	update := func(m *Match, l int) {
+		k := m.J
+		pi := estimateGuesses(m, n)
+		if l > 1 {
+			pi *= product[m.I-1][l-1]
+		}
+		g := factorial(l)*pi + math.Pow(minGuessesBeforeGrowingSequence, float64(l-1))
+
+		// Keep it unless a sequence at most as long is as cheap
+		for competingL, competingG := range overall[k] {
+			if competingL <= l && competingG <= g {
+				return
+			}
+		}
+		last[k][l], product[k][l], overall[k][l] = m, pi, g
+	}
+
+	bruteforceUpdate := func(k int) {
+		update(bruteforceMatch(password, 0, k), 1)
+		for i := 1; i <= k; i++ {
+			m := bruteforceMatch(password, i, k)
+			for l, previous := range last[i-1] {
+				// Adjacent bruteforce parts are one longer bruteforce part
+				if previous.Pattern == PatternBruteforce {
+					continue
+				}
+				update(m, l+1)
+			}
+		}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range matchesByEnd[k] {
+			if m.I > 0 {
+				for l := range last[m.I-1] {
+					update(m, l+1)
+				}
+			} else {
+				update(m, 1)
+			}
+		}
+		bruteforceUpdate(k)
+	}
+
+	// Walk back from the cheapest sequence covering the whole password
+	bestL, bestG := 0, math.Inf(1)
+	for l, g := range overall[n-1] {
+		if g < bestG || (g == bestG && l < bestL) {
+			bestL, bestG = l, g
+		}
+	}
+
+	sequence := make([]*Match, bestL)
+	for k, l := n-1, bestL; k >= 0; l-- {
+		m := last[k][l]
+		sequence[l-1] = m
+		k = m.I - 1
+	}
+
+	return optimum{sequence: sequence, guesses: bestG}
+}
+
+// bruteforceMatch covers password[i..j] with a bruteforce match
+func bruteforceMatch(password []rune, i, j int) *Match {
+	return &Match{Pattern: PatternBruteforce, I: i, J: j, Token: string(password[i : j+1])}
+}
+
+// estimateGuesses sets and returns the guesses needed for one match
+func estimateGuesses(m *Match, passwordLength int) float64 {
+	if m.Guesses != 0 {
+		return m.Guesses
+	}
+
+	minGuesses := 1.0
+	if m.length() < passwordLength {
+		minGuesses = minSubmatchGuessesMultiChar
+		if m.length() == 1 {
+			minGuesses = minSubmatchGuessesSingleChar
+		}
+	}
+
+	var guesses float64
+	switch m.Pattern {
+	case PatternDictionary:
+		guesses = float64(m.Rank) * uppercaseVariations(m.Token) * l33tVariations(m)
+		if m.Reversed {
+			guesses *= 2
+		}
+	case PatternRepeat:
+		guesses = m.BaseGuesses * float64(m.Repeats)
+	case PatternSequence:
+		guesses = sequenceGuesses(m)
+	case PatternSpatial:
+		guesses = spatialGuesses(m)
+	case PatternDate:
+		guesses = dateGuesses(m)
+	case PatternYear:
+		guesses = yearGuesses(m)
+	default:
+		guesses = bruteforceGuesses(m)
+	}
+
+	m.Guesses = math.Max(guesses, minGuesses)
+	return m.Guesses
+}
+
+func bruteforceGuesses(m *Match) float64 {
+	guesses := math.Pow(bruteforceCardinality, float64(m.length()))
+	// Leave room for the cheaper patterns tried first
+	if m.length() == 1 {
+		return math.Max(guesses, minSubmatchGuessesSingleChar+1)
+	}
+	return math.Max(guesses, minSubmatchGuessesMultiChar+1)
+}
+
+func sequenceGuesses(m *Match) float64 {
+	first := []rune(m.Token)[0]
+
+	var base float64
+	switch {
+	case first == 'a' || first == 'A' || first == 'z' || first == 'Z' || first == '0' || first == '1' || first == '9':
+		// Obvious starting points
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+	if !m.Ascending {
+		base *= 2
+	}
+	return base * float64(m.length())
+}
+
+// uppercaseVariations counts the ways a word could be capitalised as
+// often as the token is. Capitalising the first or last letter, or all of
+// them, is so common it only doubles the guesses.
+func uppercaseVariations(token string) float64 {
+	upper, lower := 0, 0
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	if lower == 0 {
+		return 2
+	}
+
+	runes := []rune(token)
+	first, last := unicode.IsUpper(runes[0]), unicode.IsUpper(runes[len(runes)-1])
+	if upper == 1 && (first || last) {
+		return 2
+	}
+
+	variations := 0.0
+	for i := 1; i <= min(upper, lower); i++ {
+		variations += binomial(upper+lower, i)
+	}
+	return variations
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
+
+func binomial(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+	r := 1.0
+	for d := 1; d <= k; d++ {
+		r *= float64(n - k + d)
+		r /= float64(d)
+	}
+	return r
+}
@@ -0,0 +1,210 @@
+package strength
+
+import (
+	"math"
+	"strings"
+	"sync"
+)
+
+// Keyboard layouts, one key per token with its unshifted and shifted
+// characters. Rows of the typewriter layouts are staggered by one space.
+const (
+	qwertyLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) -_ =+
+    qQ wW eE rR tT yY uU iI oO pP [{ ]} \|
+     aA sS dD fF gG hH jJ kK lL ;: '"
+      zZ xX cC vV bB nN mM ,< .> /?
+`
+	dvorakLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) [{ ]}
+    '" ,< .> pP yY fF gG cC rR lL /? =+ \|
+     aA oO eE uU iI dD hH tT nN sS -_
+      ;: qQ jJ kK xX bB mM wW vV zZ
+`
+	keypadLayout = `
+  / * -
+7 8 9 +
+4 5 6
+1 2 3
+  0 .
+`
+	macKeypadLayout = `
+  = / *
+7 8 9 -
+4 5 6 +
+1 2 3
+  0 .
+`
+)
+
+// Keyboard graph names, as reported in matches
+const (
+	GraphQwerty    = "qwerty"
+	GraphDvorak    = "dvorak"
+	GraphKeypad    = "keypad"
+	GraphMacKeypad = "mac_keypad"
+)
+
+// keyboardGraph maps each character to the keys around it, in a fixed
+// order of directions; an empty string marks the edge of the keyboard.
+// Characters after the first of a key are typed with shift.
+type keyboardGraph struct {
+	name          string
+	adjacent      map[rune][]string
+	shifted       map[rune]bool
+	keys          int
+	averageDegree float64
+}
+
+// newKeyboardGraph builds the graph of a layout. Typewriter layouts are
+// staggered, so a key touches six others; keypads are aligned and a key
+// touches eight.
+func newKeyboardGraph(name, layout string, staggered bool) *keyboardGraph {
+	type position struct{ x, y int }
+	keys := make(map[position]string)
+	width := len(strings.Fields(layout)[0]) + 1
+
+	for y, line := range strings.Split(layout, "\n") {
+		slant := 0
+		if staggered {
+			slant = y - 1
+		}
+		for x := 0; x < len(line); {
+			if line[x] == ' ' {
+				x++
+				continue
+			}
+			end := strings.IndexByte(line[x:], ' ')
+			if end < 0 {
+				end = len(line) - x
+			}
+			keys[position{(x - slant) / width, y}] = line[x : x+end]
+			x += end
+		}
+	}
+
+	directions := []position{{-1, 0}, {-1, -1}, {0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}}
+	if staggered {
+		directions = []position{{-1, 0}, {0, -1}, {1, -1}, {1, 0}, {0, 1}, {-1, 1}}
+	}
+
+	g := &keyboardGraph{name: name, adjacent: make(map[rune][]string), shifted: make(map[rune]bool)}
+	degrees := 0
+	for p, key := range keys {
+		neighbours := make([]string, len(directions))
+		for d, direction := range directions {
+			neighbours[d] = keys[position{p.x + direction.x, p.y + direction.y}]
+			if neighbours[d] != "" {
+				degrees++
+			}
+		}
+		for at, r := range []rune(key) {
+			g.adjacent[r] = neighbours
+			g.shifted[r] = at > 0
+		}
+	}
+	g.keys = len(g.adjacent)
+	g.averageDegree = float64(degrees) / float64(len(keys))
+	return g
+}
+
+var (
+	loadKeyboardGraphs sync.Once
+	keyboardGraphs     []*keyboardGraph
+)
+
+// graphs returns the keyboard graphs, built on first use
+func graphs() []*keyboardGraph {
+	loadKeyboardGraphs.Do(func() {
+		keyboardGraphs = []*keyboardGraph{
+			newKeyboardGraph(GraphQwerty, qwertyLayout, true),
+			newKeyboardGraph(GraphDvorak, dvorakLayout, true),
+			newKeyboardGraph(GraphKeypad, keypadLayout, false),
+			newKeyboardGraph(GraphMacKeypad, macKeypadLayout, false),
+		}
+	})
+	return keyboardGraphs
+}
+
+// spatialMatches finds runs of at least three neighbouring keys, such as
+// "qwerty", "zxcvb" or "7415963"
+func spatialMatches(password []rune) []*Match {
+	var matches []*Match
+	for _, g := range graphs() {
+		for i := 0; i < len(password)-1; {
+			j, turns, shifted, lastDirection := i+1, 0, 0, -1
+			if g.shifted[password[i]] {
+				shifted++
+			}
+
+			for ; j < len(password); j++ {
+				found := false
+				for direction, key := range g.adjacent[password[j-1]] {
+					at := strings.IndexRune(key, password[j])
+					if at < 0 {
+						continue
+					}
+					found = true
+					if at > 0 {
+						shifted++
+					}
+					if direction != lastDirection {
+						turns++
+						lastDirection = direction
+					}
+					break
+				}
+				if !found {
+					break
+				}
+			}
+
+			if j-i > 2 {
+				matches = append(matches, &Match{
+					Pattern: PatternSpatial,
+					I:       i,
+					J:       j - 1,
+					Token:   string(password[i:j]),
+					Graph:   g.name,
+					Turns:   turns,
+					Shifted: shifted,
+				})
+			}
+			i = j
+		}
+	}
+	return matches
+}
+
+// spatialGuesses counts the paths on the keyboard up to the token's
+// length with at most as many turns, times the ways of using shift
+func spatialGuesses(m *Match) float64 {
+	var g *keyboardGraph
+	for _, candidate := range graphs() {
+		if candidate.name == m.Graph {
+			g = candidate
+		}
+	}
+
+	length := m.length()
+	guesses := 0.0
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= min(m.Turns, i-1); j++ {
+			guesses += binomial(i-1, j-1) * float64(g.keys) * math.Pow(g.averageDegree, float64(j))
+		}
+	}
+
+	if m.Shifted > 0 {
+		shifted, unshifted := m.Shifted, length-m.Shifted
+		if unshifted == 0 {
+			guesses *= 2
+		} else {
+			variations := 0.0
+			for i := 1; i <= min(shifted, unshifted); i++ {
+				variations += binomial(shifted+unshifted, i)
+			}
+			guesses *= variations
+		}
+	}
+	return guesses
+}
@@ -0,0 +1,179 @@
+// Package strength estimates how many guesses an attacker needs for a
+// password, in the style of zxcvbn. A password is split into the parts an
+// attacker would try first (common passwords, dictionary words and names,
+// also reversed or with l33t substitutions, keyboard patterns, dates,
+// repeats and sequences, with everything else guessed by brute force) and
+// the cheapest combination of parts gives the estimate.
+package strength
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// maxAnalyzedLength bounds the work done for very long inputs; a password
+// this long is never guessable by brute force anyway
+const maxAnalyzedLength = 100
+
+// Score thresholds in guesses; scores run from 0 (too guessable) to 4
+var scoreThresholds = []float64{1e3 + 5, 1e6 + 5, 1e8 + 5, 1e10 + 5}
+
+// Labels name the scores
+var Labels = []string{"very weak", "weak", "fair", "strong", "very strong"}
+
+// Guessing rates of common attacks, in guesses per second
+const (
+	// OnlineThrottled is an online attack on a service limiting attempts
+	OnlineThrottled = 100.0 / 3600
+	// OnlineUnthrottled is an online attack on a service without limits
+	OnlineUnthrottled = 10.0
+	// OfflineSlowHash is an offline attack on a stolen database of
+	// passwords hashed with a slow function such as bcrypt or scrypt
+	OfflineSlowHash = 1e4
+	// OfflineFastHash is an offline attack on fast hashes such as SHA-1,
+	// with many GPUs
+	OfflineFastHash = 1e10
+)
+
+// Result is the estimate for a password
+type Result struct {
+	Guesses  float64
+	Score    int
+	Sequence []*Match
+	// Warning explains what makes a weak password guessable
+	Warning string
+}
+
+// Label names the score of the result
+func (r *Result) Label() string {
+	return Labels[r.Score]
+}
+
+// CrackTime returns how long guessing the password takes at a rate in
+// guesses per second, such as OfflineSlowHash, as "3 hours" or "centuries"
+func (r *Result) CrackTime(guessesPerSecond float64) string {
+	seconds := r.Guesses / guessesPerSecond
+
+	const (
+		minute  = 60
+		hour    = minute * 60
+		day     = hour * 24
+		month   = day * 31
+		year    = month * 12
+		century = year * 100
+	)
+	units := []struct {
+		name    string
+		seconds float64
+	}{
+		{"second", 1}, {"minute", minute}, {"hour", hour}, {"day", day}, {"month", month}, {"year", year},
+	}
+
+	switch {
+	case seconds < 1:
+		return "less than a second"
+	case seconds >= century:
+		return "centuries"
+	}
+	unit := units[0]
+	for _, u := range units[1:] {
+		if seconds >= u.seconds {
+			unit = u
+		}
+	}
+	n := math.Round(seconds / unit.seconds)
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit.name)
+	}
+	return fmt.Sprintf("%.0f %ss", n, unit.name)
+}
+
+// Estimate rates a password. User inputs, such as the account or user
+// name, are treated as the most likely words of all.
+func Estimate(password string, userInputs ...string) *Result {
+	runes := []rune(password)
+	if len(runes) > maxAnalyzedLength {
+		runes = runes[:maxAnalyzedLength]
+	}
+
+	dicts := rankedDictionaries()
+	if len(userInputs) > 0 {
+		dicts = append([]*dictionary{newDictionary(DictionaryUserInputs, userInputs)}, dicts...)
+	}
+
+	best := mostGuessableSequence(runes, omnimatch(runes, dicts))
+	result := &Result{Guesses: best.guesses, Sequence: best.sequence}
+	for _, threshold := range scoreThresholds {
+		if result.Guesses >= threshold {
+			result.Score++
+		}
+	}
+	result.Warning = warning(result)
+
+	return result
+}
+
+// warning describes the longest guessable part of a weak password
+func warning(result *Result) string {
+	if result.Score > 2 || len(result.Sequence) == 0 {
+		return ""
+	}
+
+	longest := result.Sequence[0]
+	for _, m := range result.Sequence[1:] {
+		if utf8.RuneCountInString(m.Token) > utf8.RuneCountInString(longest.Token) {
+			longest = m
+		}
+	}
+	sole := len(result.Sequence) == 1
+
+	switch longest.Pattern {
+	case PatternDictionary:
+		switch longest.Dictionary {
+		case DictionaryPasswords:
+			switch {
+			case sole && longest.Rank <= 10:
+				return "This is a top-10 common password"
+			case sole && longest.Rank <= 100:
+				return "This is a top-100 common password"
+			case sole:
+				return "This is a very common password"
+			case math.Log10(longest.Guesses) <= 4:
+				return "This is similar to a commonly used password"
+			}
+		case DictionaryEnglish:
+			if sole {
+				return "A word by itself is easy to guess"
+			}
+			if longest.Reversed || len(longest.L33t) > 0 {
+				return "Reversed words and predictable substitutions like '@' for 'a' don't help much"
+			}
+		case DictionaryNames, DictionarySurnames:
+			if sole {
+				return "Names and surnames by themselves are easy to guess"
+			}
+			return "Common names and surnames are easy to guess"
+		case DictionaryUserInputs:
+			return "Passwords containing the account or user name are easy to guess"
+		}
+	case PatternRepeat:
+		if utf8.RuneCountInString(longest.BaseToken) == 1 {
+			return `Repeats like "aaa" are easy to guess`
+		}
+		return `Repeats like "abcabcabc" are only slightly harder to guess than "abc"`
+	case PatternSequence:
+		return "Sequences like abc or 6543 are easy to guess"
+	case PatternSpatial:
+		if longest.Turns == 1 {
+			return "Straight rows of keys are easy to guess"
+		}
+		return "Short keyboard patterns are easy to guess"
+	case PatternDate:
+		return "Dates are often easy to guess"
+	case PatternYear:
+		return "Recent years are easy to guess"
+	}
+
+	return "Short passwords are easy to guess, add more words or characters"
+}
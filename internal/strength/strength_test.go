@@ -0,0 +1,83 @@
+package strength
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		password string
+		score    int
+		warning  string
+		// pattern:token of each part, in order
+		sequence string
+	}{
+		{"123", 0, "Sequences like abc or 6543 are easy to guess", "sequence:123"},
+		{"password", 0, "This is a top-10 common password", "dictionary:password"},
+		{"P@ssw0rd", 0, "This is a top-10 common password", "dictionary:P@ssw0rd"},
+		{"qwertyuiop", 1, "This is a very common password", "dictionary:qwertyuiop"},
+		{"19851231", 1, "Dates are often easy to guess", "date:19851231"},
+		{"alice1985", 1, "Common names and surnames are easy to guess", "dictionary:alice year:1985"},
+		{"correcthorsebatterystaple", 4, "", "dictionary:correct dictionary:horse dictionary:battery dictionary:staple"},
+		{"x7#Kq!9vLp2@Zm", 4, "", "bruteforce:x7#Kq!9vLp2@Zm"},
+	}
+
+	for _, tt := range tests {
+		result := Estimate(tt.password)
+		if result.Score != tt.score {
+			t.Errorf("%q: score %d (%g guesses), want %d", tt.password, result.Score, result.Guesses, tt.score)
+		}
+		if result.Warning != tt.warning {
+			t.Errorf("%q: warning %q, want %q", tt.password, result.Warning, tt.warning)
+		}
+		if got := sequence(result); got != tt.sequence {
+			t.Errorf("%q: sequence %q, want %q", tt.password, got, tt.sequence)
+		}
+	}
+}
+
+func TestEstimateUserInputs(t *testing.T) {
+	without := Estimate("acmecorp2024")
+	with := Estimate("acmecorp2024", "AcmeCorp", "alice@example.com")
+	if with.Guesses >= without.Guesses {
+		t.Errorf("user inputs left %g guesses, want fewer than %g", with.Guesses, without.Guesses)
+	}
+	if without.Score != 4 || with.Score != 1 {
+		t.Errorf("scores %d without and %d with user inputs, want 4 and 1", without.Score, with.Score)
+	}
+	if want := "Passwords containing the account or user name are easy to guess"; with.Warning != want {
+		t.Errorf("warning %q, want %q", with.Warning, want)
+	}
+
+	// Inputs that don't appear in the password change nothing
+	if other := Estimate("acmecorp2024", "globex"); other.Guesses != without.Guesses {
+		t.Errorf("unrelated user input: %g guesses, want %g", other.Guesses, without.Guesses)
+	}
+}
+
+func TestCrackTime(t *testing.T) {
+	tests := []struct {
+		guesses float64
+		want    string
+	}{
+		{0.5, "less than a second"},
+		{90, "2 minutes"},
+		{3600, "1 hour"},
+		{1e12, "centuries"},
+	}
+	for _, tt := range tests {
+		result := &Result{Guesses: tt.guesses}
+		if got := result.CrackTime(1); got != tt.want {
+			t.Errorf("CrackTime for %g guesses = %q, want %q", tt.guesses, got, tt.want)
+		}
+	}
+}
+
+func sequence(result *Result) string {
+	parts := make([]string, len(result.Sequence))
+	for i, m := range result.Sequence {
+		parts[i] = m.Pattern + ":" + m.Token
+	}
+	return strings.Join(parts, " ")
+}
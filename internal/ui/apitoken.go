@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"remembrall/internal/apiserver"
+	"remembrall/internal/auth"
+	"remembrall/internal/db"
+	"remembrall/pkg/models"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	apiTokenReadOnly bool
+	apiTokenTags     []string
+)
+
+var apiTokenCmd = &cobra.Command{
+	Use:   "api-token",
+	Short: "Manage tokens for the local API",
+	Long: `Create, list and revoke the bearer tokens clients of 'remembrall serve'
+authenticate with. Only a hash of each token is stored.`,
+}
+
+var apiTokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a token",
+	Long: `Create a token for a client. The token is shown once. --read-only
+forbids changes, and --tag limits the client to entries with one of the tags.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		token, err := createAPIToken(args[0])
+		if err != nil {
+			exitWithError("Failed to create token: %v", err)
+		}
+
+		fmt.Printf("✓ Token '%s' created. It will not be shown again:\n%s\n", args[0], token)
+	},
+}
+
+var apiTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tokens",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := listAPITokens(); err != nil {
+			exitWithError("Failed to list tokens: %v", err)
+		}
+	},
+}
+
+var apiTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revoke a token",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := withTokenStore(func(store *db.SQLiteStore) error {
+			return store.DeleteToken(args[0])
+		}); err != nil {
+			exitWithError("Failed to revoke token: %v", err)
+		}
+
+		fmt.Printf("✓ Token '%s' revoked\n", args[0])
+	},
+}
+
+func createAPIToken(name string) (string, error) {
+	token, hash, err := apiserver.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = withTokenStore(func(store *db.SQLiteStore) error {
+		return store.SaveToken(&models.APIToken{
+			Name:      name,
+			TokenHash: hash,
+			ReadOnly:  apiTokenReadOnly,
+			Tags:      apiTokenTags,
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func listAPITokens() error {
+	return withTokenStore(func(store *db.SQLiteStore) error {
+		tokens, err := store.ListTokens()
+		if err != nil {
+			return err
+		}
+
+		if len(tokens) == 0 {
+			fmt.Println("No API tokens yet.")
+			fmt.Println("Use 'remembrall api-token create <name>' to create one.")
+			return nil
+		}
+
+		for _, token := range tokens {
+			access := "read-write"
+			if token.ReadOnly {
+				access = "read-only"
+			}
+			scope := "all entries"
+			if len(token.Tags) > 0 {
+				scope = "tags: " + strings.Join(token.Tags, ", ")
+			}
+			fmt.Printf("  • %-20s %-10s %-30s (created: %s)\n", token.Name, access, scope, token.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		return nil
+	})
+}
+
+// withTokenStore verifies the master password before letting fn manage tokens
+func withTokenStore(fn func(store *db.SQLiteStore) error) error {
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	if _, err := masterMgr.PromptAndVerifyMasterPassword(); err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	return fn(store)
+}
+
+func init() {
+	apiTokenCreateCmd.Flags().BoolVar(&apiTokenReadOnly, "read-only", false, "forbid creating, updating and deleting entries")
+	apiTokenCreateCmd.Flags().StringArrayVar(&apiTokenTags, "tag", nil, "only allow entries with this tag (repeatable)")
+
+	apiTokenCmd.AddCommand(apiTokenCreateCmd)
+	apiTokenCmd.AddCommand(apiTokenListCmd)
+	apiTokenCmd.AddCommand(apiTokenRevokeCmd)
+	rootCmd.AddCommand(apiTokenCmd)
+}
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/prompts"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// promptRulesFile maps prompts of other programs to entries
+	promptRulesFile = ".remembrall-prompts"
+	// askpassHelperName runs askpass when the binary is linked under this name
+	askpassHelperName = "remembrall-askpass"
+)
+
+var askpassCmd = &cobra.Command{
+	Use:   "askpass <prompt>",
+	Short: "SSH_ASKPASS / SUDO_ASKPASS program backed by the vault",
+	Long: `Answer a password prompt from another program with a secret from the
+vault, for use as SSH_ASKPASS or SUDO_ASKPASS. These variables name a
+program without arguments, so link the binary as ` + askpassHelperName + `:
+
+  ln -s "$(command -v remembrall)" ~/bin/` + askpassHelperName + `
+  export SUDO_ASKPASS=~/bin/` + askpassHelperName + ` SSH_ASKPASS=~/bin/` + askpassHelperName + `
+
+The prompt is matched against the rules in ~/` + promptRulesFile + ` (or
+$REMEMBRALL_PROMPT_RULES), one per line: an entry reference followed by a
+regular expression, e.g.
+
+  sudo-password        ^\[sudo\] password for
+  ssh/$1:passphrase    Enter passphrase for key '.*/([^/']+)'
+
+Entry names must match exactly. Yes/no confirmations are always declined, so
+the SSH agent and the browser host skip SSH_ASKPASS when it points at this
+binary and confirm through their terminal or a pinentry instead. The command
+never prompts: run 'remembrall unlock' first.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// ssh asks askpass to confirm key use or show messages this way
+		switch os.Getenv("SSH_ASKPASS_PROMPT") {
+		case "confirm":
+			exitWithError("askpass: confirmations are not answered from the vault")
+		case "none":
+			return
+		}
+
+		prompt := ""
+		if len(args) == 1 {
+			prompt = args[0]
+		}
+
+		secret, err := promptSecret(prompt)
+		if err != nil {
+			exitWithError("askpass: %v", err)
+		}
+		fmt.Println(secret)
+	},
+}
+
+// loadPromptRules reads the rules mapping prompts to entries
+func loadPromptRules() ([]prompts.Rule, error) {
+	path := os.Getenv("REMEMBRALL_PROMPT_RULES")
+	if path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, promptRulesFile)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no prompt rules, create '%s'", path)
+		}
+		return nil, fmt.Errorf("failed to open '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	rules, err := prompts.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt rules in '%s': %w", path, err)
+	}
+	return rules, nil
+}
+
+// promptSecret decrypts the secret for the first text matching a prompt
+// rule, using the master password held by the unlock agent
+func promptSecret(texts ...string) (string, error) {
+	rules, err := loadPromptRules()
+	if err != nil {
+		return "", err
+	}
+
+	ref, ok := "", false
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		if ref, ok = prompts.Match(rules, text); ok {
+			break
+		}
+	}
+	if !ok {
+		return "", fmt.Errorf("no prompt rule matches %q", texts)
+	}
+
+	// Fetch the master password without prompting
+	masterPassword, err := unlockedMasterPassword()
+	if err != nil {
+		return "", err
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	return newResolver(store, crypto.NewEncryptor(masterPassword)).resolve(ref)
+}
+
+// confirmWithAskpass asks a yes/no question through the SSH_ASKPASS program,
+// as ssh-agent does. ok is false when there is no program to ask: none is
+// set, or it is this binary, which declines every confirmation.
+func confirmWithAskpass(prompt string) (allowed, ok bool) {
+	askpass := os.Getenv("SSH_ASKPASS")
+	if askpass == "" || isOwnBinary(askpass) {
+		return false, false
+	}
+
+	cmd := exec.Command(askpass, prompt)
+	cmd.Env = append(os.Environ(), "SSH_ASKPASS_PROMPT=confirm")
+	return cmd.Run() == nil, true
+}
+
+// isOwnBinary reports whether a program is this binary, under any name
+func isOwnBinary(program string) bool {
+	if filepath.Base(program) == askpassHelperName {
+		return true
+	}
+
+	path, err := exec.LookPath(program)
+	if err != nil {
+		return false
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	programInfo, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	selfInfo, err := os.Stat(self)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(programInfo, selfInfo)
+}
+
+func init() {
+	rootCmd.AddCommand(askpassCmd)
+}
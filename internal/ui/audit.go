@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditSince  string
+	auditApp    string
+	auditAction string
+)
+
+// auditCommand names the running command in audit events, such as
+// "lookup" or "git-credential get"
+var auditCommand string
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Review access to the vault",
+	Long: `Review the audit log, which records every time a secret is read, saved,
+updated, deleted or exported and every failed unlock. Events are encrypted,
+so reading them needs the master password, and chained by hashes, so edits
+and truncation are detected by 'remembrall audit verify'.`,
+}
+
+var auditLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show recorded vault access",
+	Long: `Show the audit log, oldest event first. You will be prompted to enter
+your master password.
+
+--since takes a duration such as 12h or 7d, or a date (2006-01-02).
+--action is one of: ` + strings.Join(audit.Actions, ", ") + `.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := showAuditLog(); err != nil {
+			exitWithError("Failed to show audit log: %v", err)
+		}
+	},
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the audit log for tampering",
+	Long: `Check that the audit log is an unbroken hash chain ending at the record
+the vault expects, which detects edited, removed and truncated records.
+The master password is not needed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := audit.Verify()
+		if err != nil {
+			exitWithError("Audit log verification failed after %d records: %v", count, err)
+		}
+
+		fmt.Printf("✓ Audit log intact, %d records verified\n", count)
+	},
+}
+
+func showAuditLog() error {
+	var since time.Time
+	if auditSince != "" {
+		var err error
+		if since, err = parseSince(auditSince); err != nil {
+			return err
+		}
+	}
+	if auditAction != "" && !slices.Contains(audit.Actions, auditAction) {
+		return fmt.Errorf("unknown action '%s', expected one of: %s", auditAction, strings.Join(audit.Actions, ", "))
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	entries, err := audit.Read(masterPassword)
+	if err != nil {
+		return err
+	}
+
+	shown := 0
+	for _, entry := range entries {
+		if entry.Time.Before(since) ||
+			(auditApp != "" && !strings.EqualFold(entry.App, auditApp)) ||
+			(auditAction != "" && entry.Action != auditAction) {
+			continue
+		}
+
+		app := entry.App
+		if app == "" {
+			app = "-"
+		}
+		fmt.Printf("  %s  %-13s %-25s %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Action, app, entry.Detail)
+		shown++
+	}
+
+	if shown == 0 {
+		fmt.Println("No matching audit events")
+	}
+	return nil
+}
+
+// parseSince reads a duration back from now, which may be given in days
+// (7d), or a date
+func parseSince(value string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since '%s', expected a duration such as 7d or a date such as 2006-01-02", value)
+}
+
+// recordAccess audits an access to an entry by the running command. Reads
+// are recorded before the secret is handed out, so nothing is revealed
+// when the event can't be written.
+func recordAccess(action, appName string) error {
+	if err := audit.Record(action, appName, auditCommand); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	auditLogCmd.Flags().StringVar(&auditSince, "since", "", "only show events after this duration ago or date")
+	auditLogCmd.Flags().StringVar(&auditApp, "app", "", "only show events for this entry")
+	auditLogCmd.Flags().StringVar(&auditAction, "action", "", "only show events of this action")
+	auditCmd.AddCommand(auditLogCmd)
+	auditCmd.AddCommand(auditVerifyCmd)
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/hibp"
+	"remembrall/pkg/models"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	hibpFile     string
+	hibpFilter   string
+	buildFilter  string
+	filterFPRate float64
+)
+
+var auditBreachedCmd = &cobra.Command{
+	Use:   "breached",
+	Short: "Find passwords that appear in known breaches",
+	Long: `Check every stored password against a local copy of the Have I Been Pwned
+"Pwned Passwords" list, so no password or hash leaves this machine. Download
+the SHA-1 edition ordered by hash and pass it with --hibp-file; each breached
+password is reported with how often it was seen. You will be prompted to
+enter your master password.
+
+The list is tens of gigabytes, so for repeated checks build a compact filter
+from it once and check against that instead:
+
+  remembrall audit breached --hibp-file pwned-passwords-sha1-ordered-by-hash.txt --build-filter pwned.bloom
+  remembrall audit breached --filter pwned.bloom
+
+The filter never misses a breached password but also flags a small share of
+others (1% by default, see --fp-rate); it has no counts. Given both, the
+filter skips most lookups and the list confirms the matches.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if buildFilter != "" {
+			if err := buildBreachFilter(); err != nil {
+				exitWithError("Failed to build filter: %v", err)
+			}
+			return
+		}
+
+		report, err := auditBreached()
+		if err != nil {
+			exitWithError("Failed to check breached passwords: %v", err)
+		}
+
+		if auditJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				exitWithError("Failed to check breached passwords: %v", err)
+			}
+			return
+		}
+		printBreachReport(report)
+	},
+}
+
+// breachReport is the result of 'audit breached'
+type breachReport struct {
+	Checked  int             `json:"checked"`
+	Breached []breachedEntry `json:"breached"`
+}
+
+type breachedEntry struct {
+	Entry string `json:"entry"`
+	// Count is how often the password was seen, zero when only the filter
+	// was checked
+	Count int `json:"count,omitempty"`
+}
+
+func buildBreachFilter() error {
+	if hibpFile == "" {
+		return fmt.Errorf("--build-filter needs the list to build from, pass it with --hibp-file")
+	}
+
+	corpus, err := hibp.OpenCorpus(hibpFile)
+	if err != nil {
+		return err
+	}
+	defer corpus.Close()
+
+	fmt.Fprintf(os.Stderr, "Building filter from '%s'...\n", hibpFile)
+	stats, err := hibp.BuildFilter(corpus, buildFilter, filterFPRate)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Filter of %d hashes written to '%s' (%.1f MB, %.2g%% false positives)\n",
+		stats.Hashes, buildFilter, float64(stats.Bytes)/(1<<20), stats.FalsePositiveRate*100)
+	return nil
+}
+
+func auditBreached() (*breachReport, error) {
+	if hibpFile == "" && hibpFilter == "" {
+		return nil, fmt.Errorf("pass the Pwned Passwords list with --hibp-file or a filter built from it with --filter")
+	}
+
+	// Open the sources before prompting, so a wrong path fails fast
+	var corpus *hibp.Corpus
+	if hibpFile != "" {
+		var err error
+		if corpus, err = hibp.OpenCorpus(hibpFile); err != nil {
+			return nil, err
+		}
+		defer corpus.Close()
+	}
+	var filter *hibp.Filter
+	if hibpFilter != "" {
+		var err error
+		if filter, err = hibp.OpenFilter(hibpFilter); err != nil {
+			return nil, err
+		}
+		defer filter.Close()
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return nil, fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	stored, err := store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+	var logins []*models.PasswordEntry
+	for _, entry := range stored {
+		if entry.Type == models.EntryTypePassword {
+			logins = append(logins, entry)
+		}
+	}
+	entries, err := decryptEntries(crypto.NewEncryptor(masterPassword), logins)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if err := recordAccess(audit.ActionGet, entry.AppName); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &breachReport{Breached: []breachedEntry{}}
+	for _, entry := range entries {
+		if entry.Password == "" {
+			continue
+		}
+		report.Checked++
+
+		sum := hibp.Sum(entry.Password)
+		if filter != nil && !filter.Contains(sum) {
+			continue
+		}
+		if corpus == nil {
+			report.Breached = append(report.Breached, breachedEntry{Entry: entry.AppName})
+			continue
+		}
+
+		count, err := corpus.Count(sum)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			report.Breached = append(report.Breached, breachedEntry{Entry: entry.AppName, Count: count})
+		}
+	}
+
+	return report, nil
+}
+
+func printBreachReport(report *breachReport) {
+	if len(report.Breached) == 0 {
+		fmt.Printf("✓ None of %d passwords appear in known breaches\n", report.Checked)
+		return
+	}
+
+	fmt.Printf("Breached passwords (%d of %d):\n", len(report.Breached), report.Checked)
+	for _, breached := range report.Breached {
+		if breached.Count > 0 {
+			fmt.Printf("  • %-30s seen %d times\n", breached.Entry, breached.Count)
+		} else {
+			fmt.Printf("  • %-30s probably breached (filter match)\n", breached.Entry)
+		}
+	}
+	fmt.Println("\nChange these passwords with 'remembrall update <app-name>'.")
+}
+
+func init() {
+	auditBreachedCmd.Flags().StringVar(&hibpFile, "hibp-file", "", "Pwned Passwords SHA-1 list, ordered by hash")
+	auditBreachedCmd.Flags().StringVar(&hibpFilter, "filter", "", "filter built with --build-filter")
+	auditBreachedCmd.Flags().StringVar(&buildFilter, "build-filter", "", "build a filter from --hibp-file at this path and exit")
+	auditBreachedCmd.Flags().Float64Var(&filterFPRate, "fp-rate", 0.01, "false positive rate of a built filter")
+	auditBreachedCmd.Flags().BoolVar(&auditJSON, "json", false, "print the report as JSON")
+	auditCmd.AddCommand(auditBreachedCmd)
+}
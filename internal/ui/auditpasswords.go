@@ -0,0 +1,229 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/strength"
+	"remembrall/pkg/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditMaxAge int
+	auditJSON   bool
+)
+
+var auditPasswordsCmd = &cobra.Command{
+	Use:   "passwords",
+	Short: "Report reused, weak, old and unprotected passwords",
+	Long: `Check every stored password and report:
+
+  • passwords shared by several entries (the passwords are never shown)
+  • weak passwords, rated by how many guesses they would take
+  • passwords not changed in --max-age days
+  • entries without a two-factor (OTP) secret
+
+You will be prompted to enter your master password. With --json the report
+is printed as JSON for dashboards and scripts.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		report, err := auditPasswords()
+		if err != nil {
+			exitWithError("Failed to audit passwords: %v", err)
+		}
+
+		if auditJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				exitWithError("Failed to audit passwords: %v", err)
+			}
+			return
+		}
+		printPasswordReport(report)
+	},
+}
+
+// passwordReport is the result of 'audit passwords'
+type passwordReport struct {
+	Checked    int             `json:"checked"`
+	Reused     [][]string      `json:"reused"`
+	Weak       []weakPassword  `json:"weak"`
+	Stale      []stalePassword `json:"stale"`
+	MaxAgeDays int             `json:"max_age_days"`
+	Missing2FA []string        `json:"missing_2fa"`
+}
+
+type weakPassword struct {
+	Entry   string `json:"entry"`
+	Score   int    `json:"score"`
+	Label   string `json:"label"`
+	Warning string `json:"warning,omitempty"`
+}
+
+type stalePassword struct {
+	Entry     string    `json:"entry"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Days      int       `json:"days"`
+}
+
+func auditPasswords() (*passwordReport, error) {
+	if auditMaxAge < 1 {
+		return nil, fmt.Errorf("--max-age must be at least 1 day")
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return nil, fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	stored, err := store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+
+	// Only login passwords are checked, not SSH keys or OTP-only entries
+	var logins []*models.PasswordEntry
+	for _, entry := range stored {
+		if entry.Type == models.EntryTypePassword {
+			logins = append(logins, entry)
+		}
+	}
+	entries, err := decryptEntries(crypto.NewEncryptor(masterPassword), logins)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if err := recordAccess(audit.ActionGet, entry.AppName); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &passwordReport{
+		Reused:     [][]string{},
+		Weak:       []weakPassword{},
+		Stale:      []stalePassword{},
+		MaxAgeDays: auditMaxAge,
+		Missing2FA: []string{},
+	}
+	byPassword := make(map[string][]string)
+	now := time.Now()
+
+	for _, entry := range entries {
+		if entry.Password == "" {
+			continue
+		}
+		report.Checked++
+		byPassword[entry.Password] = append(byPassword[entry.Password], entry.AppName)
+
+		result := strength.Estimate(entry.Password, entryInputs(entry)...)
+		if result.Score <= 2 {
+			report.Weak = append(report.Weak, weakPassword{
+				Entry:   entry.AppName,
+				Score:   result.Score,
+				Label:   result.Label(),
+				Warning: result.Warning,
+			})
+		}
+
+		if days := int(now.Sub(entry.UpdatedAt).Hours() / 24); days >= auditMaxAge {
+			report.Stale = append(report.Stale, stalePassword{Entry: entry.AppName, UpdatedAt: entry.UpdatedAt, Days: days})
+		}
+
+		if entry.Fields[models.FieldOTP] == "" {
+			report.Missing2FA = append(report.Missing2FA, entry.AppName)
+		}
+	}
+
+	for _, names := range byPassword {
+		if len(names) > 1 {
+			sort.Strings(names)
+			report.Reused = append(report.Reused, names)
+		}
+	}
+	sort.Slice(report.Reused, func(i, j int) bool {
+		if len(report.Reused[i]) != len(report.Reused[j]) {
+			return len(report.Reused[i]) > len(report.Reused[j])
+		}
+		return report.Reused[i][0] < report.Reused[j][0]
+	})
+	sort.SliceStable(report.Weak, func(i, j int) bool { return report.Weak[i].Score < report.Weak[j].Score })
+	sort.SliceStable(report.Stale, func(i, j int) bool { return report.Stale[i].Days > report.Stale[j].Days })
+
+	return report, nil
+}
+
+// entryInputs lists the words of an entry's name and username, which make
+// poor passwords for that entry
+func entryInputs(entry *plainEntry) []string {
+	return auth.StrengthInputs(entry.AppName, entry.Fields[models.FieldUsername])
+}
+
+func printPasswordReport(report *passwordReport) {
+	fmt.Printf("Checked %d passwords\n\n", report.Checked)
+
+	if len(report.Reused) == 0 {
+		fmt.Println("✓ No reused passwords")
+	} else {
+		fmt.Println("Reused passwords:")
+		for _, names := range report.Reused {
+			fmt.Printf("  • %s\n", strings.Join(names, ", "))
+		}
+	}
+
+	if len(report.Weak) == 0 {
+		fmt.Println("✓ No weak passwords")
+	} else {
+		fmt.Printf("Weak passwords (%d):\n", len(report.Weak))
+		for _, weak := range report.Weak {
+			fmt.Printf("  • %-30s %-10s %s\n", weak.Entry, weak.Label, weak.Warning)
+		}
+	}
+
+	if len(report.Stale) == 0 {
+		fmt.Printf("✓ All passwords changed in the last %d days\n", report.MaxAgeDays)
+	} else {
+		fmt.Printf("Not changed in %d days (%d):\n", report.MaxAgeDays, len(report.Stale))
+		for _, stale := range report.Stale {
+			fmt.Printf("  • %-30s last changed %s (%d days ago)\n", stale.Entry, stale.UpdatedAt.Format("2006-01-02"), stale.Days)
+		}
+	}
+
+	if len(report.Missing2FA) == 0 {
+		fmt.Println("✓ All entries have two-factor authentication")
+	} else {
+		fmt.Printf("Without two-factor authentication (%d):\n", len(report.Missing2FA))
+		for _, name := range report.Missing2FA {
+			fmt.Printf("  • %s\n", name)
+		}
+	}
+}
+
+func init() {
+	auditPasswordsCmd.Flags().IntVar(&auditMaxAge, "max-age", 365, "report passwords not changed in this many days")
+	auditPasswordsCmd.Flags().BoolVar(&auditJSON, "json", false, "print the report as JSON")
+	auditCmd.AddCommand(auditPasswordsCmd)
+}
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/pkg/models"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Entry fields read by aws-credentials
+const (
+	fieldAWSAccessKeyID     = "access_key_id"
+	fieldAWSSecretAccessKey = "secret_access_key"
+	fieldAWSSessionToken    = "session_token"
+	fieldExpiration         = "expiration"
+)
+
+// awsCredentials is the output format of an AWS credential_process
+type awsCredentials struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken,omitempty"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+var awsCredentialsCmd = &cobra.Command{
+	Use:   "aws-credentials <app-name>",
+	Short: "Print AWS credential_process JSON for an entry",
+	Long: `Print the credentials stored in an entry in the JSON format of the AWS
+credential_process setting. Add to ~/.aws/config:
+
+  [profile prod]
+  credential_process = remembrall aws-credentials aws-prod
+
+The access key ID comes from the '` + fieldAWSAccessKeyID + `' field (or 'username'), the
+secret access key from '` + fieldAWSSecretAccessKey + `' (or the password), and the optional
+'` + fieldAWSSessionToken + `' and '` + fieldExpiration + `' (RFC 3339) fields are passed through.
+
+The entry name must match exactly. The command never prompts: run
+'remembrall unlock' first.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printAWSCredentials(args[0]); err != nil {
+			exitWithError("Failed to get AWS credentials: %v", err)
+		}
+	},
+}
+
+func printAWSCredentials(appName string) error {
+	entry, err := unlockedEntry(appName)
+	if err != nil {
+		return err
+	}
+
+	creds := awsCredentials{
+		Version:         1,
+		AccessKeyID:     firstField(entry, fieldAWSAccessKeyID, models.FieldUsername),
+		SecretAccessKey: entry.Fields[fieldAWSSecretAccessKey],
+		SessionToken:    entry.Fields[fieldAWSSessionToken],
+	}
+	if creds.SecretAccessKey == "" {
+		creds.SecretAccessKey = entry.Password
+	}
+	if creds.AccessKeyID == "" {
+		return fmt.Errorf("entry '%s' has no '%s' or 'username' field", appName, fieldAWSAccessKeyID)
+	}
+
+	if creds.Expiration, err = entryExpiration(entry); err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(creds)
+}
+
+// unlockedEntry decrypts the entry with exactly this name using the master
+// password held by the unlock agent
+func unlockedEntry(appName string) (*plainEntry, error) {
+	// Fetch the master password without prompting
+	masterPassword, err := unlockedMasterPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	return newResolver(store, crypto.NewEncryptor(masterPassword)).entry(appName)
+}
+
+// firstField returns the first non-empty field among keys
+func firstField(entry *plainEntry, keys ...string) string {
+	for _, key := range keys {
+		if value := entry.Fields[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// entryExpiration validates the optional expiration field, refusing
+// credentials that have already expired
+func entryExpiration(entry *plainEntry) (string, error) {
+	value := entry.Fields[fieldExpiration]
+	if value == "" {
+		return "", nil
+	}
+
+	expiration, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("entry '%s' has an invalid '%s' field, expected RFC 3339: %w", entry.AppName, fieldExpiration, err)
+	}
+	if time.Now().After(expiration) {
+		return "", fmt.Errorf("credentials in '%s' expired at %s", entry.AppName, value)
+	}
+
+	return expiration.UTC().Format(time.RFC3339), nil
+}
+
+func init() {
+	rootCmd.AddCommand(awsCredentialsCmd)
+}
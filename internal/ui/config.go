@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"fmt"
+	"remembrall/internal/auth"
+	"remembrall/internal/db"
+	"remembrall/pkg/models"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// vaultSetting describes a setting that 'config' accepts
+type vaultSetting struct {
+	description  string
+	defaultValue string
+	validate     func(value string) error
+}
+
+var vaultSettings = map[string]vaultSetting{
+	models.SettingHistoryLimit: {
+		description:  "previous passwords kept per entry (0 keeps none)",
+		defaultValue: strconv.Itoa(models.DefaultHistoryLimit),
+		validate:     validateCount,
+	},
+	models.SettingMinStrength: {
+		description:  "lowest strength of new passwords, 0 (any) to 4 (very strong)",
+		defaultValue: strconv.Itoa(models.DefaultMinStrength),
+		validate:     validateScore,
+	},
+}
+
+// validateCount accepts whole numbers from zero
+func validateCount(value string) error {
+	if n, err := strconv.Atoi(value); err != nil || n < 0 {
+		return fmt.Errorf("expected a whole number of 0 or more")
+	}
+	return nil
+}
+
+// validateScore accepts password strength scores
+func validateScore(value string) error {
+	if n, err := strconv.Atoi(value); err != nil || n < 0 || n > 4 {
+		return fmt.Errorf("expected a score from 0 to 4")
+	}
+	return nil
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or change vault settings",
+	Long: `Show or change the settings stored in the vault, such as how many previous
+passwords are kept. You will be prompted to enter your master password.`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all settings with their values",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := withSettingsStore(func(store *db.SQLiteStore) error {
+			values, err := store.Settings()
+			if err != nil {
+				return err
+			}
+
+			keys := make([]string, 0, len(vaultSettings))
+			for key := range vaultSettings {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				setting := vaultSettings[key]
+				value, ok := values[key]
+				if !ok {
+					value = setting.defaultValue + " (default)"
+				}
+				fmt.Printf("  • %-22s %-15s %s\n", key, value, setting.description)
+			}
+			return nil
+		})
+		if err != nil {
+			exitWithError("Failed to list settings: %v", err)
+		}
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a setting",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := withSettingsStore(func(store *db.SQLiteStore) error {
+			setting, err := lookupSetting(args[0])
+			if err != nil {
+				return err
+			}
+
+			value, ok, err := store.GetSetting(args[0])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				value = setting.defaultValue
+			}
+			fmt.Println(value)
+			return nil
+		})
+		if err != nil {
+			exitWithError("Failed to get setting: %v", err)
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, value := args[0], args[1]
+		setting, err := lookupSetting(key)
+		if err != nil {
+			exitWithError("Failed to set setting: %v", err)
+		}
+		if err := setting.validate(value); err != nil {
+			exitWithError("Failed to set setting: invalid value for %s: %v", key, err)
+		}
+
+		err = withSettingsStore(func(store *db.SQLiteStore) error {
+			return store.SetSetting(key, value)
+		})
+		if err != nil {
+			exitWithError("Failed to set setting: %v", err)
+		}
+
+		fmt.Printf("✓ %s set to %s\n", key, value)
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Restore the default of a setting",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setting, err := lookupSetting(args[0])
+		if err != nil {
+			exitWithError("Failed to unset setting: %v", err)
+		}
+
+		err = withSettingsStore(func(store *db.SQLiteStore) error {
+			return store.UnsetSetting(args[0])
+		})
+		if err != nil {
+			exitWithError("Failed to unset setting: %v", err)
+		}
+
+		fmt.Printf("✓ %s restored to the default of %s\n", args[0], setting.defaultValue)
+	},
+}
+
+// lookupSetting returns a known setting, or an error listing them
+func lookupSetting(key string) (vaultSetting, error) {
+	setting, ok := vaultSettings[key]
+	if !ok {
+		return vaultSetting{}, fmt.Errorf("unknown setting '%s', see 'remembrall config list'", key)
+	}
+	return setting, nil
+}
+
+// withSettingsStore verifies the master password before letting fn read or
+// change settings
+func withSettingsStore(fn func(store *db.SQLiteStore) error) error {
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	if _, err := masterMgr.PromptAndVerifyMasterPassword(); err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	return fn(store)
+}
+
+func init() {
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	rootCmd.AddCommand(configCmd)
+}
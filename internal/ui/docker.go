@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"remembrall/internal/audit"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/dockercredential"
+	"remembrall/internal/hooks"
+	"remembrall/pkg/models"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// dockerCredentialTag marks registry logins, which 'list' hides by default
+	dockerCredentialTag = "docker-credential"
+	// dockerEntryPrefix namespaces registry logins, e.g. "docker/ghcr.io"
+	dockerEntryPrefix = "docker/"
+	// dockerHelperName is the program docker runs for "credsStore": "remembrall"
+	dockerHelperName = "docker-credential-remembrall"
+)
+
+var dockerCredentialCmd = &cobra.Command{
+	Use:   "docker-credential <store|get|erase|list>",
+	Short: "Docker credential helper backed by the vault",
+	Long: `Act as a docker credential helper. Link the binary as
+` + dockerHelperName + ` somewhere on your PATH and set
+"credsStore": "remembrall" in ~/.docker/config.json:
+
+  ln -s "$(command -v remembrall)" ~/bin/` + dockerHelperName + `
+
+Registry logins are stored as 'docker/<server>' entries tagged
+'` + dockerCredentialTag + `', and are hidden from 'remembrall list' unless --all is given.
+
+The helper never prompts: run 'remembrall unlock' first.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Docker reads the error message from stdout, where Serve writes it
+		if err := dockerCredential(args[0]); err != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+func dockerCredential(action string) error {
+	// Fetch the master password without prompting
+	masterPassword, err := unlockedMasterPassword()
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		err = fmt.Errorf("failed to initialize database: %w", err)
+		fmt.Println(err)
+		return err
+	}
+	defer store.Close()
+
+	helper := &vaultDockerHelper{store: store, encryptor: crypto.NewEncryptor(masterPassword)}
+	return dockercredential.Serve(helper, action, os.Stdin, os.Stdout)
+}
+
+// vaultDockerHelper keeps registry logins in the vault
+type vaultDockerHelper struct {
+	store     models.PasswordStore
+	encryptor *crypto.Encryptor
+}
+
+// Add saves a registry login. An existing login for the server is updated
+// in place so that its history, folder and rotation settings are kept.
+func (h *vaultDockerHelper) Add(creds *dockercredential.Credentials) error {
+	existing, err := h.find(creds.ServerURL)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return h.update(existing, creds)
+	}
+
+	encryptedPassword, err := h.encryptor.Encrypt(creds.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt password: %w", err)
+	}
+	encryptedFields, err := h.encryptor.EncryptFields(map[string]string{
+		models.FieldUsername: creds.Username,
+		models.FieldURL:      creds.ServerURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encrypt fields: %w", err)
+	}
+
+	appName := dockerEntryPrefix + creds.ServerURL
+	if err := hooks.Pre(hooks.EventSave, appName); err != nil {
+		return err
+	}
+	err = h.store.SaveEntry(&models.PasswordEntry{
+		AppName:  appName,
+		Password: encryptedPassword,
+		Fields:   encryptedFields,
+		Tags:     []string{dockerCredentialTag},
+	})
+	if err != nil {
+		return err
+	}
+	if err := recordAccess(audit.ActionSave, appName); err != nil {
+		return err
+	}
+	postHook(hooks.EventSave, appName)
+	return nil
+}
+
+// update replaces the username and secret of an existing registry login
+func (h *vaultDockerHelper) update(entry *models.PasswordEntry, creds *dockercredential.Credentials) error {
+	secret, err := h.encryptor.Decrypt(entry.Password)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	fields, err := h.encryptor.DecryptFields(entry.Fields)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt fields: %w", err)
+	}
+
+	// Keep the stored ciphertext when docker logs in again with the same
+	// secret, so the history doesn't fill up with copies of it
+	if secret != creds.Secret {
+		if entry.Password, err = h.encryptor.Encrypt(creds.Secret); err != nil {
+			return fmt.Errorf("failed to encrypt password: %w", err)
+		}
+	}
+	fields[models.FieldUsername] = creds.Username
+	if entry.Fields, err = h.encryptor.EncryptFields(fields); err != nil {
+		return fmt.Errorf("failed to encrypt fields: %w", err)
+	}
+
+	if err := hooks.Pre(hooks.EventUpdate, entry.AppName); err != nil {
+		return err
+	}
+	if err := h.store.UpdateEntry(entry); err != nil {
+		return err
+	}
+	if err := recordAccess(audit.ActionUpdate, entry.AppName); err != nil {
+		return err
+	}
+	postHook(hooks.EventUpdate, entry.AppName)
+	return nil
+}
+
+// Delete removes the login for a server
+func (h *vaultDockerHelper) Delete(serverURL string) error {
+	entry, err := h.find(serverURL)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return dockercredential.ErrNotFound
+	}
+	if err := hooks.Pre(hooks.EventDelete, entry.AppName); err != nil {
+		return err
+	}
+	if err := h.store.Delete(entry.AppName); err != nil {
+		return err
+	}
+	if err := recordAccess(audit.ActionDelete, entry.AppName); err != nil {
+		return err
+	}
+	postHook(hooks.EventDelete, entry.AppName)
+	return nil
+}
+
+// Get returns the login for a server
+func (h *vaultDockerHelper) Get(serverURL string) (string, string, error) {
+	entry, err := h.find(serverURL)
+	if err != nil {
+		return "", "", err
+	}
+	if entry == nil {
+		return "", "", dockercredential.ErrNotFound
+	}
+
+	secret, err := h.encryptor.Decrypt(entry.Password)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	fields, err := h.encryptor.DecryptFields(entry.Fields)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decrypt fields: %w", err)
+	}
+	if err := recordAccess(audit.ActionGet, entry.AppName); err != nil {
+		return "", "", err
+	}
+
+	return fields[models.FieldUsername], secret, nil
+}
+
+// List maps every stored server URL to its username
+func (h *vaultDockerHelper) List() (map[string]string, error) {
+	entries, err := h.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		fields, err := h.encryptor.DecryptFields(entry.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt fields for '%s': %w", entry.AppName, err)
+		}
+		accounts[strings.TrimPrefix(entry.AppName, dockerEntryPrefix)] = fields[models.FieldUsername]
+	}
+
+	return accounts, nil
+}
+
+// find returns the login for a server, or nil if there is none. The exact
+// server URL wins; otherwise URLs differing only in scheme or trailing slash match.
+func (h *vaultDockerHelper) find(serverURL string) (*models.PasswordEntry, error) {
+	entries, err := h.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var similar *models.PasswordEntry
+	for _, entry := range entries {
+		stored := strings.TrimPrefix(entry.AppName, dockerEntryPrefix)
+		if stored == serverURL {
+			return entry, nil
+		}
+		if similar == nil && dockercredential.Normalize(stored) == dockercredential.Normalize(serverURL) {
+			similar = entry
+		}
+	}
+
+	return similar, nil
+}
+
+// entries lists the registry logins
+func (h *vaultDockerHelper) entries() ([]*models.PasswordEntry, error) {
+	all, err := h.store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+
+	var entries []*models.PasswordEntry
+	for _, entry := range all {
+		if entry.HasTag(dockerCredentialTag) && strings.HasPrefix(entry.AppName, dockerEntryPrefix) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func init() {
+	rootCmd.AddCommand(dockerCredentialCmd)
+}
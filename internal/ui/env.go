@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/dotenv"
+
+	"github.com/spf13/cobra"
+)
+
+// projectEnvFile maps environment variables to entries for a project
+const projectEnvFile = ".remembrall.env"
+
+// envTag marks entries created from .env files
+const envTag = "env"
+
+var envImportPrefix string
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Move secrets between .env files and the vault",
+	Long: `Move secrets between .env files and the vault.
+
+A project's ` + projectEnvFile + ` file maps environment variable names to entries,
+one VAR=entry or VAR=entry:field per line. It holds no secrets and can be
+committed; 'remembrall run' and 'remembrall env export' pick it up from the
+current directory or any parent directory.`,
+}
+
+var envExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Write a .env file from the project's mapping",
+	Long: `Decrypt the entries named in ` + projectEnvFile + ` and write them as a .env
+file (default '.env', or '-' for stdout) with 0600 permissions. You will be
+prompted to enter your master password. Prefer 'remembrall run', which never
+writes secrets to disk.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := ".env"
+		if len(args) == 1 {
+			path = args[0]
+		}
+
+		count, err := exportEnv(path)
+		if err != nil {
+			exitWithError("Failed to export environment: %v", err)
+		}
+
+		if path != "-" {
+			fmt.Printf("✓ Wrote %d variables to '%s'\n", count, path)
+		}
+	},
+}
+
+var envImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Store the secrets of a .env file in the vault",
+	Long: `Store every variable of a .env file (default '.env') as an entry named
+<prefix>/<VAR> and add the matching lines to ` + projectEnvFile + `, creating it if
+needed. The prefix defaults to the name of the current directory. Existing
+entries are not overwritten. You will be prompted to enter your master password.
+
+Delete the .env file afterwards and use 'remembrall run' instead.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := ".env"
+		if len(args) == 1 {
+			path = args[0]
+		}
+
+		imported, err := importEnv(path)
+		if err != nil {
+			exitWithError("Failed to import environment: %v", err)
+		}
+
+		fmt.Printf("✓ Imported %d variables and updated '%s'\n", imported, projectEnvFile)
+	},
+}
+
+// findProjectEnvFile looks for the mapping file in the current directory and its parents
+func findProjectEnvFile() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	for {
+		path := filepath.Join(dir, projectEnvFile)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// readEnvFile parses a .env-style file
+func readEnvFile(path string) ([]dotenv.Var, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	vars, err := dotenv.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %w", path, err)
+	}
+	return vars, nil
+}
+
+// loadProjectMappings reads the project's mapping file, if there is one
+func loadProjectMappings() ([]dotenv.Var, error) {
+	path, err := findProjectEnvFile()
+	if err != nil || path == "" {
+		return nil, err
+	}
+	return readEnvFile(path)
+}
+
+// resolveMappings decrypts the entries referenced by VAR=reference mappings
+func resolveMappings(r *resolver, mappings []dotenv.Var) ([]dotenv.Var, error) {
+	resolved := make([]dotenv.Var, 0, len(mappings))
+	for _, m := range mappings {
+		value, err := r.resolve(m.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Key, err)
+		}
+		resolved = append(resolved, dotenv.Var{Key: m.Key, Value: value})
+	}
+	return resolved, nil
+}
+
+func exportEnv(path string) (int, error) {
+	mappings, err := loadProjectMappings()
+	if err != nil {
+		return 0, err
+	}
+	if len(mappings) == 0 {
+		return 0, fmt.Errorf("no %s found in this directory or its parents", projectEnvFile)
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return 0, fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	vars, err := resolveMappings(newResolver(store, crypto.NewEncryptor(masterPassword)), mappings)
+	if err != nil {
+		return 0, err
+	}
+
+	if path == "-" {
+		_, err = os.Stdout.Write(dotenv.Format(vars))
+		return len(vars), err
+	}
+
+	if err := writePrivateFile(path, dotenv.Format(vars)); err != nil {
+		return 0, err
+	}
+
+	return len(vars), nil
+}
+
+func importEnv(path string) (int, error) {
+	vars, err := readEnvFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	prefix := envImportPrefix
+	if prefix == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		prefix = filepath.Base(cwd)
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return 0, fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	entries := make([]*plainEntry, 0, len(vars))
+	for _, v := range vars {
+		entries = append(entries, &plainEntry{
+			AppName:  prefix + "/" + v.Key,
+			Password: v.Value,
+			Tags:     []string{envTag},
+		})
+	}
+
+	imported, _, err := saveImported(store, crypto.NewEncryptor(masterPassword), entries)
+	if err != nil {
+		return imported, err
+	}
+
+	// Map every variable, including ones whose entry already existed
+	var mappings []dotenv.Var
+	if _, err := os.Stat(projectEnvFile); err == nil {
+		if mappings, err = readEnvFile(projectEnvFile); err != nil {
+			return imported, err
+		}
+	}
+	mapped := make(map[string]bool)
+	for _, m := range mappings {
+		mapped[m.Key] = true
+	}
+	for _, v := range vars {
+		if !mapped[v.Key] {
+			mappings = append(mappings, dotenv.Var{Key: v.Key, Value: prefix + "/" + v.Key})
+			mapped[v.Key] = true
+		}
+	}
+
+	if err := os.WriteFile(projectEnvFile, dotenv.Format(mappings), 0644); err != nil {
+		return imported, fmt.Errorf("failed to write '%s': %w", projectEnvFile, err)
+	}
+
+	return imported, nil
+}
+
+func init() {
+	envImportCmd.Flags().StringVar(&envImportPrefix, "prefix", "", "entry name prefix (default: current directory name)")
+	envCmd.AddCommand(envExportCmd)
+	envCmd.AddCommand(envImportCmd)
+	rootCmd.AddCommand(envCmd)
+}
@@ -0,0 +1,327 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"remembrall/internal/auth"
+	"remembrall/internal/db"
+	"remembrall/pkg/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	expiryTag string
+	dueWithin int
+)
+
+var expiryCmd = &cobra.Command{
+	Use:   "expiry",
+	Short: "Manage password expiry policies",
+	Long: `Set how many days passwords may go unchanged, per entry or for every entry
+with a tag. An entry's own policy wins over its tags; of several tagged
+policies the strictest applies. 'remembrall due' lists the passwords to
+change, and 'remembrall get' warns when a password has expired.
+
+  remembrall expiry set --tag prod 90
+  remembrall expiry set github 30
+
+You will be prompted to enter your master password.`,
+}
+
+var expirySetCmd = &cobra.Command{
+	Use:   "set <app-name> <days> | --tag <tag> <days>",
+	Short: "Set the expiry policy of an entry or tag",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		target, value, err := expiryArgs(args, 2)
+		if err != nil {
+			exitWithError("Failed to set expiry policy: %v", err)
+		}
+		days, err := parseExpiryDays(value)
+		if err != nil {
+			exitWithError("Failed to set expiry policy: %v", err)
+		}
+
+		err = withSettingsStore(func(store *db.SQLiteStore) error {
+			if expiryTag != "" {
+				return store.SetTagExpiryPolicy(target, days)
+			}
+			return store.SetExpiry(target, days)
+		})
+		if err != nil {
+			exitWithError("Failed to set expiry policy: %v", err)
+		}
+
+		fmt.Printf("✓ Passwords of %s now expire after %d days\n", expiryTarget(target), days)
+	},
+}
+
+var expiryUnsetCmd = &cobra.Command{
+	Use:   "unset <app-name> | --tag <tag>",
+	Short: "Remove the expiry policy of an entry or tag",
+	Args:  cobra.RangeArgs(0, 1),
+	Run: func(cmd *cobra.Command, args []string) {
+		target, _, err := expiryArgs(args, 1)
+		if err != nil {
+			exitWithError("Failed to remove expiry policy: %v", err)
+		}
+
+		err = withSettingsStore(func(store *db.SQLiteStore) error {
+			if expiryTag != "" {
+				return store.UnsetTagExpiryPolicy(target)
+			}
+			return store.SetExpiry(target, 0)
+		})
+		if err != nil {
+			exitWithError("Failed to remove expiry policy: %v", err)
+		}
+
+		fmt.Printf("✓ Expiry policy of %s removed\n", expiryTarget(target))
+	},
+}
+
+var expiryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the expiry policies of tags and entries",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := withSettingsStore(func(store *db.SQLiteStore) error {
+			policies, err := store.TagExpiryPolicies()
+			if err != nil {
+				return err
+			}
+			entries, err := store.List()
+			if err != nil {
+				return fmt.Errorf("failed to retrieve from database: %w", err)
+			}
+
+			tags := make([]string, 0, len(policies))
+			for tag := range policies {
+				tags = append(tags, tag)
+			}
+			sort.Strings(tags)
+
+			var own []*models.PasswordEntry
+			for _, entry := range entries {
+				if entry.ExpiryDays > 0 {
+					own = append(own, entry)
+				}
+			}
+
+			if len(tags) == 0 && len(own) == 0 {
+				fmt.Println("No expiry policies set.")
+				fmt.Println("Use 'remembrall expiry set' to add one.")
+				return nil
+			}
+			if len(tags) > 0 {
+				fmt.Println("Tags:")
+				for _, tag := range tags {
+					fmt.Printf("  • %-30s every %d days\n", tag, policies[tag])
+				}
+			}
+			if len(own) > 0 {
+				fmt.Println("Entries:")
+				for _, entry := range own {
+					fmt.Printf("  • %-30s every %d days\n", entry.AppName, entry.ExpiryDays)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			exitWithError("Failed to list expiry policies: %v", err)
+		}
+	},
+}
+
+var dueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "List passwords that have expired or expire soon",
+	Long: `List the passwords past their expiry policy, and those expiring within
+--within days, based on when each was last changed. You will be prompted to
+enter your master password. Set policies with 'remembrall expiry'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := listDue(); err != nil {
+			exitWithError("Failed to list due passwords: %v", err)
+		}
+	},
+}
+
+// dueEntry is a password that has expired or expires soon
+type dueEntry struct {
+	entry     *models.PasswordEntry
+	days      int
+	expiresAt time.Time
+	pending   bool
+}
+
+func listDue() error {
+	if dueWithin < 0 {
+		return fmt.Errorf("--within must be 0 or more days")
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	if _, err := masterMgr.PromptAndVerifyMasterPassword(); err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	entries, err := store.List()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+	policies, err := store.TagExpiryPolicies()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	horizon := now.AddDate(0, 0, dueWithin)
+	var expired, upcoming []dueEntry
+	for _, entry := range entries {
+		expiresAt, ok := entry.ExpiresAt(policies)
+		if !ok || expiresAt.After(horizon) {
+			continue
+		}
+
+		pending, err := store.PendingRotation(entry.AppName)
+		if err != nil {
+			return err
+		}
+		due := dueEntry{entry: entry, days: entry.ExpiryPolicy(policies), expiresAt: expiresAt, pending: pending != nil}
+		if expiresAt.After(now) {
+			upcoming = append(upcoming, due)
+		} else {
+			expired = append(expired, due)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].expiresAt.Before(expired[j].expiresAt) })
+	sort.Slice(upcoming, func(i, j int) bool { return upcoming[i].expiresAt.Before(upcoming[j].expiresAt) })
+
+	if len(expired) == 0 && len(upcoming) == 0 {
+		fmt.Printf("✓ No passwords expired or due within %d days\n", dueWithin)
+		return nil
+	}
+
+	if len(expired) > 0 {
+		fmt.Printf("Expired (%d):\n", len(expired))
+		for _, due := range expired {
+			fmt.Printf("  • %-30s expired %s ago, changed every %d days (last on %s)%s\n",
+				due.entry.AppName, formatDays(now.Sub(due.expiresAt)), due.days, due.entry.UpdatedAt.Format("2006-01-02"), pendingNote(due.pending))
+		}
+	}
+	if len(upcoming) > 0 {
+		fmt.Printf("Due within %d days (%d):\n", dueWithin, len(upcoming))
+		for _, due := range upcoming {
+			fmt.Printf("  • %-30s expires in %s (%s)%s\n",
+				due.entry.AppName, formatDays(due.expiresAt.Sub(now)), due.expiresAt.Format("2006-01-02"), pendingNote(due.pending))
+		}
+	}
+	fmt.Println("\nChange these passwords with 'remembrall rotate <app-name>'.")
+	return nil
+}
+
+// printExpiryBanner warns on stderr when an entry's password has expired
+// or a rotation of it was left pending
+func printExpiryBanner(store *db.SQLiteStore, entry *models.PasswordEntry) error {
+	policies, err := store.TagExpiryPolicies()
+	if err != nil {
+		return err
+	}
+	if expiresAt, ok := entry.ExpiresAt(policies); ok && !expiresAt.After(time.Now()) {
+		fmt.Fprintf(os.Stderr, "⚠ The password for '%s' expired %s ago (changed every %d days).\n",
+			entry.AppName, formatDays(time.Since(expiresAt)), entry.ExpiryPolicy(policies))
+		fmt.Fprintf(os.Stderr, "  Change it with 'remembrall rotate %s'.\n", entry.AppName)
+	}
+
+	pending, err := store.PendingRotation(entry.AppName)
+	if err != nil {
+		return err
+	}
+	if pending != nil {
+		fmt.Fprintf(os.Stderr, "⚠ A rotation of '%s' started on %s is pending; this is the old password.\n",
+			entry.AppName, pending.CreatedAt.Format("2006-01-02 15:04"))
+		fmt.Fprintf(os.Stderr, "  Finish it with 'remembrall rotate %s'.\n", entry.AppName)
+	}
+	return nil
+}
+
+// expiryArgs returns the entry or tag an expiry command applies to, and the
+// value following it when n is 2
+func expiryArgs(args []string, n int) (string, string, error) {
+	if expiryTag != "" {
+		args = append([]string{expiryTag}, args...)
+	}
+	if len(args) != n {
+		return "", "", fmt.Errorf("expected an app name or --tag, see --help")
+	}
+	if n == 2 {
+		return args[0], args[1], nil
+	}
+	return args[0], "", nil
+}
+
+// expiryTarget describes the entry or tag of an expiry command
+func expiryTarget(target string) string {
+	if expiryTag != "" {
+		return fmt.Sprintf("entries tagged '%s'", target)
+	}
+	return fmt.Sprintf("'%s'", target)
+}
+
+// parseExpiryDays accepts a number of days such as "90" or "90d"
+func parseExpiryDays(value string) (int, error) {
+	days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("invalid number of days '%s', expected 1 or more", value)
+	}
+	return days, nil
+}
+
+// formatDays renders a duration in whole days, as "1 day" or "12 days"
+func formatDays(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	switch days {
+	case 0:
+		return "less than a day"
+	case 1:
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+func pendingNote(pending bool) string {
+	if pending {
+		return ", rotation pending"
+	}
+	return ""
+}
+
+func init() {
+	expirySetCmd.Flags().StringVar(&expiryTag, "tag", "", "set the policy of every entry with this tag")
+	expiryUnsetCmd.Flags().StringVar(&expiryTag, "tag", "", "remove the policy of this tag")
+	expiryCmd.AddCommand(expirySetCmd)
+	expiryCmd.AddCommand(expiryUnsetCmd)
+	expiryCmd.AddCommand(expiryListCmd)
+	rootCmd.AddCommand(expiryCmd)
+
+	dueCmd.Flags().IntVar(&dueWithin, "within", 14, "also list passwords expiring within this many days")
+	rootCmd.AddCommand(dueCmd)
+}
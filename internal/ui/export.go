@@ -0,0 +1,179 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/search"
+	"remembrall/pkg/models"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat          string
+	exportKey             string
+	exportUnsafePlaintext bool
+	exportEscapeFormulas  bool
+	exportQuery           string
+	exportTag             string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export --format <format> <path>",
+	Short: "Export passwords for another password manager",
+	Long: `Export stored passwords for use in another password manager. You will
+be prompted to enter your master password, and then a password protecting the
+exported file. Existing files are never overwritten.
+
+Supported formats:
+  kdbx    KeePass / KeePassXC database (KDBX 4). Folders become groups.
+  pass    pass password-store directory. Requires --key with the OpenPGP
+          public key ring (e.g. from 'gpg --export') or age recipients to
+          encrypt to. Folders become subdirectories. SSH keys, multi-line
+          passwords and multi-line fields don't fit a pass file and are
+          skipped with a warning.
+  csv     Plaintext CSV, one row per entry. Requires --unsafe-plaintext.
+          Values are written exactly as stored. With --escape-formulas,
+          names, folders and tags starting with =, +, -, @, a tab or a
+          carriage return get a leading ' so spreadsheets don't run them as
+          formulas; passwords and fields are never changed.
+  json    Plaintext JSON array of entries. Requires --unsafe-plaintext.
+
+Plaintext exports ask you to type a confirmation phrase, are written with
+0600 permissions and are refused when the destination is a terminal or a
+world-readable directory. Use '-' as the path to write to a pipe.
+
+Use --query (fuzzy match on the name) or --tag to export only some entries.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := exportPasswords(exportFormat, args[0])
+		if err != nil {
+			exitWithError("Failed to export passwords: %v", err)
+		}
+
+		if args[0] != "-" {
+			fmt.Printf("✓ Exported %d entries to '%s'\n", count, args[0])
+		}
+	},
+}
+
+func exportPasswords(format, path string) (int, error) {
+	if exportEscapeFormulas && format != "csv" {
+		return 0, fmt.Errorf("--escape-formulas only applies to the csv format")
+	}
+
+	plaintext := format == "csv" || format == "json"
+	if plaintext {
+		if !exportUnsafePlaintext {
+			return 0, fmt.Errorf("the %s format writes unencrypted passwords, pass --unsafe-plaintext to confirm", format)
+		}
+		if err := checkPlaintextDestination(path); err != nil {
+			return 0, err
+		}
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return 0, fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	if plaintext {
+		fmt.Fprintln(os.Stderr, "⚠ This writes your passwords to disk WITHOUT encryption.")
+		if err := auth.ConfirmPhrase(plaintextConfirmation); err != nil {
+			return 0, err
+		}
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	// Select and decrypt the entries that are about to be exported
+	selected, err := selectEntries(store, exportQuery, exportTag)
+	if err != nil {
+		return 0, err
+	}
+	if len(selected) == 0 {
+		return 0, fmt.Errorf("no entries match the selection")
+	}
+
+	entries, err := decryptEntries(crypto.NewEncryptor(masterPassword), selected)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		if err := recordAccess(audit.ActionExport, entry.AppName); err != nil {
+			return 0, err
+		}
+	}
+
+	count := len(entries)
+	switch format {
+	case "kdbx":
+		err = writeKDBX(path, entries)
+	case "pass":
+		count, err = writePass(path, exportKey, entries)
+	case "csv", "json":
+		err = writePlaintext(format, path, entries)
+	default:
+		return 0, fmt.Errorf("unsupported export format '%s'", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// selectEntries lists stored entries, optionally narrowed by a fuzzy query and a tag
+func selectEntries(store *db.SQLiteStore, query, tag string) ([]*models.PasswordEntry, error) {
+	entries, err := store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+
+	if query != "" {
+		var matched []*models.PasswordEntry
+		for _, result := range search.FuzzySearch(entries, query) {
+			matched = append(matched, result.Entry)
+		}
+		entries = matched
+	}
+
+	if tag != "" {
+		var tagged []*models.PasswordEntry
+		for _, entry := range entries {
+			if entry.HasTag(tag) {
+				tagged = append(tagged, entry)
+			}
+		}
+		entries = tagged
+	}
+
+	return entries, nil
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "format of the exported file (kdbx, pass, csv, json)")
+	exportCmd.Flags().StringVar(&exportKey, "key", "", "key file used to encrypt the export (pass)")
+	exportCmd.Flags().BoolVar(&exportUnsafePlaintext, "unsafe-plaintext", false, "allow writing unencrypted passwords (csv, json)")
+	exportCmd.Flags().BoolVar(&exportEscapeFormulas, "escape-formulas", false, "quote names, folders and tags a spreadsheet would run as formulas (csv)")
+	exportCmd.Flags().StringVar(&exportQuery, "query", "", "only export entries whose name fuzzy-matches this query")
+	exportCmd.Flags().StringVar(&exportTag, "tag", "", "only export entries with this tag")
+	exportCmd.MarkFlagRequired("format")
+	rootCmd.AddCommand(exportCmd)
+}
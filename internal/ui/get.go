@@ -2,10 +2,12 @@ package ui
 
 import (
 	"fmt"
+	"remembrall/internal/audit"
 	"remembrall/internal/auth"
 	"remembrall/internal/crypto"
 	"remembrall/internal/db"
 	"remembrall/internal/search"
+	"remembrall/pkg/models"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -47,36 +49,10 @@ func getPassword(appName string) error {
 	}
 	defer store.Close()
 
-	// Try exact match first
-	entry, err := store.Get(appName)
+	// Find the entry, falling back to fuzzy matching
+	entry, err := findEntry(store, appName, true)
 	if err != nil {
-		// If exact match fails, try fuzzy search
-		allEntries, listErr := store.List()
-		if listErr != nil {
-			return fmt.Errorf("failed to retrieve from database: %w", err)
-		}
-
-		bestMatch := search.FindBestMatch(allEntries, appName)
-		if bestMatch == nil {
-			// Show similar matches if available
-			results := search.FuzzySearch(allEntries, appName)
-			if len(results) > 0 {
-				fmt.Printf("No exact match found for '%s'. Did you mean:\n", appName)
-				for i, result := range results {
-					if i >= 3 { // Show max 3 suggestions
-						break
-					}
-					fmt.Printf("  • %s\n", result.Entry.AppName)
-				}
-				return fmt.Errorf("use exact application name or try 'remembrall list' to see all stored passwords")
-			}
-			return fmt.Errorf("failed to retrieve from database: %w", err)
-		}
-
-		// Found a good match, ask for confirmation
-		fmt.Printf("No exact match found for '%s'.\n", appName)
-		fmt.Printf("Did you mean '%s'? Retrieving password for '%s'...\n\n", bestMatch.AppName, bestMatch.AppName)
-		entry = bestMatch
+		return err
 	}
 
 	// Initialize encryptor with master password
@@ -88,6 +64,10 @@ func getPassword(appName string) error {
 		return fmt.Errorf("failed to decrypt password: %w", err)
 	}
 
+	if err := recordAccess(audit.ActionGet, entry.AppName); err != nil {
+		return err
+	}
+
 	if err := clipboard.Init(); err != nil {
 		return fmt.Errorf("failed to print password to clipboard: %w", err)
 	}
@@ -98,7 +78,51 @@ func getPassword(appName string) error {
 	time.Sleep(2 * time.Second)
 	auth.ClearScreen()
 
-	return nil
+	// Warn about an expired password, after clearing so the warning stays
+	return printExpiryBanner(store, entry)
+}
+
+// findEntry looks up an entry by its exact name. When allowFuzzy is set and
+// there is no exact match, the best fuzzy match is used instead, or similar
+// names are suggested if no match is good enough. Callers that must never act
+// on the wrong entry (such as template injection) pass allowFuzzy=false.
+func findEntry(store *db.SQLiteStore, appName string, allowFuzzy bool) (*models.PasswordEntry, error) {
+	// Try exact match first
+	entry, err := store.Get(appName)
+	if err == nil {
+		return entry, nil
+	}
+	if !allowFuzzy {
+		return nil, err
+	}
+
+	// If exact match fails, try fuzzy search
+	allEntries, listErr := store.List()
+	if listErr != nil {
+		return nil, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+
+	bestMatch := search.FindBestMatch(allEntries, appName)
+	if bestMatch == nil {
+		// Show similar matches if available
+		results := search.FuzzySearch(allEntries, appName)
+		if len(results) > 0 {
+			fmt.Printf("No exact match found for '%s'. Did you mean:\n", appName)
+			for i, result := range results {
+				if i >= 3 { // Show max 3 suggestions
+					break
+				}
+				fmt.Printf("  • %s\n", result.Entry.AppName)
+			}
+			return nil, fmt.Errorf("use exact application name or try 'remembrall list' to see all stored passwords")
+		}
+		return nil, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+
+	// Found a good match, ask for confirmation
+	fmt.Printf("No exact match found for '%s'.\n", appName)
+	fmt.Printf("Did you mean '%s'? Retrieving password for '%s'...\n\n", bestMatch.AppName, bestMatch.AppName)
+	return bestMatch, nil
 }
 
 func init() {
@@ -0,0 +1,261 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"remembrall/internal/audit"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/gitcredential"
+	"remembrall/internal/hooks"
+	"remembrall/pkg/models"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// gitCredentialTag marks entries created by the git credential helper
+const gitCredentialTag = "git-credential"
+
+var gitCredentialCmd = &cobra.Command{
+	Use:   "git-credential <get|store|erase>",
+	Short: "Git credential helper backed by the vault",
+	Long: `Act as a git credential helper, speaking git's key=value protocol on
+stdin and stdout. Enable it with:
+
+  git config --global credential.helper '!remembrall git-credential'
+
+'get' answers with the entry whose 'url' field matches the requested
+protocol, host and path (and whose 'username' field matches, when git knows
+the user). A url field without a scheme or path matches any. 'store' saves
+new logins as 'git/<user>@<host>' entries tagged '` + gitCredentialTag + `', and 'erase'
+removes rejected logins saved that way.
+
+The helper never prompts: run 'remembrall unlock' first, otherwise it fails
+and git falls back to asking for the credentials itself.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := gitCredential(args[0]); err != nil {
+			exitWithError("git-credential: %v", err)
+		}
+	},
+}
+
+func gitCredential(action string) error {
+	// Git may call helpers with actions added in later versions
+	if action != "get" && action != "store" && action != "erase" {
+		return nil
+	}
+
+	request, err := gitcredential.Parse(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if request.Protocol == "" || request.Host == "" {
+		return nil
+	}
+
+	// Fetch the master password without prompting
+	masterPassword, err := unlockedMasterPassword()
+	if err != nil {
+		return err
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	encryptor := crypto.NewEncryptor(masterPassword)
+	matches, err := matchGitCredentials(store, encryptor, request)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "get":
+		return getGitCredential(encryptor, request, matches)
+	case "store":
+		return storeGitCredential(store, encryptor, request, matches)
+	default:
+		return eraseGitCredential(store, encryptor, request, matches)
+	}
+}
+
+// gitMatch is a stored entry whose url field fits a git request
+type gitMatch struct {
+	entry    *models.PasswordEntry
+	username string
+	score    int
+}
+
+// matchGitCredentials returns the entries matching the request, best first
+func matchGitCredentials(store *db.SQLiteStore, encryptor *crypto.Encryptor, request *gitcredential.Credential) ([]*gitMatch, error) {
+	entries, err := store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+
+	var matches []*gitMatch
+	for _, entry := range entries {
+		if entry.Fields == "" {
+			continue
+		}
+		fields, err := encryptor.DecryptFields(entry.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt fields for '%s': %w", entry.AppName, err)
+		}
+
+		score, ok := request.Match(fields[models.FieldURL], fields[models.FieldUsername])
+		if !ok {
+			continue
+		}
+
+		// Insert in score order, keeping the vault order for ties
+		match := &gitMatch{entry: entry, username: fields[models.FieldUsername], score: score}
+		i := len(matches)
+		for i > 0 && matches[i-1].score < score {
+			i--
+		}
+		matches = append(matches[:i], append([]*gitMatch{match}, matches[i:]...)...)
+	}
+
+	return matches, nil
+}
+
+func getGitCredential(encryptor *crypto.Encryptor, request *gitcredential.Credential, matches []*gitMatch) error {
+	if len(matches) == 0 {
+		return nil
+	}
+	best := matches[0]
+
+	password, err := encryptor.Decrypt(best.entry.Password)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	if err := recordAccess(audit.ActionGet, best.entry.AppName); err != nil {
+		return err
+	}
+
+	response := *request
+	response.Password = password
+	if best.username != "" {
+		response.Username = best.username
+	}
+	return response.Write(os.Stdout)
+}
+
+func storeGitCredential(store *db.SQLiteStore, encryptor *crypto.Encryptor, request *gitcredential.Credential, matches []*gitMatch) error {
+	if request.Username == "" || request.Password == "" {
+		return nil
+	}
+
+	encryptedPassword, err := encryptor.Encrypt(request.Password)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt password: %w", err)
+	}
+
+	// Refresh the password of an entry for the same user
+	for _, match := range matches {
+		if match.username != request.Username {
+			continue
+		}
+		current, err := encryptor.Decrypt(match.entry.Password)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt password: %w", err)
+		}
+		if current == request.Password {
+			return nil
+		}
+		if err := hooks.Pre(hooks.EventUpdate, match.entry.AppName); err != nil {
+			return err
+		}
+		if err := store.Update(match.entry.AppName, encryptedPassword); err != nil {
+			return err
+		}
+		if err := recordAccess(audit.ActionUpdate, match.entry.AppName); err != nil {
+			return err
+		}
+		postHook(hooks.EventUpdate, match.entry.AppName)
+		return nil
+	}
+
+	encryptedFields, err := encryptor.EncryptFields(map[string]string{
+		models.FieldUsername: request.Username,
+		models.FieldURL:      request.URL(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encrypt fields: %w", err)
+	}
+
+	appName := gitEntryName(request)
+	if err := hooks.Pre(hooks.EventSave, appName); err != nil {
+		return err
+	}
+	err = store.SaveEntry(&models.PasswordEntry{
+		AppName:  appName,
+		Password: encryptedPassword,
+		Fields:   encryptedFields,
+		Tags:     []string{gitCredentialTag},
+	})
+	if err != nil {
+		return err
+	}
+	if err := recordAccess(audit.ActionSave, appName); err != nil {
+		return err
+	}
+	postHook(hooks.EventSave, appName)
+	return nil
+}
+
+// eraseGitCredential deletes helper-created entries for the rejected login.
+// Entries the user created by hand are never deleted.
+func eraseGitCredential(store *db.SQLiteStore, encryptor *crypto.Encryptor, request *gitcredential.Credential, matches []*gitMatch) error {
+	for _, match := range matches {
+		if !match.entry.HasTag(gitCredentialTag) {
+			continue
+		}
+		if request.Username != "" && match.username != request.Username {
+			continue
+		}
+
+		// Leave the entry alone if it already holds a different password
+		if request.Password != "" {
+			current, err := encryptor.Decrypt(match.entry.Password)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt password: %w", err)
+			}
+			if current != request.Password {
+				continue
+			}
+		}
+
+		if err := hooks.Pre(hooks.EventDelete, match.entry.AppName); err != nil {
+			return err
+		}
+		if err := store.Delete(match.entry.AppName); err != nil {
+			return err
+		}
+		if err := recordAccess(audit.ActionDelete, match.entry.AppName); err != nil {
+			return err
+		}
+		postHook(hooks.EventDelete, match.entry.AppName)
+	}
+
+	return nil
+}
+
+// gitEntryName names an entry saved by the helper, e.g. "git/alice@github.com/org/repo.git"
+func gitEntryName(c *gitcredential.Credential) string {
+	name := "git/" + c.Username + "@" + c.Host
+	if path := strings.Trim(c.Path, "/"); path != "" {
+		name += "/" + path
+	}
+	return name
+}
+
+func init() {
+	rootCmd.AddCommand(gitCredentialCmd)
+}
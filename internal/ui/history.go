@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"fmt"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/hooks"
+	"remembrall/pkg/models"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.design/x/clipboard"
+)
+
+var (
+	historyReveal int
+	rollbackTo    int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <app-name>",
+	Short: "List the previous passwords of an application",
+	Long: `List when each previous password of an application was set and replaced.
+You will be prompted to enter your master password. Passwords are never
+shown: --reveal N copies one to the clipboard, where N is the number in the
+list (1 is the most recent).
+
+Every update keeps the replaced password, up to the vault's history.limit
+setting (see 'remembrall config').`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := showHistory(args[0]); err != nil {
+			exitWithError("Failed to show history: %v", err)
+		}
+	},
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <app-name>",
+	Short: "Restore a previous password of an application",
+	Long: `Restore the most recent previous password of an application, or an older
+one with --to N as numbered by 'remembrall history'. You will be prompted to
+enter your master password. The password being replaced is kept in the
+history, so a rollback can be undone the same way.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		appName, restored, err := rollbackPassword(args[0])
+		if err != nil {
+			exitWithError("Failed to roll back password: %v", err)
+		}
+
+		fmt.Printf("✓ Password for '%s' rolled back to the one set on %s\n", appName, restored.SetAt.Format("2006-01-02 15:04"))
+	},
+}
+
+func showHistory(appName string) error {
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	entry, err := findEntry(store, appName, true)
+	if err != nil {
+		return err
+	}
+
+	history, err := store.History(entry.AppName)
+	if err != nil {
+		return err
+	}
+
+	if historyReveal != 0 {
+		previous, err := historyEntry(history, entry.AppName, historyReveal)
+		if err != nil {
+			return err
+		}
+
+		// Copy to the clipboard like 'get' so the password never reaches the terminal
+		if err := clipboard.Init(); err != nil {
+			return fmt.Errorf("failed to copy password to clipboard: %w", err)
+		}
+		password, err := crypto.NewEncryptor(masterPassword).Decrypt(previous.Password)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt password: %w", err)
+		}
+		if err := recordAccess(audit.ActionGet, entry.AppName); err != nil {
+			return err
+		}
+
+		clipboard.Write(clipboard.FmtText, []byte(password))
+		fmt.Printf("\nPassword %d for '%s' (set %s) is copied to clipboard!\n", historyReveal, entry.AppName, previous.SetAt.Format("2006-01-02 15:04"))
+
+		time.Sleep(2 * time.Second)
+		auth.ClearScreen()
+		return nil
+	}
+
+	if len(history) == 0 {
+		fmt.Printf("No previous passwords kept for '%s'.\n", entry.AppName)
+		return nil
+	}
+
+	fmt.Printf("\nPrevious passwords for '%s' (%d kept):\n", entry.AppName, len(history))
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("    set: %s   (current)\n", entry.UpdatedAt.Format("2006-01-02 15:04"))
+	for i, previous := range history {
+		fmt.Printf("%2d. set: %s   replaced: %s%s\n",
+			i+1,
+			previous.SetAt.Format("2006-01-02 15:04"),
+			previous.ReplacedAt.Format("2006-01-02 15:04"),
+			historyNote(previous.Note))
+	}
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("\nUse 'remembrall rollback %s --to N' to restore one\n", entry.AppName)
+
+	return nil
+}
+
+func rollbackPassword(appName string) (string, *models.HistoryEntry, error) {
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	if _, err := masterMgr.PromptAndVerifyMasterPassword(); err != nil {
+		return "", nil, fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	entry, err := findEntry(store, appName, true)
+	if err != nil {
+		return "", nil, err
+	}
+
+	history, err := store.History(entry.AppName)
+	if err != nil {
+		return "", nil, err
+	}
+	previous, err := historyEntry(history, entry.AppName, rollbackTo)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := hooks.Pre(hooks.EventUpdate, entry.AppName); err != nil {
+		return "", nil, err
+	}
+	if err := store.Rollback(entry.AppName, previous.ID); err != nil {
+		return "", nil, err
+	}
+	if err := recordAccess(audit.ActionUpdate, entry.AppName); err != nil {
+		return "", nil, err
+	}
+	postHook(hooks.EventUpdate, entry.AppName)
+
+	return entry.AppName, previous, nil
+}
+
+// historyNote formats how a previous password was replaced, if known
+func historyNote(note string) string {
+	if note == "" {
+		return ""
+	}
+	return "   (" + note + ")"
+}
+
+// historyEntry picks a previous password by its number in the history list
+func historyEntry(history []*models.HistoryEntry, appName string, n int) (*models.HistoryEntry, error) {
+	if len(history) == 0 {
+		return nil, fmt.Errorf("no previous passwords kept for '%s'", appName)
+	}
+	if n < 1 || n > len(history) {
+		return nil, fmt.Errorf("'%s' has previous passwords 1 to %d", appName, len(history))
+	}
+	return history[n-1], nil
+}
+
+func init() {
+	historyCmd.Flags().IntVar(&historyReveal, "reveal", 0, "copy previous password N to the clipboard")
+	rollbackCmd.Flags().IntVar(&rollbackTo, "to", 1, "previous password to restore, as numbered by 'history'")
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -0,0 +1,187 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/hooks"
+	"remembrall/pkg/models"
+
+	"github.com/spf13/cobra"
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage rotation and event hooks",
+	Long: `Hooks are executables run when entries change.
+
+A rotation hook belongs to an entry and applies a new password to its
+service, such as a database user. 'remembrall rotate --hook' passes it the
+old and new password as JSON on stdin, never as arguments:
+
+  {"event": "rotate", "app": "pgdb", "old": "...", "new": "..."}
+
+The new password is committed only when the hook exits with status 0. The
+hook's path is stored encrypted with the master password.
+
+Event hooks run for every entry: save it as pre-save, post-save, pre-update,
+post-update, pre-delete or post-delete in ~/.remembrall-hooks and make it
+executable. They get the event and entry name on stdin and in
+REMEMBRALL_EVENT and REMEMBRALL_APP, but no secrets. A pre hook exiting
+non-zero stops the change.
+
+Hooks that are writable by group or others, sit in such a directory or belong
+to another user are refused.
+
+You will be prompted to enter your master password.`,
+}
+
+var hookSetCmd = &cobra.Command{
+	Use:   "set <app-name> <executable>",
+	Short: "Set the rotation hook of an entry",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := filepath.Abs(args[1])
+		if err != nil {
+			exitWithError("Failed to set hook: %v", err)
+		}
+		if err := hooks.Check(path); err != nil {
+			exitWithError("Failed to set hook: %v", err)
+		}
+
+		err = withHookStore(func(store *db.SQLiteStore, encryptor *crypto.Encryptor) error {
+			encryptedPath, err := encryptor.Encrypt(path)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt hook path: %w", err)
+			}
+			return store.SetHook(args[0], encryptedPath)
+		})
+		if err != nil {
+			exitWithError("Failed to set hook: %v", err)
+		}
+
+		fmt.Printf("✓ Rotation hook of '%s' set to '%s'\n", args[0], path)
+	},
+}
+
+var hookUnsetCmd = &cobra.Command{
+	Use:   "unset <app-name>",
+	Short: "Remove the rotation hook of an entry",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := withSettingsStore(func(store *db.SQLiteStore) error {
+			return store.SetHook(args[0], "")
+		})
+		if err != nil {
+			exitWithError("Failed to remove hook: %v", err)
+		}
+
+		fmt.Printf("✓ Rotation hook of '%s' removed\n", args[0])
+	},
+}
+
+var hookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List rotation hooks and installed event hooks",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := withHookStore(func(store *db.SQLiteStore, encryptor *crypto.Encryptor) error {
+			entries, err := store.List()
+			if err != nil {
+				return fmt.Errorf("failed to retrieve from database: %w", err)
+			}
+
+			fmt.Println("Rotation hooks:")
+			found := false
+			for _, entry := range entries {
+				if entry.Hook != "" {
+					path, err := hookPath(encryptor, entry)
+					if err != nil {
+						path = "(not set with this master password, set it again)"
+					}
+					fmt.Printf("  • %-30s %s\n", entry.AppName, path)
+					found = true
+				}
+			}
+			if !found {
+				fmt.Println("  none, set one with 'remembrall hook set'")
+			}
+
+			dir, err := hooks.Dir()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Event hooks in %s:\n", dir)
+			found = false
+			for _, when := range []string{"pre", "post"} {
+				for _, event := range []string{hooks.EventSave, hooks.EventUpdate, hooks.EventDelete} {
+					name := when + "-" + event
+					if err := hooks.Check(filepath.Join(dir, name)); err == nil {
+						fmt.Printf("  • %s\n", name)
+						found = true
+					}
+				}
+			}
+			if !found {
+				fmt.Println("  none")
+			}
+			return nil
+		})
+		if err != nil {
+			exitWithError("Failed to list hooks: %v", err)
+		}
+	},
+}
+
+// withHookStore verifies the master password and runs fn with the vault and
+// an encryptor for hook paths
+func withHookStore(fn func(store *db.SQLiteStore, encryptor *crypto.Encryptor) error) error {
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	return fn(store, crypto.NewEncryptor(masterPassword))
+}
+
+// hookPath decrypts the rotation hook path of an entry. A path that doesn't
+// decrypt was not set with 'hook set' and is never run.
+func hookPath(encryptor *crypto.Encryptor, entry *models.PasswordEntry) (string, error) {
+	path, err := encryptor.Decrypt(entry.Hook)
+	if err != nil {
+		return "", fmt.Errorf("rotation hook of '%s' was not set with this master password, set it again with 'remembrall hook set'", entry.AppName)
+	}
+	return path, nil
+}
+
+// postHook runs the post hook of an event once an entry has changed. The
+// change stands either way, so a failure is only reported.
+func postHook(event, appName string) {
+	if err := hooks.Post(event, appName); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+func init() {
+	hookCmd.AddCommand(hookSetCmd)
+	hookCmd.AddCommand(hookUnsetCmd)
+	hookCmd.AddCommand(hookListCmd)
+	rootCmd.AddCommand(hookCmd)
+}
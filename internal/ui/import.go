@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFrom string
+	importKey  string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import --from <format> <path>",
+	Short: "Import passwords from another password manager",
+	Long: `Import passwords from another password manager. You will be prompted
+to enter your master password, and then the password of the source if it has one.
+
+Supported formats:
+  kdbx    KeePass / KeePassXC database (KDBX 3.1 or 4). Groups become
+          folders. Pass --key if the database also needs a key file.
+  pass    pass password-store directory. Requires --key with the OpenPGP
+          secret key ring (e.g. from 'gpg --export-secret-keys') or an age
+          identity file. The first line of each entry is the password and
+          'key: value' lines become fields.
+
+Entries whose name already exists in the vault are skipped.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		imported, skipped, err := importPasswords(importFrom, args[0])
+		if err != nil {
+			exitWithError("Failed to import passwords: %v", err)
+		}
+
+		fmt.Printf("✓ Imported %d entries (%d skipped)\n", imported, skipped)
+	},
+}
+
+func importPasswords(format, path string) (int, int, error) {
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return 0, 0, fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Read entries from the source
+	var entries []*plainEntry
+	switch format {
+	case "kdbx":
+		entries, err = readKDBX(path, importKey)
+	case "pass":
+		entries, err = readPass(path, importKey)
+	default:
+		return 0, 0, fmt.Errorf("unsupported import format '%s'", format)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	return saveImported(store, crypto.NewEncryptor(masterPassword), entries)
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFrom, "from", "", "format of the source (kdbx, pass)")
+	importCmd.Flags().StringVar(&importKey, "key", "", "key file used to decrypt the source (pass, kdbx)")
+	importCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(importCmd)
+}
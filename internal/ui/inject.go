@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/inject"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	injectInput  string
+	injectOutput string
+)
+
+var injectCmd = &cobra.Command{
+	Use:   "inject -i <template> -o <output>",
+	Short: "Render a template with secrets from the vault",
+	Long: `Render a configuration template, replacing secret references with
+decrypted values. You will be prompted to enter your master password.
+
+References take one of two forms:
+  {{ remembrall "app-name" "field" }}   a field of an entry (field is optional)
+  remembrall://app-name/field            the same, as a URI
+
+Without a field the entry password is used. Entry names must match exactly:
+if any reference cannot be resolved nothing is written. The output file is
+created with 0600 permissions. Use '-' to read from stdin or write to stdout.
+
+A template read from stdin leaves no terminal to prompt on, so the master
+password is taken from the agent instead: run 'remembrall unlock' first.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := injectTemplate(injectInput, injectOutput)
+		if err != nil {
+			exitWithError("Failed to render template: %v", err)
+		}
+
+		if injectOutput != "-" {
+			fmt.Printf("✓ Rendered %d references into '%s'\n", count, injectOutput)
+		}
+	},
+}
+
+func injectTemplate(input, output string) (int, error) {
+	template, err := readInput(input)
+	if err != nil {
+		return 0, err
+	}
+
+	masterPassword, err := injectMasterPassword(input)
+	if err != nil {
+		return 0, err
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	// Resolve every reference, without fuzzy matching
+	r := newResolver(store, crypto.NewEncryptor(masterPassword))
+	count := 0
+	rendered, err := inject.Render(template, func(ref inject.Reference) (string, error) {
+		count++
+		if ref.URI {
+			return r.resolveWith(ref.Path, "/")
+		}
+		return r.field(ref.Path, ref.Field)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if output == "-" {
+		_, err = os.Stdout.Write(rendered)
+		return count, err
+	}
+
+	if err := writePrivateFile(output, rendered); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// injectMasterPassword prompts for the master password, or fetches it from
+// the agent when stdin holds the template
+func injectMasterPassword(input string) (string, error) {
+	if input == "-" {
+		return unlockedMasterPassword()
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return "", fmt.Errorf("master password verification failed: %w", err)
+	}
+	return masterPassword, nil
+}
+
+// readInput reads a whole file, or stdin when the path is "-"
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return data, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	return data, nil
+}
+
+func init() {
+	injectCmd.Flags().StringVarP(&injectInput, "input", "i", "-", "template to render ('-' for stdin)")
+	injectCmd.Flags().StringVarP(&injectOutput, "output", "o", "-", "file to write ('-' for stdout)")
+	rootCmd.AddCommand(injectCmd)
+}
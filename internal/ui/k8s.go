@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/sops"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// secretKeyPattern matches the keys allowed in a Secret's data
+var secretKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+var (
+	k8sSecretFrom          []string
+	k8sSecretNamespace     string
+	k8sSecretType          string
+	k8sSecretAgeRecipients []string
+)
+
+var k8sCmd = &cobra.Command{
+	Use:   "k8s",
+	Short: "Generate Kubernetes manifests from the vault",
+}
+
+var k8sSecretCmd = &cobra.Command{
+	Use:   "secret <name> --from [key=]entry[:field]...",
+	Short: "Print a Kubernetes Secret manifest",
+	Long: `Print a v1 Secret manifest whose data holds secrets from the vault. You
+will be prompted to enter your master password.
+
+Each --from adds one key. Its value is the entry password, or a field with
+entry:field. The key is the field name (or the entry name when no field is
+given) unless set with key=entry[:field]:
+
+  remembrall k8s secret db --from prod-db:password --from user=prod-db:username
+
+With --age-recipient the data values are encrypted in the SOPS format, so the
+manifest can be committed and decrypted with 'sops -d' (or applied by a
+GitOps controller holding the age identity). Entry names must match exactly.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printK8sSecret(args[0]); err != nil {
+			exitWithError("Failed to generate secret: %v", err)
+		}
+	},
+}
+
+// k8sSecret is the manifest of a v1 Secret
+type k8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sObjectMeta     `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type k8sObjectMeta struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// secretSource is one --from flag
+type secretSource struct {
+	key string
+	ref string
+}
+
+func printK8sSecret(name string) error {
+	sources, err := parseSecretSources(k8sSecretFrom)
+	if err != nil {
+		return err
+	}
+	if err := sops.CheckRecipients(k8sSecretAgeRecipients); err != nil {
+		return err
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	secret := k8sSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   k8sObjectMeta{Name: name, Namespace: k8sSecretNamespace},
+		Type:       k8sSecretType,
+		Data:       make(map[string]string),
+	}
+
+	r := newResolver(store, crypto.NewEncryptor(masterPassword))
+	for _, source := range sources {
+		value, err := r.resolve(source.ref)
+		if err != nil {
+			return err
+		}
+		secret.Data[source.key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	var doc yaml.Node
+	if err := doc.Encode(&secret); err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	if len(k8sSecretAgeRecipients) > 0 {
+		if err := sops.Encrypt(&doc, "^(data|stringData)$", k8sSecretAgeRecipients); err != nil {
+			return err
+		}
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return encoder.Close()
+}
+
+// parseSecretSources parses "[key=]entry[:field]" flags
+func parseSecretSources(flags []string) ([]secretSource, error) {
+	if len(flags) == 0 {
+		return nil, fmt.Errorf("at least one --from is required")
+	}
+
+	seen := make(map[string]bool)
+	var sources []secretSource
+	for _, flag := range flags {
+		key, ref, explicit := strings.Cut(flag, "=")
+		if !explicit {
+			ref = flag
+			key = ref
+			if i := strings.LastIndex(ref, ":"); i > 0 {
+				key = ref[i+1:]
+			}
+		}
+
+		if ref == "" {
+			return nil, fmt.Errorf("invalid --from '%s', expected [key=]entry[:field]", flag)
+		}
+		if !secretKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid Secret key '%s', name it with --from key=%s", key, ref)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate Secret key '%s', name it with --from key=%s", key, ref)
+		}
+		seen[key] = true
+
+		sources = append(sources, secretSource{key: key, ref: ref})
+	}
+
+	return sources, nil
+}
+
+func init() {
+	k8sSecretCmd.Flags().StringArrayVar(&k8sSecretFrom, "from", nil, "[key=]entry[:field] to add (repeatable)")
+	k8sSecretCmd.Flags().StringVarP(&k8sSecretNamespace, "namespace", "n", "", "namespace of the Secret")
+	k8sSecretCmd.Flags().StringVar(&k8sSecretType, "type", "Opaque", "type of the Secret")
+	k8sSecretCmd.Flags().StringArrayVar(&k8sSecretAgeRecipients, "age-recipient", nil, "encrypt the values to this age public key, SOPS-style (repeatable)")
+	k8sCmd.AddCommand(k8sSecretCmd)
+	rootCmd.AddCommand(k8sCmd)
+}
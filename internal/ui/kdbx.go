@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"remembrall/internal/auth"
+	"remembrall/internal/kdbx"
+	"remembrall/pkg/models"
+)
+
+// readKDBX decrypts a KeePass database and maps its entries to vault entries.
+// Groups become folders, and UserName, URL and Notes become fields alongside
+// any custom strings (including KeePassXC's "otp"). keyPath names the
+// database's key file, if it has one.
+func readKDBX(path, keyPath string) ([]*plainEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	var keyFile []byte
+	if keyPath != "" {
+		keyFile, err = os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+	}
+
+	password, err := auth.ReadPassword(fmt.Sprintf("Enter password for %s: ", path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database password: %w", err)
+	}
+
+	database, err := kdbx.Read(file, password, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	names := kdbxEntryNames(database.Entries)
+	var entries []*plainEntry
+	for i, e := range database.Entries {
+		fields := make(map[string]string)
+		for key, value := range e.Fields {
+			fields[key] = value
+		}
+		setField(fields, models.FieldUsername, e.Username)
+		setField(fields, models.FieldURL, e.URL)
+		setField(fields, models.FieldNotes, e.Notes)
+
+		entries = append(entries, &plainEntry{
+			AppName:   names[i],
+			Password:  e.Password,
+			Fields:    fields,
+			Folder:    e.Group,
+			Tags:      e.Tags,
+			CreatedAt: e.Created,
+			UpdatedAt: e.Modified,
+		})
+	}
+
+	return entries, nil
+}
+
+// kdbxEntryNames names imported entries after their titles. KeePass allows
+// the same title in several groups, so a title shared by several entries is
+// prefixed with the group path, and any name still taken gets a number.
+func kdbxEntryNames(entries []*kdbx.Entry) []string {
+	titles := make(map[string]int)
+	for _, e := range entries {
+		titles[e.Title]++
+	}
+
+	names := make([]string, len(entries))
+	taken := make(map[string]bool)
+	for i, e := range entries {
+		if e.Title == "" {
+			continue
+		}
+		base := e.Title
+		if titles[e.Title] > 1 && e.Group != "" {
+			base = e.Group + "/" + e.Title
+		}
+		name := base
+		for n := 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s (%d)", base, n)
+		}
+		taken[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// writeKDBX writes vault entries to a new KeePass database
+func writeKDBX(path string, entries []*plainEntry) error {
+	password, err := auth.ReadPasswordWithConfirmation(
+		fmt.Sprintf("Enter a password for %s: ", path),
+		"Confirm the password: ",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get database password: %w", err)
+	}
+
+	database := &kdbx.Database{Name: "Remembrall"}
+	for _, p := range entries {
+		fields := make(map[string]string)
+		for key, value := range p.Fields {
+			switch key {
+			case models.FieldUsername, models.FieldURL, models.FieldNotes:
+			default:
+				fields[key] = value
+			}
+		}
+
+		database.Entries = append(database.Entries, &kdbx.Entry{
+			Title:    p.AppName,
+			Username: p.Fields[models.FieldUsername],
+			Password: p.Password,
+			URL:      p.Fields[models.FieldURL],
+			Notes:    p.Fields[models.FieldNotes],
+			Fields:   fields,
+			Group:    p.Folder,
+			Tags:     p.Tags,
+			Created:  p.CreatedAt,
+			Modified: p.UpdatedAt,
+		})
+	}
+
+	file, err := createPrivateFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := kdbx.Write(file, database, password); err != nil {
+		file.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to write KeePass database: %w", err)
+	}
+
+	return file.Close()
+}
+
+// setField sets a field only when the value is non-empty
+func setField(fields map[string]string, key, value string) {
+	if value != "" {
+		fields[key] = value
+	}
+}
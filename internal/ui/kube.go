@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Entry fields read by kube-credentials
+const (
+	fieldKubeToken             = "token"
+	fieldKubeClientCertificate = "client_certificate_data"
+	fieldKubeClientKey         = "client_key_data"
+)
+
+// defaultExecCredentialVersion is used when kubectl doesn't say which version it wants
+const defaultExecCredentialVersion = "client.authentication.k8s.io/v1"
+
+// execCredential is the object a kubeconfig exec plugin prints
+type execCredential struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Status     execCredentialStatus `json:"status"`
+}
+
+type execCredentialStatus struct {
+	Token                 string `json:"token,omitempty"`
+	ClientCertificateData string `json:"clientCertificateData,omitempty"`
+	ClientKeyData         string `json:"clientKeyData,omitempty"`
+	ExpirationTimestamp   string `json:"expirationTimestamp,omitempty"`
+}
+
+var kubeCredentialsCmd = &cobra.Command{
+	Use:   "kube-credentials <app-name>",
+	Short: "Print a kubectl ExecCredential for an entry",
+	Long: `Print the credentials stored in an entry as a Kubernetes ExecCredential,
+for use as a kubeconfig exec plugin:
+
+  users:
+  - name: prod
+    user:
+      exec:
+        apiVersion: client.authentication.k8s.io/v1
+        command: remembrall
+        args: ["kube-credentials", "k8s-prod"]
+        interactiveMode: Never
+
+The bearer token comes from the '` + fieldKubeToken + `' field (or the password). A client
+certificate can be given instead with the '` + fieldKubeClientCertificate + `' and
+'` + fieldKubeClientKey + `' fields (PEM). An optional '` + fieldExpiration + `' field (RFC 3339) is
+passed through.
+
+The entry name must match exactly. The command never prompts: run
+'remembrall unlock' first.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printKubeCredentials(args[0]); err != nil {
+			exitWithError("Failed to get Kubernetes credentials: %v", err)
+		}
+	},
+}
+
+func printKubeCredentials(appName string) error {
+	entry, err := unlockedEntry(appName)
+	if err != nil {
+		return err
+	}
+
+	status := execCredentialStatus{
+		ClientCertificateData: entry.Fields[fieldKubeClientCertificate],
+		ClientKeyData:         entry.Fields[fieldKubeClientKey],
+		Token:                 entry.Fields[fieldKubeToken],
+	}
+	if (status.ClientCertificateData == "") != (status.ClientKeyData == "") {
+		return fmt.Errorf("entry '%s' needs both '%s' and '%s'", appName, fieldKubeClientCertificate, fieldKubeClientKey)
+	}
+	if status.Token == "" && status.ClientCertificateData == "" {
+		status.Token = entry.Password
+	}
+
+	if status.ExpirationTimestamp, err = entryExpiration(entry); err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(execCredential{
+		APIVersion: execCredentialVersion(),
+		Kind:       "ExecCredential",
+		Status:     status,
+	})
+}
+
+// execCredentialVersion answers in the version kubectl asked for through
+// KUBERNETES_EXEC_INFO
+func execCredentialVersion() string {
+	var info struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal([]byte(os.Getenv("KUBERNETES_EXEC_INFO")), &info); err != nil || info.APIVersion == "" {
+		return defaultExecCredentialVersion
+	}
+	return info.APIVersion
+}
+
+func init() {
+	rootCmd.AddCommand(kubeCredentialsCmd)
+}
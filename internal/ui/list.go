@@ -9,12 +9,14 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var listAll bool
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all stored applications",
 	Long: `List all applications for which passwords are stored. You will be prompted
 to enter your system password for authentication. Only application names are shown,
-not the actual passwords.`,
+not the actual passwords. Docker registry logins are hidden unless --all is given.`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := listPasswords(); err != nil {
@@ -49,6 +51,17 @@ func listPasswords() error {
 		return fmt.Errorf("failed to retrieve from database: %w", err)
 	}
 
+	// Hide logins managed by the docker credential helper
+	if !listAll {
+		visible := entries[:0]
+		for _, entry := range entries {
+			if !entry.HasTag(dockerCredentialTag) {
+				visible = append(visible, entry)
+			}
+		}
+		entries = visible
+	}
+
 	if len(entries) == 0 {
 		fmt.Println("No passwords stored yet.")
 		fmt.Println("Use 'remembrall save <app-name>' to add your first password.")
@@ -78,5 +91,6 @@ func listPasswords() error {
 }
 
 func init() {
+	listCmd.Flags().BoolVar(&listAll, "all", false, "also list docker registry logins")
 	rootCmd.AddCommand(listCmd)
 }
\ No newline at end of file
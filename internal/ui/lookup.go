@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+
+	"github.com/spf13/cobra"
+)
+
+var lookupJSON bool
+
+var lookupCmd = &cobra.Command{
+	Use:   "lookup <entry[:field]>...",
+	Short: "Print secrets for scripts such as Ansible lookups",
+	Long: `Print the value of each reference on its own line, for use from scripts
+and Ansible's pipe lookup:
+
+  db_password: "{{ lookup('pipe', 'remembrall lookup prod-db') }}"
+  db_user: "{{ lookup('pipe', 'remembrall lookup prod-db:username') }}"
+
+With --json the values are printed as a JSON array in argument order, for
+'| from_json' when looking up several at once.
+
+Entry names must match exactly and a missing entry or field fails the whole
+lookup. The command never prompts: run 'remembrall unlock' first.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		values, err := resolveUnlocked(args)
+		if err != nil {
+			exitWithError("lookup: %v", err)
+		}
+
+		if lookupJSON {
+			if err := json.NewEncoder(os.Stdout).Encode(values); err != nil {
+				exitWithError("lookup: %v", err)
+			}
+			return
+		}
+		for _, value := range values {
+			fmt.Println(value)
+		}
+	},
+}
+
+// resolveUnlocked decrypts "entry" or "entry:field" references using the
+// master password held by the unlock agent
+func resolveUnlocked(refs []string) ([]string, error) {
+	// Fetch the master password without prompting
+	masterPassword, err := unlockedMasterPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	res := newResolver(store, crypto.NewEncryptor(masterPassword))
+	values := make([]string, len(refs))
+	for i, ref := range refs {
+		if values[i], err = res.resolve(ref); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func init() {
+	lookupCmd.Flags().BoolVar(&lookupJSON, "json", false, "print the values as a JSON array")
+	rootCmd.AddCommand(lookupCmd)
+}
@@ -0,0 +1,502 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"remembrall/internal/audit"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/hooks"
+	"remembrall/internal/nativemsg"
+	"remembrall/internal/pinentry"
+	"remembrall/pkg/models"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// browserLoginTag marks logins saved from the browser
+	browserLoginTag = "browser"
+	// browserEntryPrefix namespaces logins saved from the browser, e.g. "web/github.com/alice"
+	browserEntryPrefix = "web/"
+	// nativeHostHelperName runs native-host when the binary is linked under this name
+	nativeHostHelperName = "remembrall-native-host"
+)
+
+var nativeHostExtensionIDs []string
+var nativeHostBrowsers []string
+
+var nativeHostCmd = &cobra.Command{
+	Use:   "native-host",
+	Short: "Native messaging host for browser extensions",
+	Long: `Serve a browser extension over native messaging: length-prefixed JSON
+messages on stdin and stdout. Register it with 'remembrall native-host install'.
+
+Each request has an "action" and an "origin" (e.g. "https://github.com"),
+plus an optional "id" that is echoed in the response:
+
+  lookup       entries whose 'url' field matches the origin, without secrets
+  credentials  the username and password of one of those entries ("name")
+  save         store a login ("username", "password") for the origin
+
+Credentials are only returned and logins only saved after you confirm, through
+SSH_ASKPASS when it is set (unless it is remembrall-askpass, which can't
+answer) and a graphical pinentry otherwise (the program in
+REMEMBRALL_PINENTRY, or the first of pinentry-gnome3, pinentry-qt,
+pinentry-gtk-2 and pinentry-mac found on PATH). Without either, requests fail.
+Failures are reported in the "error" property of the response.
+
+The host never prompts for the master password: run 'remembrall unlock' first.`,
+	Args: cobra.ArbitraryArgs,
+	// Chrome passes the caller origin, Firefox the manifest path and add-on ID
+	FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runNativeHost(nativeHostCaller(args), os.Stdin, os.Stdout); err != nil {
+			exitWithError("native-host: %v", err)
+		}
+	},
+}
+
+var nativeHostInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Register the native messaging host with your browsers",
+	Long: `Write the native messaging manifests that let the given extensions start
+'remembrall native-host'. Chromium extension IDs (32 letters a-p) are
+registered with Chrome, Chromium and Brave, other IDs with Firefox.
+
+Manifests are written for the browsers found in your home directory, or
+those named with --browser. The manifests point to a ` + nativeHostHelperName + `
+link to this binary in ~/.local/share/remembrall, so run install again if
+you move it.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := installNativeHost(); err != nil {
+			exitWithError("%v", err)
+		}
+	},
+}
+
+// nativeHostRequest is a message from the extension
+type nativeHostRequest struct {
+	ID       string `json:"id,omitempty"`
+	Action   string `json:"action"`
+	Origin   string `json:"origin"`
+	Name     string `json:"name,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// nativeHostLogin describes a matching entry without its password
+type nativeHostLogin struct {
+	Name     string `json:"name"`
+	Username string `json:"username,omitempty"`
+}
+
+// nativeHostResponse is the reply to a request
+type nativeHostResponse struct {
+	ID       string             `json:"id,omitempty"`
+	Entries  *[]nativeHostLogin `json:"entries,omitempty"` // set for lookups, even when empty
+	Name     string             `json:"name,omitempty"`
+	Username string             `json:"username,omitempty"`
+	Password string             `json:"password,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// nativeHostCaller names the extension that started the host
+func nativeHostCaller(args []string) string {
+	switch len(args) {
+	case 0:
+		return "the browser extension"
+	case 1:
+		return args[0]
+	default:
+		return args[1]
+	}
+}
+
+// runNativeHost answers requests until the browser closes stdin
+func runNativeHost(caller string, in io.Reader, out io.Writer) error {
+	for {
+		var request nativeHostRequest
+		if err := nativemsg.Read(in, &request); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		response, err := handleNativeRequest(caller, &request)
+		if err != nil {
+			response = &nativeHostResponse{Error: err.Error()}
+		}
+		response.ID = request.ID
+
+		if err := nativemsg.Write(out, response); err != nil {
+			return err
+		}
+	}
+}
+
+func handleNativeRequest(caller string, request *nativeHostRequest) (*nativeHostResponse, error) {
+	origin, err := parseOrigin(request.Origin)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the master password without prompting
+	masterPassword, err := unlockedMasterPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	encryptor := crypto.NewEncryptor(masterPassword)
+	matches, err := matchOrigin(store, encryptor, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	switch request.Action {
+	case "lookup":
+		logins := []nativeHostLogin{}
+		for _, match := range matches {
+			logins = append(logins, nativeHostLogin{Name: match.entry.AppName, Username: match.username})
+		}
+		return &nativeHostResponse{Entries: &logins}, nil
+	case "credentials":
+		return nativeCredentials(caller, encryptor, origin, request, matches)
+	case "save":
+		return nativeSave(caller, store, encryptor, origin, request, matches)
+	default:
+		return nil, fmt.Errorf("unknown action '%s'", request.Action)
+	}
+}
+
+// graphicalPinentries are tried in order when REMEMBRALL_PINENTRY is unset
+var graphicalPinentries = []string{"pinentry-gnome3", "pinentry-qt", "pinentry-gtk-2", "pinentry-mac"}
+
+// confirmBrowserRequest asks whether a request from the extension may go
+// ahead. The browser starts the host without a terminal, so the question is
+// asked through SSH_ASKPASS or a graphical pinentry, and the request fails
+// when neither is available.
+func confirmBrowserRequest(prompt string) error {
+	if allowed, ok := confirmWithAskpass(prompt); ok {
+		if !allowed {
+			return fmt.Errorf("request declined")
+		}
+		return nil
+	}
+
+	program := os.Getenv("REMEMBRALL_PINENTRY")
+	if program == "" {
+		for _, name := range graphicalPinentries {
+			if path, err := exec.LookPath(name); err == nil {
+				program = path
+				break
+			}
+		}
+	}
+	if program == "" {
+		return fmt.Errorf("no way to confirm the request: set SSH_ASKPASS or REMEMBRALL_PINENTRY, or install a graphical pinentry")
+	}
+
+	allowed, err := pinentry.Confirm(program, "remembrall", prompt)
+	if err != nil {
+		return fmt.Errorf("failed to confirm the request: %w", err)
+	}
+	if !allowed {
+		return fmt.Errorf("request declined")
+	}
+	return nil
+}
+
+// originMatch is a stored entry whose url field fits a browser origin
+type originMatch struct {
+	entry    *models.PasswordEntry
+	username string
+}
+
+// matchOrigin returns the password entries whose url field matches the origin
+func matchOrigin(store *db.SQLiteStore, encryptor *crypto.Encryptor, origin *url.URL) ([]*originMatch, error) {
+	entries, err := store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+
+	var matches []*originMatch
+	for _, entry := range entries {
+		if entry.Fields == "" || entry.Type != models.EntryTypePassword {
+			continue
+		}
+		fields, err := encryptor.DecryptFields(entry.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt fields for '%s': %w", entry.AppName, err)
+		}
+		if originMatches(origin, fields[models.FieldURL]) {
+			matches = append(matches, &originMatch{entry: entry, username: fields[models.FieldUsername]})
+		}
+	}
+
+	return matches, nil
+}
+
+func nativeCredentials(caller string, encryptor *crypto.Encryptor, origin *url.URL, request *nativeHostRequest, matches []*originMatch) (*nativeHostResponse, error) {
+	// Only entries saved for this origin can be requested
+	var match *originMatch
+	for _, m := range matches {
+		if m.entry.AppName == request.Name {
+			match = m
+			break
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no entry '%s' for %s", request.Name, origin)
+	}
+
+	if err := confirmBrowserRequest(fmt.Sprintf("Allow %s to fill '%s' into %s?", caller, match.entry.AppName, origin)); err != nil {
+		return nil, err
+	}
+
+	password, err := encryptor.Decrypt(match.entry.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	if err := recordAccess(audit.ActionGet, match.entry.AppName); err != nil {
+		return nil, err
+	}
+	return &nativeHostResponse{Name: match.entry.AppName, Username: match.username, Password: password}, nil
+}
+
+// nativeSave stores a login, updating the password of an entry for the same
+// user on the origin
+func nativeSave(caller string, store *db.SQLiteStore, encryptor *crypto.Encryptor, origin *url.URL, request *nativeHostRequest, matches []*originMatch) (*nativeHostResponse, error) {
+	if request.Password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+
+	var existing *originMatch
+	for _, match := range matches {
+		if match.username == request.Username {
+			existing = match
+			break
+		}
+	}
+
+	encryptedPassword, err := encryptor.Encrypt(request.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt password: %w", err)
+	}
+
+	if existing != nil {
+		if err := confirmBrowserRequest(fmt.Sprintf("Allow %s to update the password of '%s'?", caller, existing.entry.AppName)); err != nil {
+			return nil, err
+		}
+		if err := hooks.Pre(hooks.EventUpdate, existing.entry.AppName); err != nil {
+			return nil, err
+		}
+		if err := store.Update(existing.entry.AppName, encryptedPassword); err != nil {
+			return nil, err
+		}
+		if err := recordAccess(audit.ActionUpdate, existing.entry.AppName); err != nil {
+			return nil, err
+		}
+		postHook(hooks.EventUpdate, existing.entry.AppName)
+		return &nativeHostResponse{Name: existing.entry.AppName}, nil
+	}
+
+	name := browserEntryPrefix + origin.Host
+	if request.Username != "" {
+		name += "/" + request.Username
+	}
+	if err := confirmBrowserRequest(fmt.Sprintf("Allow %s to save a login for %s as '%s'?", caller, origin, name)); err != nil {
+		return nil, err
+	}
+
+	encryptedFields, err := encryptor.EncryptFields(map[string]string{
+		models.FieldUsername: request.Username,
+		models.FieldURL:      origin.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt fields: %w", err)
+	}
+
+	if err := hooks.Pre(hooks.EventSave, name); err != nil {
+		return nil, err
+	}
+	err = store.SaveEntry(&models.PasswordEntry{
+		AppName:  name,
+		Password: encryptedPassword,
+		Fields:   encryptedFields,
+		Tags:     []string{browserLoginTag},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAccess(audit.ActionSave, name); err != nil {
+		return nil, err
+	}
+	postHook(hooks.EventSave, name)
+	return &nativeHostResponse{Name: name}, nil
+}
+
+// parseOrigin accepts a web origin such as "https://example.com:8443"
+func parseOrigin(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("invalid origin '%s'", raw)
+	}
+	return &url.URL{Scheme: u.Scheme, Host: strings.ToLower(u.Host)}, nil
+}
+
+// originMatches reports whether a stored url field belongs to the origin.
+// Hosts must be equal; a stored url without a scheme only matches https.
+func originMatches(origin *url.URL, storedURL string) bool {
+	if storedURL == "" {
+		return false
+	}
+	if !strings.Contains(storedURL, "://") {
+		storedURL = "https://" + storedURL
+	}
+	u, err := url.Parse(storedURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	return strings.EqualFold(u.Scheme, origin.Scheme) &&
+		strings.EqualFold(u.Hostname(), origin.Hostname()) &&
+		originPort(u) == originPort(origin)
+}
+
+// originPort returns the explicit or default port of a URL
+func originPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if strings.EqualFold(u.Scheme, "http") {
+		return "80"
+	}
+	return "443"
+}
+
+func installNativeHost() error {
+	if len(nativeHostExtensionIDs) == 0 {
+		return fmt.Errorf("at least one --extension-id is required")
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	browsers, err := selectBrowsers(homeDir)
+	if err != nil {
+		return err
+	}
+
+	// Browsers start the host without a subcommand, so they run a link
+	// that selects it by name
+	hostPath, err := linkNativeHost(homeDir)
+	if err != nil {
+		return err
+	}
+
+	installed := 0
+	for _, browser := range browsers {
+		manifest := nativemsg.ManifestFor(browser, hostPath, nativeHostExtensionIDs)
+		if manifest == nil {
+			continue
+		}
+		path, err := nativemsg.WriteManifest(homeDir, browser, manifest)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Registered with %s: %s\n", browser.Name, path)
+		installed++
+	}
+
+	if installed == 0 {
+		return fmt.Errorf("none of the extension IDs apply to the selected browsers")
+	}
+	return nil
+}
+
+// selectBrowsers returns the browsers named with --browser, or those
+// installed for the user
+func selectBrowsers(homeDir string) ([]nativemsg.Browser, error) {
+	var selected []nativemsg.Browser
+
+	if len(nativeHostBrowsers) == 0 {
+		for _, browser := range nativemsg.Browsers {
+			if _, err := os.Stat(filepath.Join(homeDir, browser.ConfigDir)); err == nil {
+				selected = append(selected, browser)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("no supported browser found, name one with --browser")
+		}
+		return selected, nil
+	}
+
+	for _, name := range nativeHostBrowsers {
+		found := false
+		for _, browser := range nativemsg.Browsers {
+			if browser.Name == name {
+				selected = append(selected, browser)
+				found = true
+			}
+		}
+		if !found {
+			var names []string
+			for _, browser := range nativemsg.Browsers {
+				names = append(names, browser.Name)
+			}
+			return nil, fmt.Errorf("unknown browser '%s' (supported: %s)", name, strings.Join(names, ", "))
+		}
+	}
+	return selected, nil
+}
+
+// linkNativeHost points ~/.local/share/remembrall/remembrall-native-host at
+// the running binary and returns the link's path
+func linkNativeHost(homeDir string) (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the remembrall binary: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return "", fmt.Errorf("failed to locate the remembrall binary: %w", err)
+	}
+
+	dir := filepath.Join(homeDir, ".local", "share", "remembrall")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create '%s': %w", dir, err)
+	}
+
+	link := filepath.Join(dir, nativeHostHelperName)
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to replace '%s': %w", link, err)
+	}
+	if err := os.Symlink(executable, link); err != nil {
+		return "", fmt.Errorf("failed to create '%s': %w", link, err)
+	}
+	return link, nil
+}
+
+func init() {
+	nativeHostInstallCmd.Flags().StringSliceVar(&nativeHostExtensionIDs, "extension-id", nil, "extension allowed to use the host (repeatable)")
+	nativeHostInstallCmd.Flags().StringSliceVar(&nativeHostBrowsers, "browser", nil, "browser to register with: chrome, chromium, brave, firefox (default: all found)")
+	nativeHostCmd.AddCommand(nativeHostInstallCmd)
+	rootCmd.AddCommand(nativeHostCmd)
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/otp"
+	"remembrall/pkg/models"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.design/x/clipboard"
+)
+
+var otpCmd = &cobra.Command{
+	Use:   "otp <app-name>",
+	Short: "Copy the current one-time password for an application",
+	Long: `Generate the current one-time password from the OTP secret of an entry
+and copy it to the clipboard. You will be prompted to enter your master
+password.
+
+Secrets are added with 'save --otp-uri otpauth://...' (the URI shown as a QR
+code when enabling two-factor authentication), or read from screenshots of
+the QR codes with 'otp import-qr'. TOTP (RFC 6238) and HOTP
+(RFC 4226) keys are supported with SHA1, SHA256 or SHA512, 6 to 8 digits
+and custom periods. Each HOTP code uses up one counter value.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := copyOTP(args[0]); err != nil {
+			exitWithError("Failed to generate code: %v", err)
+		}
+	},
+}
+
+func copyOTP(appName string) error {
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	entry, err := findEntry(store, appName, true)
+	if err != nil {
+		return err
+	}
+
+	key, err := entryOTPKey(crypto.NewEncryptor(masterPassword), entry)
+	if err != nil {
+		return err
+	}
+	if err := recordAccess(audit.ActionGet, entry.AppName); err != nil {
+		return err
+	}
+
+	// Check the clipboard first so no HOTP counter value is wasted
+	if err := clipboard.Init(); err != nil {
+		return fmt.Errorf("failed to copy code to clipboard: %w", err)
+	}
+
+	var code, validity string
+	if key.Type == otp.TypeHOTP {
+		counter, err := store.NextOTPCounter(entry.AppName)
+		if err != nil {
+			return err
+		}
+		code = key.HOTP(counter)
+		validity = fmt.Sprintf("counter %d", counter)
+	} else {
+		var remaining time.Duration
+		code, remaining = key.TOTP(time.Now())
+		validity = fmt.Sprintf("valid for %ds", int(remaining.Seconds()))
+	}
+
+	clipboard.Write(clipboard.FmtText, []byte(code))
+
+	fmt.Printf("✓ Code for '%s' copied to clipboard (%s)\n", entry.AppName, validity)
+	return nil
+}
+
+// entryOTPKey parses the OTP secret stored in an entry's fields
+func entryOTPKey(encryptor *crypto.Encryptor, entry *models.PasswordEntry) (*otp.Key, error) {
+	if entry.Fields == "" {
+		return nil, fmt.Errorf("entry '%s' has no OTP secret, add one with 'save --otp-uri'", entry.AppName)
+	}
+	fields, err := encryptor.DecryptFields(entry.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt fields: %w", err)
+	}
+
+	uri := fields[models.FieldOTP]
+	if uri == "" {
+		return nil, fmt.Errorf("entry '%s' has no OTP secret, add one with 'save --otp-uri'", entry.AppName)
+	}
+
+	key, err := otp.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTP secret in '%s': %w", entry.AppName, err)
+	}
+	return key, nil
+}
+
+func init() {
+	rootCmd.AddCommand(otpCmd)
+}
@@ -0,0 +1,274 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/hooks"
+	"remembrall/internal/otp"
+	"remembrall/internal/qrdecode"
+	"remembrall/internal/search"
+	"remembrall/pkg/models"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var otpImportQRCmd = &cobra.Command{
+	Use:   "import-qr <image>...",
+	Short: "Import OTP secrets from QR code images",
+	Long: `Read the two-factor QR codes in PNG, JPEG or GIF images and store their
+secrets. You will be prompted to enter your master password.
+
+Codes may hold a single otpauth:// URI, as shown when enabling two-factor
+authentication, or a Google Authenticator export (otpauth-migration://) with
+many keys; pass every image of a multi-part export at once.
+
+Each key is matched to an existing entry by its issuer or account name and
+you are asked before the secret is attached; replacing a secret an entry
+already has defaults to no. Keys without a matching entry are saved as new
+entries named after the issuer, or "issuer/account" when an entry already
+has the issuer's name.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		imported, total, err := importOTPQRCodes(args)
+		if err != nil {
+			exitWithError("Failed to import OTP secrets: %v", err)
+		}
+
+		fmt.Printf("✓ Imported %d of %d OTP secrets\n", imported, total)
+	},
+}
+
+func importOTPQRCodes(paths []string) (int, int, error) {
+	// Read every image first, so a bad file fails before any prompt
+	var keys []*otp.Key
+	for _, path := range paths {
+		found, err := readOTPQRCode(path)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, found...)
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return 0, 0, fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	encryptor := crypto.NewEncryptor(masterPassword)
+	imported := 0
+	for _, key := range keys {
+		ok, err := importOTPKey(store, encryptor, key)
+		if err != nil {
+			return imported, len(keys), err
+		}
+		if ok {
+			imported++
+		}
+	}
+
+	return imported, len(keys), nil
+}
+
+// readOTPQRCode decodes the QR code in an image into its OTP keys
+func readOTPQRCode(path string) ([]*otp.Key, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	text, err := qrdecode.Decode(img)
+	if err != nil {
+		return nil, err
+	}
+
+	lower := strings.ToLower(text)
+	switch {
+	case strings.HasPrefix(lower, otp.MigrationScheme+"://"):
+		return otp.ParseMigration(text)
+	case strings.HasPrefix(lower, "otpauth://"):
+		key, err := otp.Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		return []*otp.Key{key}, nil
+	default:
+		return nil, fmt.Errorf("QR code does not hold an otpauth:// URI")
+	}
+}
+
+// importOTPKey attaches a key to the entry matching its issuer or account,
+// after confirmation, or saves it as a new entry. It reports whether the
+// key was stored.
+func importOTPKey(store *db.SQLiteStore, encryptor *crypto.Encryptor, key *otp.Key) (bool, error) {
+	name := key.Issuer
+	if name == "" {
+		name = key.Account
+	}
+	if name == "" {
+		fmt.Println("Skipping a key without an issuer or account name")
+		return false, nil
+	}
+
+	label := name
+	if key.Issuer != "" && key.Account != "" {
+		label = fmt.Sprintf("%s (%s)", key.Issuer, key.Account)
+	}
+
+	entries, err := store.List()
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+	match := search.FindBestMatch(entries, name)
+
+	// A second account with the same issuer gets an entry of its own
+	if key.Issuer != "" && key.Account != "" && entryNamed(entries, name) {
+		name = key.Issuer + "/" + key.Account
+	}
+	if match == nil && key.Issuer != "" && key.Account != "" {
+		match = search.FindBestMatch(entries, key.Account)
+	}
+
+	if match == nil {
+		if err := saveOTPEntry(store, encryptor, name, key); err != nil {
+			return false, err
+		}
+		fmt.Printf("Saved %s as new entry '%s'\n", label, name)
+		return true, nil
+	}
+
+	// Attach to the matching entry, keeping its other fields
+	fields, err := encryptor.DecryptFields(match.Fields)
+	if err != nil {
+		return false, fmt.Errorf("failed to decrypt fields for '%s': %w", match.AppName, err)
+	}
+
+	// Attaching defaults to yes, but replacing an existing secret to no
+	prompt := fmt.Sprintf("Attach the OTP secret for %s to '%s'? [Y/n]: ", label, match.AppName)
+	accept := func(answer string) bool { return answer == "" || answer == "y" || answer == "yes" }
+	if fields[models.FieldOTP] != "" {
+		prompt = fmt.Sprintf("'%s' already has an OTP secret, replace it with the one for %s? [y/N]: ", match.AppName, label)
+		accept = func(answer string) bool { return answer == "y" || answer == "yes" }
+	}
+	answer, err := auth.ReadLine(prompt)
+	if err != nil {
+		return false, err
+	}
+	if !accept(strings.ToLower(strings.TrimSpace(answer))) {
+		// The fuzzy match may be wrong; offer a separate entry instead
+		if !strings.EqualFold(match.AppName, name) {
+			answer, err := auth.ReadLine(fmt.Sprintf("Save it as a new entry '%s' instead? [y/N]: ", name))
+			if err != nil {
+				return false, err
+			}
+			if answer = strings.ToLower(strings.TrimSpace(answer)); answer == "y" || answer == "yes" {
+				if err := saveOTPEntry(store, encryptor, name, key); err != nil {
+					return false, err
+				}
+				fmt.Printf("Saved %s as new entry '%s'\n", label, name)
+				return true, nil
+			}
+		}
+		fmt.Printf("Skipped %s\n", label)
+		return false, nil
+	}
+
+	fields[models.FieldOTP] = key.URI()
+	if match.Fields, err = encryptor.EncryptFields(fields); err != nil {
+		return false, fmt.Errorf("failed to encrypt fields: %w", err)
+	}
+	if err := hooks.Pre(hooks.EventUpdate, match.AppName); err != nil {
+		return false, err
+	}
+	if err := store.UpdateEntry(match); err != nil {
+		return false, fmt.Errorf("failed to update in database: %w", err)
+	}
+	if err := store.SetOTPCounter(match.AppName, key.Counter); err != nil {
+		return false, err
+	}
+	if err := recordAccess(audit.ActionUpdate, match.AppName); err != nil {
+		return false, err
+	}
+	postHook(hooks.EventUpdate, match.AppName)
+
+	fmt.Printf("Attached %s to '%s'\n", label, match.AppName)
+	return true, nil
+}
+
+// entryNamed reports whether an entry has the name, ignoring case
+func entryNamed(entries []*models.PasswordEntry, name string) bool {
+	for _, entry := range entries {
+		if strings.EqualFold(entry.AppName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// saveOTPEntry stores a key as a new entry without a password
+func saveOTPEntry(store *db.SQLiteStore, encryptor *crypto.Encryptor, name string, key *otp.Key) error {
+	encryptedPassword, err := encryptor.Encrypt("")
+	if err != nil {
+		return fmt.Errorf("failed to encrypt password: %w", err)
+	}
+
+	fields := map[string]string{models.FieldOTP: key.URI()}
+	if key.Account != "" {
+		fields[models.FieldUsername] = key.Account
+	}
+	encryptedFields, err := encryptor.EncryptFields(fields)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt fields: %w", err)
+	}
+
+	if err := hooks.Pre(hooks.EventSave, name); err != nil {
+		return err
+	}
+	err = store.SaveEntry(&models.PasswordEntry{
+		AppName:    name,
+		Password:   encryptedPassword,
+		Fields:     encryptedFields,
+		OTPCounter: key.Counter,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save to database: %w", err)
+	}
+	if err := recordAccess(audit.ActionSave, name); err != nil {
+		return err
+	}
+	postHook(hooks.EventSave, name)
+
+	return nil
+}
+
+func init() {
+	otpCmd.AddCommand(otpImportQRCmd)
+}
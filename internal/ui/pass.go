@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"remembrall/internal/auth"
+	"remembrall/internal/passstore"
+	"remembrall/pkg/models"
+	"strings"
+)
+
+// passUsernameKeys are the field names pass users commonly use for the login
+var passUsernameKeys = []string{"login", "user", "username"}
+
+// loadPassKeys reads the key file given with --key, prompting for the
+// passphrase of protected OpenPGP keys
+func loadPassKeys(keyPath string) (passstore.Cipher, error) {
+	if keyPath == "" {
+		return nil, fmt.Errorf("the pass format needs --key with an OpenPGP key ring or age key file")
+	}
+
+	return passstore.LoadKeys(keyPath, func(keyDescription string) (string, error) {
+		return auth.ReadPassword(fmt.Sprintf("Enter passphrase for %s: ", keyDescription))
+	})
+}
+
+// readPass decrypts a password-store directory. Entry names keep their
+// directory path, so 'work/github.gpg' becomes 'work/github'.
+func readPass(dir, keyPath string) ([]*plainEntry, error) {
+	cipher, err := loadPassKeys(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	storeEntries, err := passstore.Read(dir, cipher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password store: %w", err)
+	}
+
+	var entries []*plainEntry
+	for _, e := range storeEntries {
+		fields := make(map[string]string)
+		for key, value := range e.Fields {
+			fields[key] = value
+		}
+		for _, key := range passUsernameKeys {
+			if value, ok := fields[key]; ok {
+				delete(fields, key)
+				setField(fields, models.FieldUsername, value)
+				break
+			}
+		}
+		setField(fields, models.FieldNotes, e.Notes)
+		setField(fields, models.FieldOTP, e.OTP)
+
+		entries = append(entries, &plainEntry{
+			AppName:  e.Name,
+			Password: e.Password,
+			Fields:   fields,
+		})
+	}
+
+	return entries, nil
+}
+
+// writePass encrypts vault entries into a password-store directory and
+// returns how many were written. Entries in a folder are written below the
+// matching subdirectory. A pass file holds a single-line password and
+// "key: value" lines, so SSH keys and multi-line passwords are skipped and
+// multi-line fields left out, each with a warning.
+func writePass(dir, keyPath string, entries []*plainEntry) (int, error) {
+	cipher, err := loadPassKeys(keyPath)
+	if err != nil {
+		return 0, err
+	}
+
+	var storeEntries []*passstore.Entry
+	for _, p := range entries {
+		if p.Type == models.EntryTypeSSHKey {
+			fmt.Fprintf(os.Stderr, "Warning: skipping '%s', SSH keys can't be stored in the pass format\n", p.AppName)
+			continue
+		}
+		if strings.ContainsAny(p.Password, "\r\n") {
+			fmt.Fprintf(os.Stderr, "Warning: skipping '%s', its password contains line breaks\n", p.AppName)
+			continue
+		}
+
+		fields := make(map[string]string)
+		for key, value := range p.Fields {
+			switch {
+			case key == models.FieldNotes, key == models.FieldOTP:
+			case strings.ContainsAny(value, "\r\n"):
+				fmt.Fprintf(os.Stderr, "Warning: leaving out field '%s' of '%s', its value contains line breaks\n", key, p.AppName)
+			default:
+				fields[key] = value
+			}
+		}
+
+		storeEntries = append(storeEntries, &passstore.Entry{
+			Name:     path.Join(p.Folder, p.AppName),
+			Password: p.Password,
+			Fields:   fields,
+			Notes:    p.Fields[models.FieldNotes],
+			OTP:      p.Fields[models.FieldOTP],
+		})
+	}
+
+	if len(storeEntries) == 0 {
+		return 0, fmt.Errorf("none of the selected entries can be stored in the pass format")
+	}
+	if err := passstore.Write(dir, storeEntries, cipher); err != nil {
+		return 0, fmt.Errorf("failed to write password store: %w", err)
+	}
+
+	return len(storeEntries), nil
+}
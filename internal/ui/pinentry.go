@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"os"
+	"remembrall/internal/pinentry"
+
+	"github.com/spf13/cobra"
+)
+
+// pinentryHelperName runs pinentry when the binary is linked under this name
+const pinentryHelperName = "pinentry-remembrall"
+
+var pinentryCmd = &cobra.Command{
+	Use:   "pinentry",
+	Short: "Pinentry program for gpg-agent backed by the vault",
+	Long: `Speak the Assuan pinentry protocol on stdin and stdout, answering
+gpg-agent's passphrase requests from the vault. Link the binary as
+` + pinentryHelperName + ` and add to ~/.gnupg/gpg-agent.conf:
+
+  pinentry-program /home/you/bin/` + pinentryHelperName + `
+
+Requests are matched against the rules in ~/` + promptRulesFile + ` (see
+'remembrall askpass --help'), trying the key info ("n/<keygrip>"), then the
+description, then the prompt. Entry names must match exactly, and yes/no
+confirmations are always declined. The command never prompts: run
+'remembrall unlock' first.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := pinentry.Serve(os.Stdin, os.Stdout, func(req *pinentry.Request) (string, error) {
+			return promptSecret(req.KeyInfo, req.Description, req.Prompt)
+		})
+		if err != nil {
+			exitWithError("pinentry: %v", err)
+		}
+	},
+}
+
+func init() {
+	// gpg-agent passes options such as --display and --ttyname, which don't apply
+	pinentryCmd.FParseErrWhitelist.UnknownFlags = true
+	rootCmd.AddCommand(pinentryCmd)
+}
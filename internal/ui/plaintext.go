@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"remembrall/pkg/models"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// plaintextConfirmation is the phrase users must type before a plaintext export
+const plaintextConfirmation = "export plaintext"
+
+// checkPlaintextDestination refuses destinations where a plaintext dump could
+// be seen by others: terminals and directories readable by every user
+func checkPlaintextDestination(path string) error {
+	if path == "-" {
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			return fmt.Errorf("refusing to write plaintext passwords to a terminal, redirect the output or give a file path")
+		}
+		return nil
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return fmt.Errorf("refusing to write plaintext passwords to a terminal or device")
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("failed to resolve '%s': %w", path, err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to inspect '%s': %w", dir, err)
+	}
+	if info.Mode().Perm()&0004 != 0 {
+		return fmt.Errorf("refusing to write plaintext passwords into world-readable directory '%s'", dir)
+	}
+
+	return nil
+}
+
+// writePlaintext writes entries as CSV or JSON to a new 0600 file, or to stdout for "-"
+func writePlaintext(format, path string, entries []*plainEntry) error {
+	var out io.Writer = os.Stdout
+	if path != "-" {
+		file, err := createPrivateFile(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if term.IsTerminal(int(file.Fd())) {
+			os.Remove(path)
+			return fmt.Errorf("refusing to write plaintext passwords to a terminal")
+		}
+		out = file
+	}
+
+	var err error
+	switch format {
+	case "csv":
+		err = writeCSV(out, entries, exportEscapeFormulas)
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(entries)
+	}
+	if err != nil {
+		if path != "-" {
+			os.Remove(path)
+		}
+		return fmt.Errorf("failed to write %s: %w", format, err)
+	}
+
+	return nil
+}
+
+// writeCSV writes one row per entry. Well-known fields get fixed columns and
+// any other fields are appended as extra columns named after the field.
+// escapeFormulas quotes names, folders and tags for spreadsheets; secrets
+// are always written as they are so the export matches the vault.
+func writeCSV(w io.Writer, entries []*plainEntry, escapeFormulas bool) error {
+	known := map[string]bool{
+		models.FieldUsername: true,
+		models.FieldURL:      true,
+		models.FieldNotes:    true,
+		models.FieldOTP:      true,
+	}
+
+	extraSet := make(map[string]bool)
+	for _, p := range entries {
+		for key := range p.Fields {
+			if !known[key] {
+				extraSet[key] = true
+			}
+		}
+	}
+	var extra []string
+	for key := range extraSet {
+		extra = append(extra, key)
+	}
+	sort.Strings(extra)
+
+	writer := csv.NewWriter(w)
+	header := []string{"app_name", "username", "password", "url", "notes", "otp", "folder", "tags", "created_at", "updated_at"}
+	if err := writer.Write(append(header, extra...)); err != nil {
+		return err
+	}
+
+	for _, p := range entries {
+		name, folder, tags := p.AppName, p.Folder, strings.Join(p.Tags, ",")
+		if escapeFormulas {
+			name, folder, tags = escapeFormula(name), escapeFormula(folder), escapeFormula(tags)
+		}
+		row := []string{
+			name,
+			p.Fields[models.FieldUsername],
+			p.Password,
+			p.Fields[models.FieldURL],
+			p.Fields[models.FieldNotes],
+			p.Fields[models.FieldOTP],
+			folder,
+			tags,
+			p.CreatedAt.Format(time.RFC3339),
+			p.UpdatedAt.Format(time.RFC3339),
+		}
+		for _, key := range extra {
+			row = append(row, p.Fields[key])
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeFormula prefixes a cell a spreadsheet would run as a formula
+// (starting with =, +, -, @, a tab or a carriage return) with a single
+// quote, so it is shown as text
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,257 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/otp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"rsc.io/qr"
+)
+
+// fieldSSID names the network of a Wi-Fi entry
+const fieldSSID = "ssid"
+
+// qrQuietZone is the light margin around codes in the terminal, in
+// modules; the standard asks for four, two is enough for phone cameras
+const qrQuietZone = 2
+
+var (
+	qrField   string
+	qrWiFi    bool
+	qrOutput  string
+	qrTimeout time.Duration
+)
+
+var qrCmd = &cobra.Command{
+	Use:   "qr <app-name>",
+	Short: "Show a secret as a QR code for scanning with a phone",
+	Long: `Show the password of an entry, or another field with --field, as a QR
+code in the terminal so it can be scanned instead of retyped. You will be
+prompted to enter your master password. The screen is cleared after
+--timeout or when Enter is pressed.
+
+With --wifi the code joins a Wi-Fi network: the entry password is the
+network key and the 'ssid' field (or the entry name) the network name.
+
+Two-factor secrets can also be written to a PNG or SVG file for
+authenticator apps that import images:
+
+  remembrall qr github --field otp -o github-2fa.png`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := showQRCode(args[0]); err != nil {
+			exitWithError("Failed to show QR code: %v", err)
+		}
+	},
+}
+
+func showQRCode(appName string) error {
+	if qrWiFi && qrField != "" {
+		return fmt.Errorf("--wifi uses the password and 'ssid' field, it cannot be combined with --field")
+	}
+
+	var format string
+	if qrOutput != "" {
+		format = strings.ToLower(filepath.Ext(qrOutput))
+		if format != ".png" && format != ".svg" {
+			return fmt.Errorf("unsupported output '%s', expected a .png or .svg file", qrOutput)
+		}
+	} else if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("not running in a terminal")
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	stored, err := findEntry(store, appName, true)
+	if err != nil {
+		return err
+	}
+	entry, err := decryptEntry(crypto.NewEncryptor(masterPassword), stored)
+	if err != nil {
+		return err
+	}
+	if err := recordAccess(audit.ActionGet, entry.AppName); err != nil {
+		return err
+	}
+
+	// Pick the text to encode
+	var text string
+	switch {
+	case qrWiFi:
+		ssid := firstField(entry, fieldSSID)
+		if ssid == "" {
+			ssid = entry.AppName
+		}
+		text = wifiURI(ssid, entry.Password)
+	case qrField == "" || qrField == fieldPassword:
+		text = entry.Password
+	default:
+		value, ok := entry.Fields[qrField]
+		if !ok {
+			return fmt.Errorf("entry '%s' has no field '%s'", entry.AppName, qrField)
+		}
+		text = value
+
+		// The stored URI keeps the counter the secret was saved with, so an
+		// HOTP key is re-encoded with the vault's current counter
+		if strings.HasPrefix(strings.ToLower(text), "otpauth://") {
+			if key, err := otp.Parse(text); err == nil && key.Type == otp.TypeHOTP {
+				key.Counter = stored.OTPCounter
+				text = key.URI()
+			}
+		}
+	}
+	if text == "" {
+		return fmt.Errorf("nothing to show, the value is empty")
+	}
+
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	// Only two-factor secrets may be written to disk, as authenticator
+	// apps import them from images
+	if qrOutput != "" {
+		if !strings.HasPrefix(strings.ToLower(text), "otpauth://") {
+			return fmt.Errorf("only otpauth:// URIs can be written to a file, other secrets are shown in the terminal")
+		}
+
+		data := code.PNG()
+		if format == ".svg" {
+			data = qrSVG(code)
+		}
+		if err := writePrivateFile(qrOutput, data); err != nil {
+			return err
+		}
+		fmt.Printf("✓ QR code for '%s' written to '%s'\n", entry.AppName, qrOutput)
+		return nil
+	}
+
+	// Clear the code on Ctrl-C as well
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	enter := make(chan struct{})
+	go func() {
+		bufio.NewReader(os.Stdin).ReadString('\n')
+		close(enter)
+	}()
+
+	auth.ClearScreen()
+	fmt.Print(qrTerminal(code))
+	if qrTimeout > 0 {
+		fmt.Printf("\nQR code for '%s', clearing in %s or when Enter is pressed\n", entry.AppName, qrTimeout)
+	} else {
+		fmt.Printf("\nQR code for '%s', press Enter to clear\n", entry.AppName)
+	}
+
+	var timeout <-chan time.Time
+	if qrTimeout > 0 {
+		timer := time.NewTimer(qrTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-timeout:
+	case <-enter:
+	case <-signals:
+	}
+	auth.ClearScreen()
+
+	return nil
+}
+
+// wifiURI builds the Wi-Fi network format understood by phone cameras
+func wifiURI(ssid, password string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+	return fmt.Sprintf("WIFI:T:WPA;S:%s;P:%s;;", escape.Replace(ssid), escape.Replace(password))
+}
+
+// qrTerminal draws a code with Unicode half blocks, two modules per
+// character cell. Colors are set explicitly so the code scans the same on
+// dark and light terminal themes.
+func qrTerminal(code *qr.Code) string {
+	const (
+		dark  = "\033[30;107m" // black on bright white
+		reset = "\033[0m"
+	)
+
+	var b strings.Builder
+	for y := -qrQuietZone; y < code.Size+qrQuietZone; y += 2 {
+		b.WriteString(dark)
+		for x := -qrQuietZone; x < code.Size+qrQuietZone; x++ {
+			// Black reports false outside the code, giving the quiet zone
+			top, bottom := code.Black(x, y), code.Black(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(reset + "\n")
+	}
+	return b.String()
+}
+
+// qrSVG draws a code as a scalable image with a four module margin
+func qrSVG(code *qr.Code) []byte {
+	size := code.Size + 8
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n", size, size)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", size, size)
+	b.WriteString(`<path fill="#000" d="`)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+4, y+4)
+			}
+		}
+	}
+	b.WriteString("\"/>\n</svg>\n")
+	return []byte(b.String())
+}
+
+func init() {
+	qrCmd.Flags().StringVar(&qrField, "field", "", "field to show instead of the password")
+	qrCmd.Flags().BoolVar(&qrWiFi, "wifi", false, "show a Wi-Fi network code from the password and 'ssid' field")
+	qrCmd.Flags().StringVarP(&qrOutput, "output", "o", "", "write an otpauth:// URI to a .png or .svg file instead")
+	qrCmd.Flags().DurationVar(&qrTimeout, "timeout", 30*time.Second, "clear the screen after this long (0 waits for Enter)")
+	rootCmd.AddCommand(qrCmd)
+}
@@ -0,0 +1,329 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/render"
+	"remembrall/pkg/models"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Entry fields read by render
+const (
+	fieldHost     = "host"
+	fieldPort     = "port"
+	fieldLogin    = "login"
+	fieldDatabase = "database"
+)
+
+// renderPollInterval is how often --watch checks the vault for changes
+const renderPollInterval = 2 * time.Second
+
+// renderFormat is a credential file render can produce
+type renderFormat struct {
+	file   string // default path, relative to the home directory
+	format func([]render.Login) []byte
+}
+
+var renderFormats = map[string]renderFormat{
+	"netrc":  {file: ".netrc", format: render.Netrc},
+	"pgpass": {file: ".pgpass", format: render.Pgpass},
+}
+
+var (
+	renderOutput string
+	renderFIFO   bool
+	renderWatch  bool
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render <netrc|pgpass>",
+	Short: "Write ~/.netrc or ~/.pgpass from tagged entries",
+	Long: `Build a credential file from the entries tagged with its name ('netrc' or
+'pgpass'). You will be prompted to enter your master password.
+
+Each entry gives the 'host' field (or the host of 'url'), the optional 'port'
+and 'database' fields (pgpass only; empty matches any), the 'login' field (or
+'username') and the entry password:
+
+  remembrall save prod-db --tag pgpass --field host=db.internal --field port=5432 --field login=app
+
+The file (~/.netrc or ~/.pgpass unless -o is given, '-' for stdout) is
+written with 0600 permissions. With --fifo a named pipe is created there
+instead and every reader gets a freshly rendered copy, so the plaintext
+never touches the disk; stop with Ctrl-C, which removes the pipe. With
+--watch the file is rewritten whenever the vault changes.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"netrc", "pgpass"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := renderCredentialFile(args[0]); err != nil {
+			exitWithError("Failed to render %s: %v", args[0], err)
+		}
+	},
+}
+
+func renderCredentialFile(name string) error {
+	format, ok := renderFormats[name]
+	if !ok {
+		return fmt.Errorf("unknown format, expected netrc or pgpass")
+	}
+	if renderFIFO && renderWatch {
+		return fmt.Errorf("--fifo always serves the current vault, --watch is not needed")
+	}
+
+	path := renderOutput
+	if path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, format.file)
+	}
+	if path == "-" && (renderFIFO || renderWatch) {
+		return fmt.Errorf("--fifo and --watch need an output file")
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	encryptor := crypto.NewEncryptor(masterPassword)
+	generate := func() ([]byte, []string, error) {
+		logins, names, err := renderLogins(encryptor, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return format.format(logins), names, nil
+	}
+
+	switch {
+	case path == "-":
+		data, names, err := generate()
+		if err != nil {
+			return err
+		}
+		if err := recordRendered(names); err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	case renderFIFO:
+		return serveFIFO(path, generate)
+	case renderWatch:
+		return watchVault(path, generate)
+	default:
+		data, names, err := generate()
+		if err != nil {
+			return err
+		}
+		if err := recordRendered(names); err != nil {
+			return err
+		}
+		if err := writePrivateFile(path, data); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Wrote %d logins to '%s'\n", len(names), path)
+		return nil
+	}
+}
+
+// recordRendered records access to the entries written out by render
+func recordRendered(names []string) error {
+	for _, name := range names {
+		if err := recordAccess(audit.ActionGet, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderLogins decrypts the entries tagged for a format, returning the
+// logins and the names of the entries they came from. Access is recorded by
+// the caller once the logins are written out.
+func renderLogins(encryptor *crypto.Encryptor, tag string) ([]render.Login, []string, error) {
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	entries, err := store.List()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+
+	var logins []render.Login
+	var names []string
+	for _, stored := range entries {
+		if !stored.HasTag(tag) {
+			continue
+		}
+		entry, err := decryptEntry(encryptor, stored)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		login := render.Login{
+			Host:     entry.Fields[fieldHost],
+			Port:     entry.Fields[fieldPort],
+			Database: entry.Fields[fieldDatabase],
+			Login:    firstField(entry, fieldLogin, models.FieldUsername),
+			Password: entry.Password,
+		}
+		if u, err := url.Parse(entry.Fields[models.FieldURL]); err == nil && u.Host != "" {
+			if login.Host == "" {
+				login.Host = u.Hostname()
+			}
+			if login.Port == "" {
+				login.Port = u.Port()
+			}
+		}
+		if login.Host == "" {
+			fmt.Fprintf(os.Stderr, "Warning: skipping '%s', it has no '%s' or 'url' field\n", entry.AppName, fieldHost)
+			continue
+		}
+		if strings.ContainsAny(login.Host+login.Port+login.Database+login.Login+login.Password, "\r\n") {
+			fmt.Fprintf(os.Stderr, "Warning: skipping '%s', its values contain line breaks\n", entry.AppName)
+			continue
+		}
+
+		logins = append(logins, login)
+		names = append(names, entry.AppName)
+	}
+
+	return logins, names, nil
+}
+
+// serveFIFO creates a named pipe at path and writes a fresh rendering to
+// every reader until interrupted
+func serveFIFO(path string, generate func() ([]byte, []string, error)) error {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			return fmt.Errorf("'%s' already exists, remove it to serve a named pipe there", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to replace '%s': %w", path, err)
+		}
+	}
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		return fmt.Errorf("failed to create named pipe '%s': %w", path, err)
+	}
+
+	// Remove the pipe on Ctrl-C, kill or when the terminal goes away; the
+	// main loop may be blocked waiting for a reader
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		<-signals
+		os.Remove(path)
+		os.Exit(0)
+	}()
+	defer os.Remove(path)
+
+	fmt.Printf("✓ Serving '%s', press Ctrl-C to stop\n", path)
+	for {
+		// Blocks until a program opens the pipe for reading
+		pipe, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open named pipe '%s': %w", path, err)
+		}
+
+		data, names, err := generate()
+		if err == nil {
+			err = recordRendered(names)
+		}
+		if err != nil {
+			pipe.Close()
+			return err
+		}
+		if _, err := pipe.Write(data); err != nil && !errors.Is(err, syscall.EPIPE) {
+			pipe.Close()
+			return fmt.Errorf("failed to write named pipe '%s': %w", path, err)
+		}
+		pipe.Close()
+
+		// Let the reader see end-of-file before the pipe is opened again
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// watchVault writes the file, then rewrites it whenever a change to the
+// vault changes its content, until interrupted. Access is only recorded when
+// the file is written, as recording it is itself a change to the vault.
+func watchVault(path string, generate func() ([]byte, []string, error)) error {
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	var lastVersion int64
+	var current []byte
+	written := false
+	ticker := time.NewTicker(renderPollInterval)
+	defer ticker.Stop()
+
+	for {
+		version, err := store.DataVersion()
+		if err != nil {
+			return err
+		}
+
+		if !written || version != lastVersion {
+			lastVersion = version
+
+			data, names, err := generate()
+			if err != nil {
+				return err
+			}
+			if !written || string(data) != string(current) {
+				if err := recordRendered(names); err != nil {
+					return err
+				}
+				if err := writePrivateFile(path, data); err != nil {
+					return err
+				}
+				current, written = data, true
+				fmt.Printf("✓ Wrote %d logins to '%s'\n", len(names), path)
+			}
+		}
+
+		select {
+		case <-signals:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func init() {
+	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "", "file to write ('-' for stdout, default ~/.netrc or ~/.pgpass)")
+	renderCmd.Flags().BoolVar(&renderFIFO, "fifo", false, "serve the file through a named pipe instead of writing it")
+	renderCmd.Flags().BoolVar(&renderWatch, "watch", false, "rewrite the file whenever the vault changes")
+	rootCmd.AddCommand(renderCmd)
+}
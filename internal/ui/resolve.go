@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"remembrall/internal/audit"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"strings"
+)
+
+// fieldPassword names the entry password in "entry:field" references
+const fieldPassword = "password"
+
+// resolver decrypts values named by "entry" or "entry:field" references.
+// Only exact entry names are accepted: a reference that doesn't match an
+// entry is an error, never a fuzzy guess. Decrypted entries are cached for
+// the lifetime of the resolver.
+type resolver struct {
+	store     *db.SQLiteStore
+	encryptor *crypto.Encryptor
+	cache     map[string]*plainEntry
+}
+
+func newResolver(store *db.SQLiteStore, encryptor *crypto.Encryptor) *resolver {
+	return &resolver{store: store, encryptor: encryptor, cache: make(map[string]*plainEntry)}
+}
+
+// resolve decrypts an "entry" or "entry:field" reference
+func (r *resolver) resolve(ref string) (string, error) {
+	return r.resolveWith(ref, ":")
+}
+
+// resolveWith decrypts a reference whose optional field follows the last
+// separator. The whole reference is tried as an entry name first, so names
+// containing the separator keep working.
+func (r *resolver) resolveWith(ref, separator string) (string, error) {
+	if _, err := findEntry(r.store, ref, false); err == nil {
+		return r.field(ref, fieldPassword)
+	}
+
+	if i := strings.LastIndex(ref, separator); i > 0 {
+		return r.field(ref[:i], ref[i+len(separator):])
+	}
+
+	return "", fmt.Errorf("no entry named '%s'", ref)
+}
+
+// field decrypts one field of an entry; "password" is the entry password
+func (r *resolver) field(appName, field string) (string, error) {
+	entry, err := r.entry(appName)
+	if err != nil {
+		return "", err
+	}
+
+	if field == "" || field == fieldPassword {
+		return entry.Password, nil
+	}
+
+	value, ok := entry.Fields[field]
+	if !ok {
+		return "", fmt.Errorf("entry '%s' has no field '%s'", appName, field)
+	}
+	return value, nil
+}
+
+// entry returns the decrypted entry with exactly this name
+func (r *resolver) entry(appName string) (*plainEntry, error) {
+	if entry, ok := r.cache[appName]; ok {
+		return entry, nil
+	}
+
+	stored, err := findEntry(r.store, appName, false)
+	if err != nil {
+		return nil, fmt.Errorf("no entry named '%s'", appName)
+	}
+
+	entry, err := decryptEntry(r.encryptor, stored)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAccess(audit.ActionGet, entry.AppName); err != nil {
+		return nil, err
+	}
+
+	r.cache[appName] = entry
+	return entry, nil
+}
@@ -3,6 +3,8 @@ package ui
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -13,6 +15,9 @@ var rootCmd = &cobra.Command{
 	Long: `Remembrall is a secure command-line password manager that helps you 
 store and retrieve passwords for various applications and websites.
 All passwords are stored with highest security standards.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		auditCommand = strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
 			cmd.Help()
@@ -20,8 +25,19 @@ All passwords are stored with highest security standards.`,
 	},
 }
 
-// Execute runs the root command
+// Execute runs the root command. When the binary is invoked under the name
+// of a helper program (such as docker-credential-remembrall) it runs that
+// helper's command, since the callers can't pass a subcommand.
 func Execute() error {
+	helpers := map[string]*cobra.Command{
+		dockerHelperName:     dockerCredentialCmd,
+		askpassHelperName:    askpassCmd,
+		pinentryHelperName:   pinentryCmd,
+		nativeHostHelperName: nativeHostCmd,
+	}
+	if cmd, ok := helpers[filepath.Base(os.Args[0])]; ok {
+		rootCmd.SetArgs(append([]string{cmd.Name()}, os.Args[1:]...))
+	}
 	return rootCmd.Execute()
 }
 
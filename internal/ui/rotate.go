@@ -0,0 +1,265 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/hooks"
+	"remembrall/pkg/models"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.design/x/clipboard"
+)
+
+var (
+	rotateLength    int
+	rotateNoSymbols bool
+	rotateConfirm   bool
+	rotateAbort     bool
+	rotateHook      bool
+)
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate <app-name>",
+	Short: "Change a password to a generated one, once the website accepts it",
+	Long: `Generate a new password for an application and copy it to the clipboard, so
+it can be set on the website. The vault keeps the old password until you
+confirm the website accepted the new one; until then the new password is
+kept pending, so neither is lost if the change fails halfway.
+
+Running rotate again resumes a pending rotation with the same new password.
+--confirm commits a pending rotation without asking, --abort drops it and
+keeps the old password. The replaced password is kept in the history. You
+will be prompted to enter your master password.
+
+For services that can be changed by a script, such as databases, set a
+rotation hook with 'remembrall hook set' and pass --hook: the hook gets the
+old and new password as JSON on stdin and the new one is committed only if
+it exits with status 0. A failed attempt is noted in the history with the
+new password, in case the hook applied it after all.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		appName, committed, err := rotatePassword(args[0])
+		if err != nil {
+			exitWithError("Failed to rotate password: %v", err)
+		}
+
+		if committed {
+			fmt.Printf("✓ Password for '%s' rotated successfully!\n", appName)
+		}
+	},
+}
+
+// rotatePassword runs the rotation of an entry and reports whether the new
+// password was committed
+func rotatePassword(appName string) (string, bool, error) {
+	if rotateConfirm && rotateAbort {
+		return "", false, fmt.Errorf("--confirm and --abort cannot be used together")
+	}
+	if rotateHook && (rotateConfirm || rotateAbort) {
+		return "", false, fmt.Errorf("--hook cannot be used with --confirm or --abort")
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return "", false, fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	// Only rotate the exact entry, never a fuzzy match
+	entry, err := findEntry(store, appName, false)
+	if err != nil {
+		return "", false, err
+	}
+	appName = entry.AppName
+
+	// A generated password would replace an SSH private key for good
+	if entry.Type != models.EntryTypePassword {
+		return "", false, fmt.Errorf("'%s' is not a password entry, only passwords can be rotated", appName)
+	}
+
+	if rotateAbort {
+		if err := store.DiscardPendingRotation(appName); err != nil {
+			return "", false, err
+		}
+		fmt.Printf("✓ Pending rotation of '%s' dropped, the old password is kept\n", appName)
+		return appName, false, nil
+	}
+
+	pending, err := store.PendingRotation(appName)
+	if err != nil {
+		return "", false, err
+	}
+	encryptor := crypto.NewEncryptor(masterPassword)
+
+	if rotateHook {
+		if err := rotateWithHook(store, encryptor, entry, pending); err != nil {
+			return "", false, err
+		}
+		return appName, true, nil
+	}
+
+	if !rotateConfirm {
+		if err := handOverPendingPassword(store, encryptor, appName, pending); err != nil {
+			return "", false, err
+		}
+		fmt.Println("Change the password on the website, then confirm here.")
+		answer, err := auth.ReadLine(fmt.Sprintf("Did the website accept the new password for '%s'? [y/N]: ", appName))
+		if err != nil {
+			return "", false, err
+		}
+		if answer = strings.ToLower(answer); answer != "y" && answer != "yes" {
+			fmt.Printf("The new password stays pending and '%s' keeps the old one.\n", appName)
+			fmt.Printf("Run 'remembrall rotate %s' again to finish, or with --abort to drop it.\n", appName)
+			return appName, false, nil
+		}
+	} else if pending == nil {
+		return "", false, fmt.Errorf("no rotation of '%s' is pending", appName)
+	}
+
+	// Commit the new password, keeping the old one in the history
+	if err := hooks.Pre(hooks.EventUpdate, appName); err != nil {
+		return "", false, err
+	}
+	if err := store.CommitPendingRotation(appName, "rotated"); err != nil {
+		return "", false, err
+	}
+	if err := recordAccess(audit.ActionUpdate, appName); err != nil {
+		return "", false, err
+	}
+	postHook(hooks.EventUpdate, appName)
+
+	return appName, true, nil
+}
+
+// handOverPendingPassword copies the pending password of an entry to the
+// clipboard, generating and keeping one first when none is pending
+func handOverPendingPassword(store *db.SQLiteStore, encryptor *crypto.Encryptor, appName string, pending *models.PendingRotation) error {
+	// Check the clipboard first so no rotation is left pending for nothing
+	if err := clipboard.Init(); err != nil {
+		return fmt.Errorf("failed to copy password to clipboard: %w", err)
+	}
+
+	newPassword, err := pendingPassword(store, encryptor, appName, pending)
+	if err != nil {
+		return err
+	}
+
+	if err := recordAccess(audit.ActionGet, appName); err != nil {
+		return err
+	}
+	clipboard.Write(clipboard.FmtText, []byte(newPassword))
+	fmt.Printf("New password for '%s' copied to clipboard.\n", appName)
+	return nil
+}
+
+// rotateWithHook has the entry's rotation hook apply a new password, and
+// commits it only when the hook succeeds. Either way the outcome is noted
+// in the entry's history.
+func rotateWithHook(store *db.SQLiteStore, encryptor *crypto.Encryptor, entry *models.PasswordEntry, pending *models.PendingRotation) error {
+	if entry.Hook == "" {
+		return fmt.Errorf("'%s' has no rotation hook, set one with 'remembrall hook set'", entry.AppName)
+	}
+	hook, err := hookPath(encryptor, entry)
+	if err != nil {
+		return err
+	}
+	if err := hooks.Check(hook); err != nil {
+		return err
+	}
+	if err := hooks.Pre(hooks.EventUpdate, entry.AppName); err != nil {
+		return err
+	}
+
+	oldPassword, err := encryptor.Decrypt(entry.Password)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	// The new password is kept pending first, so it survives a crash while
+	// the hook runs
+	newPassword, err := pendingPassword(store, encryptor, entry.AppName, pending)
+	if err != nil {
+		return err
+	}
+
+	// The hook receives both passwords
+	if err := recordAccess(audit.ActionGet, entry.AppName); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Running rotation hook '%s'...\n", hook)
+	hookErr := hooks.Run(hook, &hooks.Payload{
+		Event: hooks.EventRotate,
+		App:   entry.AppName,
+		Old:   oldPassword,
+		New:   newPassword,
+	})
+	if hookErr != nil {
+		note := fmt.Sprintf("rotation hook failed: %v", hookErr)
+		if err := store.FailPendingRotation(entry.AppName, note); err != nil {
+			return err
+		}
+		return fmt.Errorf("%s; the old password is kept and the new one is in 'remembrall history %s'", note, entry.AppName)
+	}
+
+	if err := store.CommitPendingRotation(entry.AppName, "rotated by hook"); err != nil {
+		return err
+	}
+	if err := recordAccess(audit.ActionUpdate, entry.AppName); err != nil {
+		return err
+	}
+	postHook(hooks.EventUpdate, entry.AppName)
+
+	return nil
+}
+
+// pendingPassword returns the pending password of an entry, generating and
+// keeping one when none is pending
+func pendingPassword(store *db.SQLiteStore, encryptor *crypto.Encryptor, appName string, pending *models.PendingRotation) (string, error) {
+	if pending != nil {
+		password, err := encryptor.Decrypt(pending.Password)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt pending password: %w", err)
+		}
+		fmt.Printf("Resuming the rotation of '%s' started on %s.\n", appName, pending.CreatedAt.Format("2006-01-02 15:04"))
+		return password, nil
+	}
+
+	password, err := crypto.GeneratePassword(crypto.PasswordOptions{Length: rotateLength, NoSymbols: rotateNoSymbols})
+	if err != nil {
+		return "", err
+	}
+	encryptedPassword, err := encryptor.Encrypt(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt password: %w", err)
+	}
+	if err := store.SavePendingRotation(appName, encryptedPassword); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+func init() {
+	rotateCmd.Flags().IntVar(&rotateLength, "length", crypto.DefaultPasswordLength, "length of the generated password")
+	rotateCmd.Flags().BoolVar(&rotateNoSymbols, "no-symbols", false, "generate a password without symbols")
+	rotateCmd.Flags().BoolVar(&rotateConfirm, "confirm", false, "commit a pending rotation without asking")
+	rotateCmd.Flags().BoolVar(&rotateAbort, "abort", false, "drop a pending rotation, keeping the old password")
+	rotateCmd.Flags().BoolVar(&rotateHook, "hook", false, "have the entry's rotation hook apply the new password")
+	rootCmd.AddCommand(rotateCmd)
+}
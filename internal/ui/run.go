@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/dotenv"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+var runEnv []string
+
+var runCmd = &cobra.Command{
+	Use:   "run [--env VAR=entry[:field]]... -- <command> [args...]",
+	Short: "Run a command with secrets in its environment",
+	Long: `Decrypt the referenced entries and run a command with them in its
+environment. Secrets are handed to the command directly and never written to disk.
+
+Mappings come from the project's ` + projectEnvFile + ` file (searched in the current
+directory and its parents) and from --env flags, which take precedence.
+A reference is an exact entry name, optionally followed by ':field' to use a
+field such as 'username' instead of the password.
+
+Example:
+  remembrall run --env DB_PASSWORD=prod-db -- ./server`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runWithSecrets(args); err != nil {
+			exitWithError("Failed to run command: %v", err)
+		}
+	},
+}
+
+func runWithSecrets(args []string) error {
+	mappings, err := loadProjectMappings()
+	if err != nil {
+		return err
+	}
+	for _, flag := range runEnv {
+		key, ref, ok := strings.Cut(flag, "=")
+		if !ok || key == "" || ref == "" {
+			return fmt.Errorf("invalid --env '%s', expected VAR=entry[:field]", flag)
+		}
+		mappings = append(mappings, dotenv.Var{Key: key, Value: ref})
+	}
+	if len(mappings) == 0 {
+		return fmt.Errorf("nothing to inject, use --env or create %s", projectEnvFile)
+	}
+
+	program, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	vars, err := resolveMappings(newResolver(store, crypto.NewEncryptor(masterPassword)), mappings)
+	store.Close()
+	if err != nil {
+		return err
+	}
+
+	// Later mappings override earlier ones and the inherited environment.
+	// Duplicate keys must not reach the child, where the first one would win.
+	values := make(map[string]string)
+	var order []string
+	for _, v := range vars {
+		if _, seen := values[v.Key]; !seen {
+			order = append(order, v.Key)
+		}
+		values[v.Key] = v.Value
+	}
+
+	var env []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, overridden := values[key]; !overridden {
+			env = append(env, kv)
+		}
+	}
+	for _, key := range order {
+		env = append(env, key+"="+values[key])
+	}
+
+	// Replace this process so the command owns the terminal, signals and exit status
+	return syscall.Exec(program, args, env)
+}
+
+func init() {
+	runCmd.Flags().StringArrayVar(&runEnv, "env", nil, "set VAR from an entry (VAR=entry[:field]), repeatable")
+	runCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(runCmd)
+}
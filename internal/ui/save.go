@@ -1,20 +1,47 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"remembrall/internal/audit"
 	"remembrall/internal/auth"
 	"remembrall/internal/crypto"
 	"remembrall/internal/db"
+	"remembrall/internal/hooks"
+	"remembrall/internal/otp"
+	"remembrall/pkg/models"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	saveFields []string
+	saveFolder string
+	saveTags   []string
+	saveOTPURI string
+	saveForce  bool
+)
+
 var saveCmd = &cobra.Command{
 	Use:   "save <app-name>",
 	Short: "Save a password for an application",
 	Long: `Save a password for an application or website. You will be prompted
 to enter your system password for authentication, and then the password
-to store. The password input will be hidden from the terminal.`,
+to store. The password input will be hidden from the terminal.
+
+Extra fields are stored encrypted alongside the password. Pass them as
+--field key=value, or as --field key to be prompted for a hidden value:
+
+  remembrall save aws-prod --field access_key_id=AKIA... --field secret_access_key
+
+A two-factor secret is added with --otp-uri otpauth://..., or --otp-uri - to
+be prompted for it; 'remembrall otp' then generates the codes.
+
+The password's strength and the time an offline attack would take are
+shown. Passwords weaker than the vault's password.min-strength setting are
+refused unless --force is given.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		appName := args[0]
@@ -40,12 +67,47 @@ func savePassword(appName string) error {
 		return fmt.Errorf("master password verification failed: %w", err)
 	}
 
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
 	// Prompt for application password
 	appPassword, err := auth.PromptApplicationPassword(appName)
 	if err != nil {
 		return fmt.Errorf("failed to get application password: %w", err)
 	}
 
+	// Collect extra fields, prompting for those given without a value
+	fields, err := parseFieldFlags(saveFields)
+	if err != nil {
+		return err
+	}
+
+	// Check the password against the vault's minimum strength
+	if err := checkStrength(store, appPassword, saveForce, appName, fields[models.FieldUsername]); err != nil {
+		return err
+	}
+
+	// Validate the OTP secret, keeping the HOTP counter in its own column
+	var otpCounter uint64
+	if saveOTPURI != "" {
+		uri := saveOTPURI
+		if uri == "-" {
+			if uri, err = auth.ReadPassword("Enter otpauth URI: "); err != nil {
+				return fmt.Errorf("failed to get OTP URI: %w", err)
+			}
+		}
+		key, err := otp.Parse(uri)
+		if err != nil {
+			return err
+		}
+		fields[models.FieldOTP] = strings.TrimSpace(uri)
+		otpCounter = key.Counter
+	}
+
 	// Initialize encryptor with master password
 	encryptor := crypto.NewEncryptor(masterPassword)
 	
@@ -55,22 +117,84 @@ func savePassword(appName string) error {
 		return fmt.Errorf("failed to encrypt password: %w", err)
 	}
 
-	// Initialize database store
-	store, err := db.NewSQLiteStore()
+	// Encrypt the extra fields
+	encryptedFields, err := encryptor.EncryptFields(fields)
 	if err != nil {
-		return fmt.Errorf("failed to initialize database: %w", err)
+		return fmt.Errorf("failed to encrypt fields: %w", err)
 	}
-	defer store.Close()
 
 	// Save encrypted password to database
-	err = store.Save(appName, encryptedPassword)
+	if err := hooks.Pre(hooks.EventSave, appName); err != nil {
+		return err
+	}
+	err = store.SaveEntry(&models.PasswordEntry{
+		AppName:    appName,
+		Password:   encryptedPassword,
+		Fields:     encryptedFields,
+		Folder:     saveFolder,
+		Tags:       saveTags,
+		OTPCounter: otpCounter,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to save to database: %w", err)
 	}
 
+	if err := recordAccess(audit.ActionSave, appName); err != nil {
+		return err
+	}
+	postHook(hooks.EventSave, appName)
+
 	return nil
 }
 
+// checkStrength rates a new password for an entry against the vault's
+// minimum strength; with force a weak password is only warned about
+func checkStrength(store *db.SQLiteStore, password string, force bool, names ...string) error {
+	minScore, err := store.MinStrength()
+	if err != nil {
+		return err
+	}
+
+	err = auth.CheckPasswordStrength(password, minScore, names...)
+	if errors.Is(err, auth.ErrWeakPassword) {
+		if force {
+			fmt.Fprintf(os.Stderr, "%v, storing it anyway (--force)\n", err)
+			return nil
+		}
+		return fmt.Errorf("%w; choose a stronger one or pass --force", err)
+	}
+	return err
+}
+
+// parseFieldFlags turns key=value flags into fields. A key without a value
+// is prompted for, so secrets need not appear in the shell history.
+func parseFieldFlags(flags []string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for _, flag := range flags {
+		key, value, ok := strings.Cut(flag, "=")
+		key = strings.TrimSpace(key)
+		if key == "" || key == fieldPassword {
+			return nil, fmt.Errorf("invalid --field '%s', expected key=value or key", flag)
+		}
+
+		if !ok {
+			var err error
+			value, err = auth.ReadPassword(fmt.Sprintf("Enter %s: ", key))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get field '%s': %w", key, err)
+			}
+		}
+		fields[key] = value
+	}
+
+	return fields, nil
+}
+
 func init() {
+	saveCmd.Flags().StringArrayVar(&saveFields, "field", nil, "extra field as key=value, or key to be prompted (repeatable)")
+	saveCmd.Flags().StringVar(&saveFolder, "folder", "", "folder to file the entry under")
+	saveCmd.Flags().StringArrayVar(&saveTags, "tag", nil, "tag for the entry (repeatable)")
+	saveCmd.Flags().StringVar(&saveOTPURI, "otp-uri", "", "otpauth:// URI of a two-factor secret ('-' to be prompted)")
+	saveCmd.Flags().BoolVar(&saveForce, "force", false, "store the password even if it is weaker than password.min-strength")
 	rootCmd.AddCommand(saveCmd)
 }
\ No newline at end of file
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"remembrall/internal/agent"
+	"remembrall/internal/apiserver"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveSocket string
+	serveListen string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the vault over a local JSON API",
+	Long: `Serve a versioned JSON API (list, get, search, save, update, delete,
+generate) for local tools. You will be prompted to enter your master password.
+
+By default the API listens on ~/.remembrall-api.sock, which only you can open;
+requests there may omit a token for full access. With --listen it listens on a
+loopback address instead and every request needs a bearer token:
+
+  remembrall api-token create ci --read-only --tag ci
+  curl -H "Authorization: Bearer $TOKEN" http://127.0.0.1:7890/v1/entries
+
+The API is described at /v1/openapi.yaml, and remembrall/pkg/client is a Go client.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := serveAPI(serveSocket, serveListen); err != nil {
+			exitWithError("API server failed: %v", err)
+		}
+	},
+}
+
+func serveAPI(socket, address string) error {
+	if address != "" && socket != "" {
+		return fmt.Errorf("use either --socket or --listen")
+	}
+	if address != "" {
+		if err := checkLoopback(address); err != nil {
+			return err
+		}
+	}
+	if address == "" && socket == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+		socket = filepath.Join(homeDir, ".remembrall-api.sock")
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	var listener net.Listener
+	if address != "" {
+		listener, err = net.Listen("tcp", address)
+		if err != nil {
+			return fmt.Errorf("failed to listen on '%s': %w", address, err)
+		}
+	} else {
+		listener, err = agent.Listen(socket)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(socket)
+	}
+
+	server := &http.Server{
+		Handler:           apiserver.NewServer(store, store, crypto.NewEncryptor(masterPassword), address != "").Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Stop cleanly on Ctrl-C, kill or when the terminal goes away
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		<-signals
+		server.Close()
+	}()
+
+	fmt.Printf("✓ Serving the API on %s\n", listener.Addr())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// checkLoopback refuses addresses other machines could reach
+func checkLoopback(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address '%s': %w", address, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("refusing to listen on '%s', only loopback addresses are allowed", address)
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveSocket, "socket", "", "Unix socket path (default ~/.remembrall-api.sock)")
+	serveCmd.Flags().StringVar(&serveListen, "listen", "", "loopback address to listen on instead, e.g. 127.0.0.1:7890")
+	rootCmd.AddCommand(serveCmd)
+}
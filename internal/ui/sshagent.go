@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"remembrall/internal/agent"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/sshkeys"
+	"remembrall/pkg/models"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+)
+
+var sshAgentSocket string
+
+var sshAgentCmd = &cobra.Command{
+	Use:   "ssh-agent",
+	Short: "Serve vault SSH keys to ssh",
+	Long: `Run an SSH agent that signs with the keys stored by 'remembrall ssh-key'.
+You will be prompted to enter your master password. The agent prints the
+SSH_AUTH_SOCK setting to use and runs until interrupted:
+
+  remembrall ssh-agent &
+  export SSH_AUTH_SOCK=~/.remembrall-ssh-agent.sock
+
+Keys saved with --confirm are only used after confirmation, through
+SSH_ASKPASS when it is set (unless it is remembrall-askpass, which can't
+answer) and the agent's terminal otherwise. Keys saved
+with --lifetime stop being offered once it has passed. Keys can't be added
+or removed with ssh-add.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSSHAgent(sshAgentSocket); err != nil {
+			exitWithError("SSH agent failed: %v", err)
+		}
+	},
+}
+
+func runSSHAgent(socket string) error {
+	if socket == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+		socket = filepath.Join(homeDir, ".remembrall-ssh-agent.sock")
+	}
+
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	keys, err := loadSSHKeys(store, crypto.NewEncryptor(masterPassword))
+	store.Close()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no SSH keys stored, add one with 'remembrall ssh-key generate'")
+	}
+
+	listener, err := agent.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	// Stop cleanly on Ctrl-C, kill or when the terminal goes away
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		<-signals
+		listener.Close()
+	}()
+
+	fmt.Printf("SSH_AUTH_SOCK=%s; export SSH_AUTH_SOCK;\n", socket)
+	fmt.Fprintf(os.Stderr, "✓ Serving %d SSH keys\n", len(keys))
+
+	return sshkeys.NewAgent(keys, confirmSSHKey).Serve(listener)
+}
+
+// loadSSHKeys decrypts every ssh-key entry
+func loadSSHKeys(store *db.SQLiteStore, encryptor *crypto.Encryptor) ([]*sshkeys.Key, error) {
+	entries, err := store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+
+	started := time.Now()
+	var keys []*sshkeys.Key
+	for _, entry := range entries {
+		if entry.Type != models.EntryTypeSSHKey {
+			continue
+		}
+
+		plain, err := decryptEntry(encryptor, entry)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordAccess(audit.ActionGet, entry.AppName); err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey([]byte(plain.Password))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH key '%s': %w", entry.AppName, err)
+		}
+
+		key := &sshkeys.Key{
+			Name:    entry.AppName,
+			Comment: plain.Fields[fieldSSHComment],
+			Signer:  signer,
+			Confirm: plain.Fields[fieldSSHConfirm] == "true",
+		}
+		if lifetime := plain.Fields[fieldSSHLifetime]; lifetime != "" {
+			d, err := time.ParseDuration(lifetime)
+			if err != nil {
+				return nil, fmt.Errorf("invalid lifetime for SSH key '%s': %w", entry.AppName, err)
+			}
+			key.Expires = started.Add(d)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// confirmSSHKey asks whether a key may be used
+func confirmSSHKey(key *sshkeys.Key) bool {
+	return confirmWithUser(fmt.Sprintf("Allow use of SSH key '%s'?", key.Name))
+}
+
+// confirmWithUser asks the SSH agent's yes/no questions through SSH_ASKPASS
+// when it is set (as ssh-agent does) and the controlling terminal otherwise.
+// Without either the answer is no.
+func confirmWithUser(prompt string) bool {
+	if allowed, ok := confirmWithAskpass(prompt); ok {
+		return allowed
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(tty, "%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func init() {
+	sshAgentCmd.Flags().StringVar(&sshAgentSocket, "socket", "", "socket path (default ~/.remembrall-ssh-agent.sock)")
+	rootCmd.AddCommand(sshAgentCmd)
+}
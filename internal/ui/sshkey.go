@@ -0,0 +1,237 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"remembrall/internal/audit"
+	"remembrall/internal/auth"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/hooks"
+	"remembrall/internal/sshkeys"
+	"remembrall/pkg/models"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Fields of SSH key entries
+const (
+	fieldSSHPublicKey = "public_key"
+	fieldSSHComment   = "comment"
+	fieldSSHConfirm   = "confirm"
+	fieldSSHLifetime  = "lifetime"
+)
+
+var (
+	sshKeyType     string
+	sshKeyBits     int
+	sshKeyComment  string
+	sshKeyConfirm  bool
+	sshKeyLifetime time.Duration
+)
+
+var sshKeyCmd = &cobra.Command{
+	Use:   "ssh-key",
+	Short: "Store SSH private keys in the vault",
+	Long: `Generate or import SSH private keys. Keys are stored encrypted like
+passwords and are served to ssh by 'remembrall ssh-agent'.
+
+--confirm makes the agent ask before every use of the key, and --lifetime
+limits how long the agent offers it after starting.`,
+}
+
+var sshKeyGenerateCmd = &cobra.Command{
+	Use:   "generate <name>",
+	Short: "Generate a new SSH key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		publicKey, err := generateSSHKey(args[0])
+		if err != nil {
+			exitWithError("Failed to generate SSH key: %v", err)
+		}
+
+		fmt.Printf("✓ SSH key '%s' generated. Public key:\n%s\n", args[0], publicKey)
+	},
+}
+
+var sshKeyImportCmd = &cobra.Command{
+	Use:   "import <name> <file>",
+	Short: "Import an existing SSH private key",
+	Long: `Import a PEM or OpenSSH private key file. If the key is protected you
+will be prompted for its passphrase. The file itself is left in place.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		publicKey, err := importSSHKey(args[0], args[1])
+		if err != nil {
+			exitWithError("Failed to import SSH key: %v", err)
+		}
+
+		fmt.Printf("✓ SSH key '%s' imported. Public key:\n%s\n", args[0], publicKey)
+	},
+}
+
+var sshKeyPublicCmd = &cobra.Command{
+	Use:   "public <name>",
+	Short: "Print the public key of a stored SSH key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printSSHPublicKey(args[0]); err != nil {
+			exitWithError("Failed to get public key: %v", err)
+		}
+	},
+}
+
+func generateSSHKey(name string) (string, error) {
+	return saveSSHKey(name, func() (interface{}, error) {
+		return sshkeys.Generate(sshKeyType, sshKeyBits)
+	})
+}
+
+func importSSHKey(name, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+
+	return saveSSHKey(name, func() (interface{}, error) {
+		return sshkeys.Parse(data, func() (string, error) {
+			return auth.ReadPassword(fmt.Sprintf("Enter passphrase for %s: ", path))
+		})
+	})
+}
+
+// saveSSHKey stores the private key returned by loadKey as an ssh-key entry
+// and returns its public key
+func saveSSHKey(name string, loadKey func() (interface{}, error)) (string, error) {
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return "", fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	key, err := loadKey()
+	if err != nil {
+		return "", err
+	}
+
+	comment := sshKeyComment
+	if comment == "" {
+		comment = name
+	}
+	privateKey, err := sshkeys.Marshal(key, comment)
+	if err != nil {
+		return "", err
+	}
+	publicKey, err := sshkeys.AuthorizedKey(key, comment)
+	if err != nil {
+		return "", err
+	}
+
+	fields := map[string]string{
+		fieldSSHPublicKey: publicKey,
+		fieldSSHComment:   comment,
+	}
+	if sshKeyConfirm {
+		fields[fieldSSHConfirm] = strconv.FormatBool(true)
+	}
+	if sshKeyLifetime > 0 {
+		fields[fieldSSHLifetime] = sshKeyLifetime.String()
+	}
+
+	// Encrypt the private key and its fields
+	encryptor := crypto.NewEncryptor(masterPassword)
+	encryptedKey, err := encryptor.Encrypt(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+	encryptedFields, err := encryptor.EncryptFields(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt fields: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	if err := hooks.Pre(hooks.EventSave, name); err != nil {
+		return "", err
+	}
+	err = store.SaveEntry(&models.PasswordEntry{
+		AppName:  name,
+		Type:     models.EntryTypeSSHKey,
+		Password: encryptedKey,
+		Fields:   encryptedFields,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save to database: %w", err)
+	}
+	if err := recordAccess(audit.ActionSave, name); err != nil {
+		return "", err
+	}
+	postHook(hooks.EventSave, name)
+
+	return publicKey, nil
+}
+
+func printSSHPublicKey(name string) error {
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Initialize database store
+	store, err := db.NewSQLiteStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	entry, err := store.Get(name)
+	if err != nil {
+		return err
+	}
+	if entry.Type != models.EntryTypeSSHKey {
+		return fmt.Errorf("'%s' is not an SSH key", name)
+	}
+
+	fields, err := crypto.NewEncryptor(masterPassword).DecryptFields(entry.Fields)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt fields: %w", err)
+	}
+
+	fmt.Println(fields[fieldSSHPublicKey])
+	return nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{sshKeyGenerateCmd, sshKeyImportCmd} {
+		cmd.Flags().StringVar(&sshKeyComment, "comment", "", "key comment (default: the entry name)")
+		cmd.Flags().BoolVar(&sshKeyConfirm, "confirm", false, "ask before every use of the key")
+		cmd.Flags().DurationVar(&sshKeyLifetime, "lifetime", 0, "how long the agent offers the key after starting (0 for no limit)")
+	}
+	sshKeyGenerateCmd.Flags().StringVar(&sshKeyType, "type", sshkeys.TypeEd25519, "key type (ed25519, rsa)")
+	sshKeyGenerateCmd.Flags().IntVar(&sshKeyBits, "bits", 3072, "key size for RSA keys")
+
+	sshKeyCmd.AddCommand(sshKeyGenerateCmd)
+	sshKeyCmd.AddCommand(sshKeyImportCmd)
+	sshKeyCmd.AddCommand(sshKeyPublicCmd)
+	rootCmd.AddCommand(sshKeyCmd)
+}
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var tfExternalCmd = &cobra.Command{
+	Use:   "tf-external",
+	Short: "Terraform external data source program",
+	Long: `Act as the program of a Terraform 'external' data source. The query is
+read as JSON from stdin: each key names a result and each value is an
+"entry" or "entry:field" reference. The result is a flat JSON object of the
+same keys and their decrypted values:
+
+  data "external" "db" {
+    program = ["remembrall", "tf-external"]
+    query = {
+      username = "prod-db:username"
+      password = "prod-db"
+    }
+  }
+
+  # data.external.db.result.password
+
+Entry names must match exactly and a missing entry or field fails the plan.
+The command never prompts: run 'remembrall unlock' first. Note that results
+are stored in the Terraform state.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Terraform shows stderr when the program exits non-zero
+		if err := tfExternal(); err != nil {
+			exitWithError("tf-external: %v", err)
+		}
+	},
+}
+
+func tfExternal() error {
+	var query map[string]string
+	if err := json.NewDecoder(os.Stdin).Decode(&query); err != nil {
+		return fmt.Errorf("invalid query, expected a JSON object of strings: %w", err)
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	refs := make([]string, len(keys))
+	for i, key := range keys {
+		refs[i] = query[key]
+	}
+
+	values, err := resolveUnlocked(refs)
+	if err != nil {
+		return err
+	}
+
+	result := make(map[string]string, len(keys))
+	for i, key := range keys {
+		result[key] = values[i]
+	}
+	return json.NewEncoder(os.Stdout).Encode(result)
+}
+
+func init() {
+	rootCmd.AddCommand(tfExternalCmd)
+}
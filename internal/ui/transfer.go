@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"remembrall/internal/audit"
+	"remembrall/internal/crypto"
+	"remembrall/internal/db"
+	"remembrall/internal/hooks"
+	"remembrall/pkg/models"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// plainEntry is a decrypted password entry, used when moving entries
+// in and out of the vault
+type plainEntry struct {
+	AppName   string            `json:"app_name"`
+	Type      string            `json:"type,omitempty"`
+	Password  string            `json:"password"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Folder    string            `json:"folder,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// decryptEntry decrypts the password and fields of a stored entry
+func decryptEntry(encryptor *crypto.Encryptor, entry *models.PasswordEntry) (*plainEntry, error) {
+	password, err := encryptor.Decrypt(entry.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password for '%s': %w", entry.AppName, err)
+	}
+
+	fields, err := encryptor.DecryptFields(entry.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt fields for '%s': %w", entry.AppName, err)
+	}
+
+	return &plainEntry{
+		AppName:   entry.AppName,
+		Type:      entry.Type,
+		Password:  password,
+		Fields:    fields,
+		Folder:    entry.Folder,
+		Tags:      entry.Tags,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+	}, nil
+}
+
+// decryptEntries decrypts a list of stored entries, keeping their order.
+// Key derivation makes each decryption slow, so entries are decrypted in
+// parallel.
+func decryptEntries(encryptor *crypto.Encryptor, entries []*models.PasswordEntry) ([]*plainEntry, error) {
+	plain := make([]*plainEntry, len(entries))
+	errs := make([]error, len(entries))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				plain[i], errs[i] = decryptEntry(encryptor, entries[i])
+			}
+		}()
+	}
+	for i := range entries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return plain, nil
+}
+
+// saveImported encrypts and stores imported entries. Entries whose name
+// already exists in the vault are skipped rather than overwritten.
+func saveImported(store *db.SQLiteStore, encryptor *crypto.Encryptor, entries []*plainEntry) (imported, skipped int, err error) {
+	for _, p := range entries {
+		if p.AppName == "" {
+			fmt.Println("  • skipping an entry without a name")
+			skipped++
+			continue
+		}
+		if _, err := store.Get(p.AppName); err == nil {
+			fmt.Printf("  • skipping '%s': already exists\n", p.AppName)
+			skipped++
+			continue
+		}
+
+		encryptedPassword, err := encryptor.Encrypt(p.Password)
+		if err != nil {
+			return imported, skipped, fmt.Errorf("failed to encrypt password: %w", err)
+		}
+		encryptedFields, err := encryptor.EncryptFields(p.Fields)
+		if err != nil {
+			return imported, skipped, fmt.Errorf("failed to encrypt fields: %w", err)
+		}
+
+		if err := hooks.Pre(hooks.EventSave, p.AppName); err != nil {
+			return imported, skipped, err
+		}
+		err = store.SaveEntry(&models.PasswordEntry{
+			AppName:   p.AppName,
+			Type:      p.Type,
+			Password:  encryptedPassword,
+			Fields:    encryptedFields,
+			Folder:    p.Folder,
+			Tags:      p.Tags,
+			CreatedAt: p.CreatedAt,
+			UpdatedAt: p.UpdatedAt,
+		})
+		if err != nil {
+			return imported, skipped, fmt.Errorf("failed to save '%s': %w", p.AppName, err)
+		}
+		if err := recordAccess(audit.ActionSave, p.AppName); err != nil {
+			return imported, skipped, err
+		}
+		postHook(hooks.EventSave, p.AppName)
+		imported++
+	}
+
+	return imported, skipped, nil
+}
+
+// createPrivateFile creates a new file readable only by the current user,
+// refusing to overwrite an existing one
+func createPrivateFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("'%s' already exists, refusing to overwrite it", path)
+		}
+		return nil, fmt.Errorf("failed to create '%s': %w", path, err)
+	}
+	return file, nil
+}
+
+// writePrivateFile writes data to a file readable only by the current user,
+// replacing any existing content
+func writePrivateFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	// O_CREATE keeps the mode of an existing file, so tighten it explicitly
+	if err := file.Chmod(0600); err != nil {
+		return fmt.Errorf("failed to restrict permissions of '%s': %w", path, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+
+	return file.Close()
+}
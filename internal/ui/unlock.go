@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"remembrall/internal/agent"
+	"remembrall/internal/auth"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var unlockTimeout time.Duration
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Keep the vault unlocked for non-interactive helpers",
+	Long: `Start a background agent that holds the master password in memory, so
+that helpers run by other programs (such as 'git-credential') can read the
+vault without prompting. You will be prompted to enter your master password.
+
+The agent listens on a socket only you can open and exits after --timeout
+(0 keeps it running) or when 'remembrall lock' is run.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := unlockVault(unlockTimeout); err != nil {
+			exitWithError("Failed to unlock vault: %v", err)
+		}
+
+		if unlockTimeout > 0 {
+			fmt.Printf("✓ Vault unlocked for %s\n", unlockTimeout)
+		} else {
+			fmt.Println("✓ Vault unlocked until 'remembrall lock'")
+		}
+	},
+}
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Stop the unlock agent",
+	Long:  `Stop the background agent started by 'remembrall unlock' so that it forgets the master password.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := agent.Lock(); err != nil && !errors.Is(err, agent.ErrLocked) {
+			exitWithError("Failed to lock vault: %v", err)
+		}
+
+		fmt.Println("✓ Vault locked")
+	},
+}
+
+// agentCmd is the background agent started by unlock. It reads the master
+// password from stdin so it never appears in the process arguments.
+var agentCmd = &cobra.Command{
+	Use:    "agent",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runAgent(unlockTimeout); err != nil {
+			exitWithError("Agent failed: %v", err)
+		}
+	},
+}
+
+func unlockVault(timeout time.Duration) error {
+	// Initialize master password manager
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+
+	// Prompt and verify master password
+	masterPassword, err := masterMgr.PromptAndVerifyMasterPassword()
+	if err != nil {
+		return fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	// Replace an agent that is already running so the new timeout applies
+	if err := agent.Lock(); err != nil && !errors.Is(err, agent.ErrLocked) {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate remembrall: %w", err)
+	}
+
+	// Start the agent in its own session, detached from this terminal
+	daemon := exec.Command(executable, "agent", "--timeout", timeout.String())
+	daemon.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	stdin, err := daemon.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start agent: %w", err)
+	}
+	if err := daemon.Start(); err != nil {
+		return fmt.Errorf("failed to start agent: %w", err)
+	}
+	fmt.Fprintln(stdin, masterPassword)
+	stdin.Close()
+	daemon.Process.Release()
+
+	// Wait for the agent to answer
+	for i := 0; i < 50; i++ {
+		if _, err := agent.Password(); err == nil {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("agent did not start")
+}
+
+func runAgent(timeout time.Duration) error {
+	masterPassword, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read master password: %w", err)
+	}
+	masterPassword = strings.TrimSuffix(masterPassword, "\n")
+
+	path, err := agent.SocketPath()
+	if err != nil {
+		return err
+	}
+	listener, err := agent.Listen(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	return agent.Serve(listener, masterPassword, timeout)
+}
+
+// unlockedMasterPassword returns the master password held by the unlock
+// agent. It never prompts, so helpers run by other programs fail cleanly
+// instead of hanging on a terminal that isn't there.
+func unlockedMasterPassword() (string, error) {
+	masterPassword, err := agent.Password()
+	if err != nil {
+		return "", err
+	}
+
+	// Make sure the agent holds the current master password
+	masterMgr, err := auth.NewMasterPasswordManager()
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize master password manager: %w", err)
+	}
+	if err := masterMgr.VerifyMasterPassword(masterPassword); err != nil {
+		return "", fmt.Errorf("master password verification failed: %w", err)
+	}
+
+	return masterPassword, nil
+}
+
+func init() {
+	unlockCmd.Flags().DurationVar(&unlockTimeout, "timeout", 15*time.Minute, "how long the vault stays unlocked (0 for no limit)")
+	agentCmd.Flags().DurationVar(&unlockTimeout, "timeout", 0, "how long the agent runs")
+	rootCmd.AddCommand(unlockCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(agentCmd)
+}
@@ -2,20 +2,28 @@ package ui
 
 import (
 	"fmt"
+	"remembrall/internal/audit"
 	"remembrall/internal/auth"
 	"remembrall/internal/crypto"
 	"remembrall/internal/db"
+	"remembrall/internal/hooks"
 	"remembrall/internal/search"
+	"remembrall/pkg/models"
 
 	"github.com/spf13/cobra"
 )
 
+var updateForce bool
+
 var updateCmd = &cobra.Command{
 	Use:   "update <app-name>",
 	Short: "Update a password for an application",
 	Long: `Update an existing password for an application or website. You will be prompted
 to enter your system password for authentication, and then the new password
-to store. The password input will be hidden from the terminal.`,
+to store. The password input will be hidden from the terminal.
+
+Passwords weaker than the vault's password.min-strength setting are refused
+unless --force is given.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		appName := args[0]
@@ -91,6 +99,19 @@ func updatePassword(appName string) (string, error) {
 
 	// Initialize encryptor with master password
 	encryptor := crypto.NewEncryptor(masterPassword)
+
+	// Check the password against the vault's minimum strength
+	stored, err := store.Get(targetAppName)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve from database: %w", err)
+	}
+	fields, err := encryptor.DecryptFields(stored.Fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt fields: %w", err)
+	}
+	if err := checkStrength(store, newPassword, updateForce, targetAppName, fields[models.FieldUsername]); err != nil {
+		return "", err
+	}
 	
 	// Encrypt the new password
 	encryptedPassword, err := encryptor.Encrypt(newPassword)
@@ -99,14 +120,22 @@ func updatePassword(appName string) (string, error) {
 	}
 
 	// Update password in database
+	if err := hooks.Pre(hooks.EventUpdate, targetAppName); err != nil {
+		return "", err
+	}
 	err = store.Update(targetAppName, encryptedPassword)
 	if err != nil {
 		return "", fmt.Errorf("failed to update in database: %w", err)
 	}
+	if err := recordAccess(audit.ActionUpdate, targetAppName); err != nil {
+		return "", err
+	}
+	postHook(hooks.EventUpdate, targetAppName)
 
 	return targetAppName, nil
 }
 
 func init() {
+	updateCmd.Flags().BoolVar(&updateForce, "force", false, "store the password even if it is weaker than password.min-strength")
 	rootCmd.AddCommand(updateCmd)
 }
\ No newline at end of file
@@ -0,0 +1,53 @@
+// Package api defines the JSON types of the local remembrall API served by
+// 'remembrall serve'. All paths are prefixed with Version.
+package api
+
+import "time"
+
+// Version is the path prefix of the current API version
+const Version = "/v1"
+
+// EntrySummary describes an entry without its secrets
+type EntrySummary struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Folder    string    `json:"folder,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Entry is a decrypted entry
+type Entry struct {
+	EntrySummary
+	Password string            `json:"password"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+// EntryInput creates or replaces an entry. On update, omitted fields,
+// folder and tags are left unchanged.
+type EntryInput struct {
+	Name     string            `json:"name,omitempty"`
+	Password string            `json:"password"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Folder   *string           `json:"folder,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+}
+
+// GenerateRequest controls password generation
+type GenerateRequest struct {
+	Length    int  `json:"length,omitempty"`
+	NoUpper   bool `json:"no_upper,omitempty"`
+	NoDigits  bool `json:"no_digits,omitempty"`
+	NoSymbols bool `json:"no_symbols,omitempty"`
+}
+
+// GenerateResponse holds a generated password
+type GenerateResponse struct {
+	Password string `json:"password"`
+}
+
+// Error is the body of every non-2xx response
+type Error struct {
+	Error string `json:"error"`
+}
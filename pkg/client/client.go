@@ -0,0 +1,180 @@
+// Package client is a Go client for the local API served by 'remembrall serve'.
+//
+//	c := client.NewUnix("/home/me/.remembrall-api.sock", os.Getenv("REMEMBRALL_TOKEN"))
+//	entry, err := c.Get("prod-db")
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"remembrall/pkg/api"
+)
+
+// Client talks to a remembrall API server
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// Error is returned for non-2xx responses
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("remembrall API: %s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// NewUnix creates a client for a server listening on a Unix socket. The
+// token may be empty for full access over the socket.
+func NewUnix(socketPath, token string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return &Client{
+		baseURL:    "http://localhost" + api.Version,
+		token:      token,
+		httpClient: &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}
+}
+
+// NewHTTP creates a client for a server listening on a loopback address,
+// e.g. "http://127.0.0.1:7890"
+func NewHTTP(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/") + api.Version,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// List returns the entries visible to the client, optionally filtered by tag
+func (c *Client) List(tag string) ([]api.EntrySummary, error) {
+	path := "/entries"
+	if tag != "" {
+		path += "?tag=" + url.QueryEscape(tag)
+	}
+
+	var entries []api.EntrySummary
+	err := c.do(http.MethodGet, path, nil, &entries)
+	return entries, err
+}
+
+// Search fuzzy-matches entry names, best match first
+func (c *Client) Search(query string) ([]api.EntrySummary, error) {
+	var entries []api.EntrySummary
+	err := c.do(http.MethodGet, "/search?q="+url.QueryEscape(query), nil, &entries)
+	return entries, err
+}
+
+// Get returns the decrypted entry with exactly this name
+func (c *Client) Get(name string) (*api.Entry, error) {
+	var entry api.Entry
+	if err := c.do(http.MethodGet, entryPath(name), nil, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Save creates an entry
+func (c *Client) Save(input *api.EntryInput) (*api.EntrySummary, error) {
+	var summary api.EntrySummary
+	if err := c.do(http.MethodPost, "/entries", input, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// Update changes an entry; empty or nil properties of input are left unchanged
+func (c *Client) Update(name string, input *api.EntryInput) (*api.EntrySummary, error) {
+	var summary api.EntrySummary
+	if err := c.do(http.MethodPut, entryPath(name), input, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// Delete removes an entry
+func (c *Client) Delete(name string) error {
+	return c.do(http.MethodDelete, entryPath(name), nil, nil)
+}
+
+// Generate returns a random password
+func (c *Client) Generate(req *api.GenerateRequest) (string, error) {
+	if req == nil {
+		req = &api.GenerateRequest{}
+	}
+
+	var resp api.GenerateResponse
+	if err := c.do(http.MethodPost, "/generate", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.Password, nil
+}
+
+// entryPath escapes each segment of a name, keeping the slashes
+func entryPath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return "/entries/" + strings.Join(segments, "/")
+}
+
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr api.Error
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Error == "" {
+			apiErr.Error = resp.Status
+		}
+		return &Error{StatusCode: resp.StatusCode, Message: apiErr.Error}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
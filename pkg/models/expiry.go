@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// PendingRotation is a new password generated by 'rotate' that replaces
+// the entry's password once the website has accepted it
+type PendingRotation struct {
+	AppName   string    `db:"app_name"`
+	Password  string    `db:"password"` // Encrypted like the entry password
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// ExpiryPolicy returns how many days the entry's password may go unchanged:
+// its own policy if set, otherwise the strictest policy of its tags. Zero
+// means the password never expires.
+func (e *PasswordEntry) ExpiryPolicy(tagPolicies map[string]int) int {
+	if e.ExpiryDays > 0 {
+		return e.ExpiryDays
+	}
+
+	days := 0
+	for _, tag := range e.Tags {
+		if policy := tagPolicies[tag]; policy > 0 && (days == 0 || policy < days) {
+			days = policy
+		}
+	}
+	return days
+}
+
+// ExpiresAt returns when the entry's password expires under its policy;
+// ok is false when it never does
+func (e *PasswordEntry) ExpiresAt(tagPolicies map[string]int) (expiresAt time.Time, ok bool) {
+	days := e.ExpiryPolicy(tagPolicies)
+	if days == 0 {
+		return time.Time{}, false
+	}
+	return e.UpdatedAt.AddDate(0, 0, days), true
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// HistoryEntry is a previous password of an entry, kept so a failed change
+// can be rolled back
+type HistoryEntry struct {
+	ID         int       `db:"id"`
+	AppName    string    `db:"app_name"`
+	Password   string    `db:"password"` // Encrypted like the entry password
+	SetAt      time.Time `db:"set_at"`   // When the entry was last changed before this password was replaced
+	ReplacedAt time.Time `db:"replaced_at"`
+	Note       string    `db:"note"` // How the password was replaced, such as by a rotation hook
+}
@@ -2,21 +2,59 @@ package models
 
 import "time"
 
+// Well-known keys in the encrypted fields of a password entry
+const (
+	FieldUsername = "username"
+	FieldURL      = "url"
+	FieldNotes    = "notes"
+	FieldOTP      = "otp"
+)
+
+// Entry types
+const (
+	EntryTypePassword = "password"
+	EntryTypeSSHKey   = "ssh-key" // the password holds an OpenSSH private key
+)
+
 // PasswordEntry represents a stored password entry
 type PasswordEntry struct {
 	ID          int       `db:"id"`
 	AppName     string    `db:"app_name"`
+	Type        string    `db:"type"`     // One of the EntryType constants
 	Password    string    `db:"password"` // This will be encrypted
+	Fields      string    `db:"fields"`   // Encrypted JSON object of extra fields, empty if none
+	Folder      string    `db:"folder"`   // Slash-separated folder path, empty for the top level
+	Tags        []string  `db:"tags"`
+	OTPCounter  uint64    `db:"otp_counter"` // HOTP moving factor, used by the next code
+	ExpiryDays  int       `db:"expiry_days"` // Days between password changes, 0 to follow the tags' policies
+	Hook        string    `db:"hook"`        // Encrypted path of the executable applying a new password for 'rotate --hook', empty if none
 	CreatedAt   time.Time `db:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at"`
 }
 
+// HasTag reports whether the entry carries the given tag
+func (e *PasswordEntry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // PasswordStore defines the interface for password storage operations
 type PasswordStore interface {
 	Save(appName, password string) error
+	SaveEntry(entry *PasswordEntry) error
 	Get(appName string) (*PasswordEntry, error)
 	Update(appName, newPassword string) error
+	UpdateEntry(entry *PasswordEntry) error
+	NextOTPCounter(appName string) (uint64, error)
+	SetOTPCounter(appName string, counter uint64) error
+	History(appName string) ([]*HistoryEntry, error)
+	Rollback(appName string, historyID int) error
+	Delete(appName string) error
 	List() ([]*PasswordEntry, error)
 	Search(query string) ([]*PasswordEntry, error)
 	Close() error
-}
\ No newline at end of file
+}
@@ -0,0 +1,14 @@
+package models
+
+// Vault settings, stored in the vault and changed with 'remembrall config'
+const (
+	SettingHistoryLimit = "history.limit"         // previous passwords kept per entry
+	SettingMinStrength  = "password.min-strength" // lowest score, 0 to 4, of new passwords
+)
+
+// DefaultHistoryLimit is used when history.limit is not set
+const DefaultHistoryLimit = 10
+
+// DefaultMinStrength is used when password.min-strength is not set; it
+// turns away passwords rated weak or very weak
+const DefaultMinStrength = 2
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// APIToken grants a client of the local API access to the vault
+type APIToken struct {
+	ID        int       `db:"id"`
+	Name      string    `db:"name"`
+	TokenHash string    `db:"token_hash"` // Hex SHA-256 of the bearer token, which itself is never stored
+	ReadOnly  bool      `db:"read_only"`
+	Tags      []string  `db:"tags"` // When set, only entries with one of these tags are visible
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Allows reports whether the token may see an entry
+func (t *APIToken) Allows(entry *PasswordEntry) bool {
+	if len(t.Tags) == 0 {
+		return true
+	}
+	for _, tag := range t.Tags {
+		if entry.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
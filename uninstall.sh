@@ -18,6 +18,7 @@ SYSTEM_INSTALL_DIR="/usr/local/bin"
 USER_INSTALL_DIR="$HOME/.local/bin"
 DB_FILE="$HOME/.remembrall.db"
 MASTER_FILE="$HOME/.remembrall-master"
+AUDIT_FILE="$HOME/.remembrall-audit.log"
 
 # Print colored output
 print_info() {
@@ -44,6 +45,7 @@ confirm_uninstall() {
     echo "  • Remembrall binary"
     echo "  • All stored passwords ($DB_FILE)"
     echo "  • Master password verification ($MASTER_FILE)"
+    echo "  • Audit log ($AUDIT_FILE)"
     echo "  • PATH configuration (if added during installation)"
     echo ""
     read -p "Are you sure you want to uninstall Remembrall? (y/N): " -r
@@ -105,6 +107,13 @@ remove_data() {
         print_success "Removed master password file: $MASTER_FILE"
     fi
     
+    # Remove audit log
+    if [[ -f "$AUDIT_FILE" ]]; then
+        rm -f "$AUDIT_FILE"
+        REMOVED_FILES+=("Audit log")
+        print_success "Removed audit log: $AUDIT_FILE"
+    fi
+    
     if [[ ${#REMOVED_FILES[@]} -eq 0 ]]; then
         print_warning "No Remembrall data files found"
     else
@@ -177,6 +186,10 @@ verify_removal() {
         REMAINING_ITEMS+=("Master file: $MASTER_FILE")
     fi
     
+    if [[ -f "$AUDIT_FILE" ]]; then
+        REMAINING_ITEMS+=("Audit log: $AUDIT_FILE")
+    fi
+    
     if [[ ${#REMAINING_ITEMS[@]} -eq 0 ]]; then
         print_success "Remembrall has been completely removed"
     else